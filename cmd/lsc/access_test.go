@@ -0,0 +1,95 @@
+package lsc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeUserConfig installs content as the user configuration for one test
+func writeUserConfig(t *testing.T, content string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	if err := os.MkdirAll(filepath.Join(dir, "lsc"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "lsc", "config.toml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	srv := newScooter(t)
+
+	res := runLSC(t, srv, "--read-only", "vehicle", "lock")
+	assertCode(t, res.err, "permission_denied")
+	assertContains(t, res.stderr, "read-only mode: lsc vehicle.lock would send LPUSH scooter:state")
+	if srv.Exists("scooter:state") {
+		t.Error("lock was sent in read-only mode")
+	}
+
+	res = runLSC(t, srv, "--read-only", "set", "alarm.enabled", "true")
+	assertCode(t, res.err, "permission_denied")
+	if got := srv.HGet("settings", "alarm.enabled"); got != "false" {
+		t.Error("setting was written in read-only mode")
+	}
+
+	// Reading is never restricted
+	data := jsonData(t, srv, "--read-only", "status")
+	if got := lookup(t, data, "vehicle.state"); got != "parked" {
+		t.Errorf("vehicle.state = %v, want parked", got)
+	}
+}
+
+func TestAccessLists(t *testing.T) {
+	srv := newScooter(t)
+	writeUserConfig(t, `
+[access]
+deny = ["power.*"]
+`)
+
+	res := runLSC(t, srv, "power", "reboot", "--force", "--json")
+	envelope := decodeResult(t, res)
+	if envelope.Error == nil || envelope.Error.Code != "permission_denied" {
+		t.Fatalf("envelope = %+v", envelope)
+	}
+	assertContains(t, envelope.Error.Message, "lsc power.reboot is denied by the access list entry 'power.*'")
+	if srv.Exists("scooter:power") {
+		t.Error("reboot was sent although it is denied")
+	}
+
+	writeUserConfig(t, `
+[access]
+allow = ["vehicle.lock"]
+`)
+
+	// The shortcut is checked as the command it stands for
+	mustRun(t, srv, "lock", "--no-block")
+	if got, _ := srv.List("scooter:state"); len(got) != 1 || got[0] != "lock" {
+		t.Errorf("scooter:state = %v, want [lock]", got)
+	}
+	srv.Del("scooter:state")
+
+	res = runLSC(t, srv, "unlock")
+	assertCode(t, res.err, "permission_denied")
+	assertContains(t, res.stderr, "lsc vehicle.unlock is not in the access allow list")
+}
+
+func TestAccessListsPerAction(t *testing.T) {
+	srv := newScooter(t)
+	writeUserConfig(t, `
+[access]
+deny = ["power.*"]
+`)
+	mustRun(t, srv, "status")
+
+	// The bridge and the dashboard check the commands they send as the lsc
+	// command that sends them, not as themselves
+	err := lscApp.AllowAs("power.reboot", "send reboot to scooter:power")
+	assertCode(t, err, "permission_denied")
+	assertContains(t, err.Error(), "lsc power.reboot is denied by the access list entry 'power.*'")
+	if err := lscApp.AllowAs("vehicle.lock", "send lock to scooter:state"); err != nil {
+		t.Errorf("vehicle.lock: %v", err)
+	}
+}
@@ -1,14 +1,13 @@
 package lsc
 
 import (
-	"context"
-	"encoding/json"
 	"fmt"
-	"os"
+	"strconv"
 	"time"
 
 	"librescoot/lsc/internal/confirm"
 	"librescoot/lsc/internal/format"
+	"librescoot/lsc/internal/output"
 
 	"github.com/spf13/cobra"
 )
@@ -23,49 +22,31 @@ var alarmStatusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show alarm status",
 	Long:  `Display current alarm status and settings.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		// Get alarm status
-		status, err := redisClient.HGet("alarm", "status")
+		status, err := lscApp.Redis.HGet("alarm", "status")
 		if err != nil {
-			if JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"error": err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Failed to get alarm status: %v\n"), err)
-			}
-			return
+			return fmt.Errorf("failed to get alarm status: %w", err)
 		}
 
 		// Get alarm settings
-		enabled, _ := redisClient.HGet("settings", "alarm.enabled")
-		honk, _ := redisClient.HGet("settings", "alarm.honk")
-		duration, _ := redisClient.HGet("settings", "alarm.duration")
-
-		if JSONOutput {
-			// Parse duration or use default
-			durationVal := duration
-			if durationVal == "" {
-				durationVal = "10"
-			}
-
-			output, _ := json.Marshal(map[string]interface{}{
-				"status":   status,
-				"enabled":  enabled == "true",
-				"honk":     honk == "true",
-				"duration": durationVal,
-			})
-			fmt.Println(string(output))
-			return
-		}
-
-		format.PrintSection("Alarm Status")
-		format.PrintKV("Status", format.ColorizeState(status))
-		format.PrintKV("Enabled", format.ColorizeState(enabled))
-		format.PrintKV("Honk", format.SafeValueOr(honk, "false"))
-		format.PrintKV("Duration", format.SafeValueOr(duration, "10")+" seconds")
-		fmt.Println()
+		enabled, _ := lscApp.Redis.HGet("settings", "alarm.enabled")
+		honk, _ := lscApp.Redis.HGet("settings", "alarm.honk")
+		duration, _ := lscApp.Redis.HGet("settings", "alarm.duration")
+
+		return lscApp.Render(map[string]interface{}{
+			"status":   status,
+			"enabled":  enabled == "true",
+			"honk":     honk == "true",
+			"duration": format.SafeValueOr(duration, "10"),
+		}, func() {
+			format.PrintSection("Alarm Status")
+			format.PrintKV("Status", format.ColorizeState(status))
+			format.PrintKV("Enabled", format.ColorizeState(enabled))
+			format.PrintKV("Honk", format.SafeValueOr(honk, "false"))
+			format.PrintKV("Duration", format.SafeValueOr(duration, "10")+" seconds")
+			fmt.Println()
+		})
 	},
 }
 
@@ -73,115 +54,58 @@ var alarmArmCmd = &cobra.Command{
 	Use:   "arm",
 	Short: "Arm the alarm",
 	Long:  `Enable the alarm system. Will arm when vehicle enters stand-by state.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		if !JSONOutput {
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !lscApp.Structured() {
 			fmt.Println("Arming alarm...")
 		}
 
 		// Set alarm.enabled to true
-		if err := redisClient.HSet("settings", "alarm.enabled", "true"); err != nil {
-			if JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "arm",
-					"status":  "error",
-					"error":   err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Failed to enable alarm: %v\n"), err)
-			}
-			return
+		if err := lscApp.Redis.HSet("settings", "alarm.enabled", "true"); err != nil {
+			return fmt.Errorf("failed to enable alarm: %w", err)
 		}
 
 		// Publish the change
-		ctx := context.Background()
-		if err := redisClient.Publish(ctx, "settings", "alarm.enabled"); err != nil {
-			if JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "arm",
-					"status":  "warning",
-					"message": "Alarm enabled but publish failed",
-					"error":   err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Warning("Alarm enabled but publish failed: %v\n"), err)
-			}
-			return
+		ctx := cmd.Context()
+		if err := lscApp.Redis.Publish(ctx, "settings", "alarm.enabled"); err != nil {
+			return fmt.Errorf("alarm enabled but publish failed: %w", err)
 		}
 
 		if noBlock {
-			if JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "arm",
-					"status":  "enabled",
-				})
-				fmt.Println(string(output))
-			} else {
+			return lscApp.Render(map[string]interface{}{
+				"enabled": true,
+			}, func() {
 				fmt.Println(format.Success("Alarm enabled"))
-			}
-			return
+			})
 		}
 
 		// Wait for alarm to arm (if vehicle is in stand-by)
-		ctx2, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-
-		pubsub := redisClient.Subscribe(ctx2, "alarm")
-		defer pubsub.Close()
-
-		ch := pubsub.Channel()
-		timeout := time.After(10 * time.Second)
-
-		// Check current status immediately
-		status, _ := redisClient.HGet("alarm", "status")
-		if status == "armed" || status == "delay-armed" {
-			if JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command":      "arm",
-					"status":       "success",
-					"alarm_status": status,
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Println(format.Success(fmt.Sprintf("Alarm %s", status)))
-			}
-			return
+		result, err := lscApp.Confirm(ctx, confirm.Command{
+			Hash:    "alarm",
+			Field:   "status",
+			Accept:  []string{"armed", "delay-armed"},
+			Timeout: confirmTimeout(10 * time.Second),
+		})
+		if output.ClassOf(err) == output.ClassTimeout {
+			// Not an error: the alarm only arms once the vehicle is in stand-by
+			status, _ := lscApp.Redis.HGet("alarm", "status")
+			return lscApp.Render(map[string]interface{}{
+				"enabled":      true,
+				"alarm_status": status,
+				"message":      "Will arm when vehicle enters stand-by",
+			}, func() {
+				fmt.Println(format.Success("Alarm enabled (will arm when vehicle enters stand-by)"))
+			})
 		}
-
-		for {
-			select {
-			case <-timeout:
-				if JSONOutput {
-					output, _ := json.Marshal(map[string]interface{}{
-						"command": "arm",
-						"status":  "enabled",
-						"message": "Will arm when vehicle enters stand-by",
-					})
-					fmt.Println(string(output))
-				} else {
-					fmt.Println(format.Success("Alarm enabled (will arm when vehicle enters stand-by)"))
-				}
-				return
-			case msg := <-ch:
-				if msg.Payload == "status" {
-					status, _ := redisClient.HGet("alarm", "status")
-					if status == "armed" || status == "delay-armed" {
-						if JSONOutput {
-							output, _ := json.Marshal(map[string]interface{}{
-								"command":      "arm",
-								"status":       "success",
-								"alarm_status": status,
-							})
-							fmt.Println(string(output))
-						} else {
-							fmt.Println(format.Success(fmt.Sprintf("Alarm %s", status)))
-						}
-						return
-					}
-				}
-			}
+		if err != nil {
+			return err
 		}
+
+		return lscApp.Render(map[string]interface{}{
+			"enabled":      true,
+			"alarm_status": result.Value,
+		}, func() {
+			fmt.Println(format.Success(fmt.Sprintf("Alarm %s", result.Value)))
+		})
 	},
 }
 
@@ -189,83 +113,51 @@ var alarmDisarmCmd = &cobra.Command{
 	Use:   "disarm",
 	Short: "Disarm the alarm",
 	Long:  `Disable the alarm system.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		if !JSONOutput {
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !lscApp.Structured() {
 			fmt.Println("Disarming alarm...")
 		}
 
 		// Set alarm.enabled to false
-		if err := redisClient.HSet("settings", "alarm.enabled", "false"); err != nil {
-			if JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "disarm",
-					"status":  "error",
-					"error":   err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Failed to disable alarm: %v\n"), err)
-			}
-			return
+		if err := lscApp.Redis.HSet("settings", "alarm.enabled", "false"); err != nil {
+			return fmt.Errorf("failed to disable alarm: %w", err)
 		}
 
 		// Publish the change
-		ctx := context.Background()
-		if err := redisClient.Publish(ctx, "settings", "alarm.enabled"); err != nil {
-			if JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "disarm",
-					"status":  "warning",
-					"message": "Alarm disabled but publish failed",
-					"error":   err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Warning("Alarm disabled but publish failed: %v\n"), err)
-			}
-			return
+		ctx := cmd.Context()
+		if err := lscApp.Redis.Publish(ctx, "settings", "alarm.enabled"); err != nil {
+			return fmt.Errorf("alarm disabled but publish failed: %w", err)
 		}
 
-		if noBlock {
-			if JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "disarm",
-					"status":  "disabled",
-				})
-				fmt.Println(string(output))
-			} else {
+		disabled := func() error {
+			return lscApp.Render(map[string]interface{}{
+				"enabled": false,
+			}, func() {
 				fmt.Println(format.Success("Alarm disabled"))
-			}
-			return
+			})
 		}
 
-		// Wait for alarm status to change to disarmed
-		ctx2, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-
-		if err := confirm.WaitForFieldValue(ctx2, redisClient, "alarm", "status", "disarmed", 5*time.Second); err != nil {
-			if JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "disarm",
-					"status":  "disabled",
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Println(format.Success("Alarm disabled"))
-			}
-			return
+		if noBlock {
+			return disabled()
 		}
 
-		if JSONOutput {
-			output, _ := json.Marshal(map[string]interface{}{
-				"command":      "disarm",
-				"status":       "success",
-				"alarm_status": "disarmed",
-			})
-			fmt.Println(string(output))
-		} else {
+		// Wait for alarm status to change to disarmed
+		if _, err := lscApp.Confirm(ctx, confirm.Command{
+			Hash:    "alarm",
+			Field:   "status",
+			Accept:  []string{"disarmed"},
+			Timeout: confirmTimeout(5 * time.Second),
+		}); err != nil {
+			// The alarm may not have been armed in the first place
+			return disabled()
+		}
+
+		return lscApp.Render(map[string]interface{}{
+			"enabled":      false,
+			"alarm_status": "disarmed",
+		}, func() {
 			fmt.Println(format.Success("Alarm disarmed"))
-		}
+		})
 	},
 }
 
@@ -274,48 +166,37 @@ var alarmTriggerCmd = &cobra.Command{
 	Short: "Manually trigger the alarm",
 	Long:  `Manually trigger the alarm for a specified duration (in seconds). Uses alarm.duration setting if not specified.`,
 	Args:  cobra.MaximumNArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		// Get duration from args or settings
 		duration := "10"
 		if len(args) > 0 {
 			duration = args[0]
+			if _, err := strconv.Atoi(duration); err != nil {
+				return output.InvalidArgument("invalid duration '%s': must be a number of seconds", duration)
+			}
 		} else {
-			if d, err := redisClient.HGet("settings", "alarm.duration"); err == nil && d != "" {
+			if d, err := lscApp.Redis.HGet("settings", "alarm.duration"); err == nil && d != "" {
 				duration = d
 			}
 		}
 
-		if !JSONOutput {
+		if !lscApp.Structured() {
 			fmt.Printf("Triggering alarm for %s seconds...\n", duration)
 		}
 
 		// Send trigger command
-		command := fmt.Sprintf("start:%s", duration)
-		if err := redisClient.LPush("scooter:alarm", command); err != nil {
-			if JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command":  "trigger",
-					"status":   "error",
-					"error":    err.Error(),
-					"duration": duration,
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Failed to trigger alarm: %v\n"), err)
-			}
-			return
+		if err := lscApp.Send(cmd.Context(), confirm.Command{
+			List:    "scooter:alarm",
+			Payload: "start:" + duration,
+		}); err != nil {
+			return fmt.Errorf("failed to trigger alarm: %w", err)
 		}
 
-		if JSONOutput {
-			output, _ := json.Marshal(map[string]interface{}{
-				"command":  "trigger",
-				"status":   "success",
-				"duration": duration,
-			})
-			fmt.Println(string(output))
-		} else {
+		return lscApp.Render(map[string]interface{}{
+			"duration": duration,
+		}, func() {
 			fmt.Println(format.Success("Alarm triggered"))
-		}
+		})
 	},
 }
 
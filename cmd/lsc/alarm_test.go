@@ -0,0 +1,111 @@
+package lsc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAlarmStatus(t *testing.T) {
+	srv := newScooter(t)
+
+	res := mustRun(t, srv, "alarm", "status")
+	assertContains(t, res.stdout, "Status:              disarmed", "Honk:                true", "Duration:            10 seconds")
+
+	data := jsonData(t, srv, "alarm", "status")
+	if data["status"] != "disarmed" || data["enabled"] != false || data["honk"] != true || data["duration"] != "10" {
+		t.Errorf("status data = %v", data)
+	}
+}
+
+func TestAlarmArmAndDisarm(t *testing.T) {
+	srv := newScooter(t)
+	startSim(t, srv)
+
+	res := mustRun(t, srv, "alarm", "arm")
+	assertContains(t, res.stdout, "Arming alarm...", "Alarm delay-armed")
+	if enabled := srv.HGet("settings", "alarm.enabled"); enabled != "true" {
+		t.Errorf("alarm.enabled = %s, want true", enabled)
+	}
+
+	data := jsonData(t, srv, "alarm", "disarm")
+	if data["enabled"] != false || data["alarm_status"] != "disarmed" {
+		t.Errorf("disarm data = %v", data)
+	}
+
+	data = jsonData(t, srv, "alarm", "arm")
+	if status := data["alarm_status"]; status != "delay-armed" && status != "armed" {
+		t.Errorf("arm data = %v", data)
+	}
+}
+
+func TestAlarmArmWhileUnlocked(t *testing.T) {
+	srv := newScooter(t)
+
+	// Nothing arms the alarm while the scooter is parked, which is not an error
+	res := mustRun(t, srv, "--command-timeout", "200ms", "alarm", "arm")
+	assertContains(t, res.stdout, "Alarm enabled (will arm when vehicle enters stand-by)")
+
+	data := jsonData(t, srv, "--command-timeout", "200ms", "alarm", "arm")
+	if data["alarm_status"] != "disarmed" || data["message"] != "Will arm when vehicle enters stand-by" {
+		t.Errorf("arm data = %v", data)
+	}
+}
+
+func TestAlarmDisarmUnconfirmed(t *testing.T) {
+	srv := newScooter(t)
+	srv.HSet("alarm", "status", "armed")
+	srv.HSet("settings", "alarm.enabled", "true")
+
+	// Without confirmation the alarm is still reported as disabled
+	res := mustRun(t, srv, "--command-timeout", "200ms", "alarm", "disarm")
+	assertContains(t, res.stdout, "Alarm disabled")
+	if enabled := srv.HGet("settings", "alarm.enabled"); enabled != "false" {
+		t.Errorf("alarm.enabled = %s, want false", enabled)
+	}
+
+	data := jsonData(t, srv, "--command-timeout", "200ms", "alarm", "disarm")
+	if _, ok := data["alarm_status"]; ok || data["enabled"] != false {
+		t.Errorf("disarm data = %v", data)
+	}
+}
+
+func TestAlarmNoBlock(t *testing.T) {
+	srv := newScooter(t)
+	next := subscribe(t, srv, "settings")
+
+	data := jsonData(t, srv, "alarm", "arm", "--no-block")
+	if data["enabled"] != true {
+		t.Errorf("arm data = %v", data)
+	}
+	if payload := next(); payload != "alarm.enabled" {
+		t.Errorf("published %q, want alarm.enabled", payload)
+	}
+
+	data = jsonData(t, srv, "alarm", "disarm", "--no-block")
+	if data["enabled"] != false || srv.HGet("settings", "alarm.enabled") != "false" {
+		t.Errorf("disarm data = %v", data)
+	}
+}
+
+func TestAlarmTrigger(t *testing.T) {
+	srv := newScooter(t)
+
+	res := mustRun(t, srv, "alarm", "trigger", "5")
+	assertContains(t, res.stdout, "Triggering alarm for 5 seconds...", "Alarm triggered")
+	if list, _ := srv.List("scooter:alarm"); !reflect.DeepEqual(list, []string{"start:5"}) {
+		t.Errorf("scooter:alarm = %v", list)
+	}
+
+	srv.Del("scooter:alarm")
+	srv.HSet("settings", "alarm.duration", "20")
+	data := jsonData(t, srv, "alarm", "trigger")
+	if data["duration"] != "20" {
+		t.Errorf("trigger data = %v", data)
+	}
+	if list, _ := srv.List("scooter:alarm"); !reflect.DeepEqual(list, []string{"start:20"}) {
+		t.Errorf("scooter:alarm = %v", list)
+	}
+
+	res = runLSC(t, srv, "alarm", "trigger", "soon")
+	assertCode(t, res.err, "invalid_argument")
+}
@@ -0,0 +1,125 @@
+package lsc
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"librescoot/lsc/internal/audit"
+	"librescoot/lsc/internal/format"
+	"librescoot/lsc/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	auditSince  time.Duration
+	auditStream bool
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Show the record of commands that changed the scooter",
+	Long: `lsc records every operation that changes the scooter in an append-only JSONL
+log: commands pushed to scooter:* lists, settings changes, systemctl start, stop,
+enable and the like, and update installations. Each record holds the time, Unix
+user, SSH client, profile, target, lsc command, the exact key and payload and the
+result.
+
+The log is ~/.config/lsc/audit.jsonl unless configured otherwise. With a stream
+configured, records are also added to that Redis stream on the scooter:
+
+  [audit]
+  path = "/var/log/lsc/audit.jsonl"
+  stream = "lsc:audit"`,
+}
+
+var auditShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show audit records",
+	Long: `Show the records of the local audit log, or with --stream those of the
+configured Redis stream, oldest first.`,
+	Example: `  lsc audit show --since 24h
+  lsc audit show --stream --json`,
+	Args: cobra.NoArgs,
+	// The local log is read without connecting to the scooter
+	Annotations: map[string]string{annotationRedisFlag: "stream"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if auditSince < 0 {
+			return output.InvalidArgument("--since must not be negative")
+		}
+		var since time.Time
+		if auditSince > 0 {
+			since = lscApp.Now().Add(-auditSince)
+		}
+
+		var records []audit.Record
+		var err error
+		if auditStream {
+			if lscApp.Audit.Stream == "" {
+				return output.InvalidArgument("no audit stream configured; set stream in the [audit] section of the configuration")
+			}
+			records, err = audit.ReadStream(cmd.Context(), lscApp.Redis, lscApp.Audit.Stream, since)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", lscApp.Audit.Stream, err)
+			}
+		} else {
+			records, err = audit.ReadFile(lscApp.Audit.Path, since)
+			if err != nil {
+				return fmt.Errorf("failed to read audit log: %w", err)
+			}
+		}
+
+		return lscApp.Render(records, func() {
+			printAuditRecords(records)
+		})
+	},
+}
+
+// printAuditRecords prints one table row per record
+func printAuditRecords(records []audit.Record) {
+	if len(records) == 0 {
+		fmt.Println(format.Dim("No audit records"))
+		return
+	}
+	rows := make([][]string, 0, len(records))
+	for _, r := range records {
+		user := r.User
+		if r.SSHClient != "" {
+			user += "@" + r.SSHClient
+		}
+		operation := strings.Join(nonEmpty(r.Operation, r.Key, r.Field, r.Payload), " ")
+		result := format.Success(r.Result)
+		if r.Result != "ok" {
+			result = format.Error(r.Result + ": " + r.Error)
+		}
+		rows = append(rows, []string{
+			r.Time.Local().Format("2006-01-02 15:04:05"),
+			user,
+			r.Target,
+			r.Command,
+			operation,
+			result,
+		})
+	}
+	format.PrintTable([]string{"Time", "User", "Target", "Command", "Operation", "Result"}, rows)
+}
+
+// nonEmpty returns the values that are not empty
+func nonEmpty(values ...string) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func init() {
+	auditShowCmd.Flags().DurationVar(&auditSince, "since", 0, "Only show records of this period, e.g. 24h")
+	auditShowCmd.Flags().BoolVar(&auditStream, "stream", false, "Read the configured Redis stream instead of the local log")
+
+	auditCmd.AddCommand(auditShowCmd)
+	rootCmd.AddCommand(auditCmd)
+}
@@ -0,0 +1,82 @@
+package lsc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAuditLog(t *testing.T) {
+	srv := newScooter(t)
+	writeUserConfig(t, "")
+
+	mustRun(t, srv, "lock", "--no-block")
+	mustRun(t, srv, "settings", "set", "alarm.enabled", "true")
+	srv.Del("scooter:state")
+	// Denied commands do not change anything, so they are not recorded
+	runLSC(t, srv, "--read-only", "unlock")
+
+	res := mustRun(t, srv, "audit", "show", "--json")
+	records := decodeResult(t, res).Data.([]interface{})
+	if len(records) != 2 {
+		t.Fatalf("records = %v, want 2", records)
+	}
+	lock := records[0].(map[string]interface{})
+	if lock["command"] != "vehicle.lock" || lock["operation"] != "LPUSH" || lock["key"] != "scooter:state" ||
+		lock["payload"] != "lock" || lock["result"] != "ok" || lock["target"] != srv.Addr() {
+		t.Errorf("lock record = %v", lock)
+	}
+	set := records[1].(map[string]interface{})
+	if set["command"] != "settings.set" || set["operation"] != "HSET" || set["key"] != "settings" ||
+		set["field"] != "alarm.enabled" || set["payload"] != "true" {
+		t.Errorf("settings record = %v", set)
+	}
+
+	res = mustRun(t, srv, "audit", "show")
+	assertContains(t, res.stdout, "vehicle.lock", "LPUSH scooter:state lock", "HSET settings alarm.enabled true")
+}
+
+func TestAuditShowSince(t *testing.T) {
+	srv := newScooter(t)
+	writeUserConfig(t, "")
+	mustRun(t, srv, "lock", "--no-block")
+
+	path := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "lsc", "audit.jsonl")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"time":"2020-01-01T00:00:00Z","user":"old","operation":"LPUSH","key":"scooter:power","payload":"reboot","result":"ok"}` + "\n")
+	f.Close()
+
+	records := decodeResult(t, mustRun(t, srv, "audit", "show", "--json")).Data.([]interface{})
+	if len(records) != 2 {
+		t.Errorf("all records = %d, want 2", len(records))
+	}
+	records = decodeResult(t, mustRun(t, srv, "audit", "show", "--since", "24h", "--json")).Data.([]interface{})
+	if len(records) != 1 {
+		t.Errorf("records of the last 24h = %d, want 1", len(records))
+	}
+}
+
+func TestAuditStream(t *testing.T) {
+	srv := newScooter(t)
+	writeUserConfig(t, `
+[audit]
+stream = "lsc:audit"
+`)
+
+	mustRun(t, srv, "queue", "flush", "scooter:power")
+	if !srv.Exists("lsc:audit") {
+		t.Fatal("nothing was added to lsc:audit")
+	}
+
+	res := mustRun(t, srv, "audit", "show", "--stream", "--json")
+	records := decodeResult(t, res).Data.([]interface{})
+	if len(records) != 1 {
+		t.Fatalf("records = %v, want 1", records)
+	}
+	if r := records[0].(map[string]interface{}); r["operation"] != "DEL" || r["key"] != "scooter:power" || r["command"] != "queue.flush" {
+		t.Errorf("record = %v", r)
+	}
+}
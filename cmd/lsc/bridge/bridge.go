@@ -0,0 +1,18 @@
+package bridge
+
+import (
+	"librescoot/lsc/internal/app"
+
+	"github.com/spf13/cobra"
+)
+
+// NewCommand creates the bridge command and its subcommands
+func NewCommand(a *app.App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bridge",
+		Short: "Bridge scooter state and commands to other systems",
+		Long:  `Mirror Redis state hashes and fault events to other messaging systems and accept commands from them.`,
+	}
+	cmd.AddCommand(newMqttCmd(a))
+	return cmd
+}
@@ -0,0 +1,106 @@
+package bridge
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"librescoot/lsc/internal/policy"
+)
+
+// commandQueues maps the command topics to the Redis lists lsc pushes to and
+// the commands accepted on each
+var commandQueues = map[string]struct {
+	list     string
+	commands []string
+}{
+	"state":   {"scooter:state", []string{"lock", "unlock", "lock-hibernate", "force-lock"}},
+	"seatbox": {"scooter:seatbox", []string{"open"}},
+	"blinker": {"scooter:blinker", []string{"off", "left", "right", "both"}},
+	"horn":    {"scooter:horn", []string{"on", "off"}},
+	"alarm":   {"scooter:alarm", nil}, // start:<seconds>, see alarmCommand
+	"power":   {"scooter:power", []string{"run", "suspend", "hibernate", "hibernate-manual", "hibernate-timer", "reboot"}},
+}
+
+// guardedCommands are the commands lsc refuses while the scooter is in one of
+// the conditions, like the CLI commands sending them
+var guardedCommands = map[string][]policy.Condition{
+	"state force-lock":       policy.Riding,
+	"power hibernate":        policy.All,
+	"power hibernate-manual": policy.All,
+	"power hibernate-timer":  policy.All,
+	"power reboot":           policy.All,
+}
+
+// queuePaths are the dotted paths of the lsc commands sending to each queue.
+// Commands received over MQTT are checked against the access lists as these
+// commands, see actionPath.
+var queuePaths = map[string]string{
+	"state":   "vehicle",
+	"seatbox": "vehicle.open",
+	"blinker": "diag.blinkers",
+	"horn":    "diag.horn",
+	"alarm":   "alarm.trigger",
+	"power":   "power",
+}
+
+// actionPath returns the dotted path of the lsc command that sends command to
+// queue, e.g. power.reboot for reboot
+func actionPath(queue, command string) string {
+	path := queuePaths[queue]
+	switch queue {
+	case "state":
+		if command == "lock-hibernate" {
+			return path + ".hibernate"
+		}
+		return path + "." + command
+	case "power":
+		if strings.HasPrefix(command, "hibernate") {
+			return path + ".hibernate"
+		}
+		return path + "." + command
+	}
+	return path
+}
+
+// queueNames returns the command topic names, sorted
+func queueNames() []string {
+	names := make([]string, 0, len(commandQueues))
+	for name := range commandQueues {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// translateCommand validates a command received for a queue and returns the
+// Redis list and value to push
+func translateCommand(queue, command string) (string, string, error) {
+	q, ok := commandQueues[queue]
+	if !ok {
+		return "", "", fmt.Errorf("unknown command queue '%s' (valid: %s)", queue, strings.Join(queueNames(), ", "))
+	}
+	command = strings.TrimSpace(command)
+
+	if queue == "alarm" {
+		if !alarmCommand(command) {
+			return "", "", fmt.Errorf("invalid alarm command '%s': must be start:<seconds>", command)
+		}
+		return q.list, command, nil
+	}
+	if !slices.Contains(q.commands, command) {
+		return "", "", fmt.Errorf("invalid %s command '%s': must be one of %s", queue, command, strings.Join(q.commands, ", "))
+	}
+	return q.list, command, nil
+}
+
+// alarmCommand reports whether command is an alarm trigger as sent by 'lsc alarm trigger'
+func alarmCommand(command string) bool {
+	seconds, ok := strings.CutPrefix(command, "start:")
+	if !ok {
+		return false
+	}
+	_, err := strconv.Atoi(seconds)
+	return err == nil
+}
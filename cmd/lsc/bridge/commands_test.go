@@ -0,0 +1,70 @@
+package bridge
+
+import "testing"
+
+func TestTranslateCommand(t *testing.T) {
+	for _, tc := range []struct {
+		queue, command string
+		list, value    string // list is "" if the command is invalid
+	}{
+		{"state", "unlock", "scooter:state", "unlock"},
+		{"state", " force-lock\n", "scooter:state", "force-lock"},
+		{"seatbox", "open", "scooter:seatbox", "open"},
+		{"blinker", "both", "scooter:blinker", "both"},
+		{"horn", "on", "scooter:horn", "on"},
+		{"alarm", "start:30", "scooter:alarm", "start:30"},
+		{"power", "hibernate-timer", "scooter:power", "hibernate-timer"},
+		{"state", "open", "", ""},
+		{"blinker", "up", "", ""},
+		{"alarm", "stop", "", ""},
+		{"alarm", "start:soon", "", ""},
+		{"power", "", "", ""},
+		{"engine", "off", "", ""},
+	} {
+		list, value, err := translateCommand(tc.queue, tc.command)
+		if tc.list == "" {
+			if err == nil {
+				t.Errorf("%s %q: got %s %s, want an error", tc.queue, tc.command, list, value)
+			}
+			continue
+		}
+		if err != nil || list != tc.list || value != tc.value {
+			t.Errorf("%s %q: got %s %s, %v; want %s %s", tc.queue, tc.command, list, value, err, tc.list, tc.value)
+		}
+	}
+}
+
+func TestAlarmCommand(t *testing.T) {
+	for command, want := range map[string]bool{
+		"start:0":   true,
+		"start:120": true,
+		"start:":    false,
+		"start:1m":  false,
+		"stop":      false,
+		"120":       false,
+	} {
+		if got := alarmCommand(command); got != want {
+			t.Errorf("alarmCommand(%q) = %v, want %v", command, got, want)
+		}
+	}
+}
+
+func TestActionPath(t *testing.T) {
+	for _, tc := range []struct {
+		queue, command, path string
+	}{
+		{"state", "unlock", "vehicle.unlock"},
+		{"state", "lock-hibernate", "vehicle.hibernate"},
+		{"state", "force-lock", "vehicle.force-lock"},
+		{"seatbox", "open", "vehicle.open"},
+		{"blinker", "left", "diag.blinkers"},
+		{"horn", "on", "diag.horn"},
+		{"alarm", "start:30", "alarm.trigger"},
+		{"power", "hibernate-manual", "power.hibernate"},
+		{"power", "reboot", "power.reboot"},
+	} {
+		if got := actionPath(tc.queue, tc.command); got != tc.path {
+			t.Errorf("actionPath(%s, %s) = %s, want %s", tc.queue, tc.command, got, tc.path)
+		}
+	}
+}
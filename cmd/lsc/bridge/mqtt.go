@@ -0,0 +1,390 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"librescoot/lsc/cmd/lsc/diag"
+	"librescoot/lsc/cmd/lsc/service"
+	"librescoot/lsc/internal/app"
+	"librescoot/lsc/internal/confirm"
+	"librescoot/lsc/internal/output"
+	"librescoot/lsc/internal/policy"
+	"librescoot/lsc/internal/redis"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/spf13/cobra"
+)
+
+// defaultHashes are the state hashes mirrored by default
+var defaultHashes = []string{
+	"vehicle", "battery:0", "battery:1", "engine-ecu", "gps", "alarm", "power-manager", "ota",
+}
+
+// publishTimeout bounds how long a publish may wait for the broker
+const publishTimeout = 5 * time.Second
+
+func newMqttCmd(a *app.App) *cobra.Command {
+	var (
+		mqttBroker   string
+		mqttClientID string
+		mqttUsername string
+		mqttPassword string
+		mqttPrefix   string
+		mqttHashes   []string
+		mqttQoS      int
+		mqttResync   time.Duration
+	)
+	cmd := &cobra.Command{
+		Use:   "mqtt",
+		Short: "Bridge state hashes, fault events and commands to an MQTT broker",
+		Long: `Publish the scooter's state hashes and fault events to an MQTT broker as
+JSON, and push commands received over MQTT to the scooter's command queues.
+
+Topics, below --prefix (default lsc):
+  <prefix>/status              online or offline (retained, offline is the last will)
+  <prefix>/state/<hash>        Fields of a hash, e.g. lsc/state/battery/0 (retained);
+                               published when the hash changes
+  <prefix>/events/faults       New events:faults entries
+  <prefix>/command/<queue>     Commands to send, see below (subscribed)
+  <prefix>/result              Outcome of every command received
+
+Command queues and their commands:
+  state     lock, unlock, lock-hibernate, force-lock     (scooter:state)
+  seatbox   open                                         (scooter:seatbox)
+  blinker   off, left, right, both                       (scooter:blinker)
+  horn      on, off                                      (scooter:horn)
+  alarm     start:<seconds>                              (scooter:alarm)
+  power     run, suspend, hibernate, hibernate-manual,   (scooter:power)
+            hibernate-timer, reboot
+
+A command message is the command as plain text, or a JSON object like
+{"command": "unlock", "id": "42"} whose id is returned in the result.
+Commands are sent without waiting for confirmation; watch the state topics.
+
+Like the CLI, the bridge refuses force-lock while the scooter is ridden, and
+hibernate and reboot while it is ridden or charging. The result then has the
+code precondition_failed and the violations; set "force": true in a JSON
+message to send the command anyway. The access lists check each command as
+the lsc command that sends it, e.g. power.reboot or vehicle.unlock.
+
+The bridge reconnects to the broker and to Redis on its own. After every
+(re)connect all hashes are published again, and every --resync interval
+hashes whose change notification was missed are published.
+
+Examples:
+  lsc bridge mqtt --broker tcp://localhost:1883
+  lsc --ssh deep-blue bridge mqtt --broker ssl://mqtt.example.com:8883 --prefix scooters/deep-blue --username deep-blue
+
+  mosquitto_sub -t 'lsc/#' -v
+  mosquitto_pub -t lsc/command/state -m unlock`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if mqttQoS < 0 || mqttQoS > 2 {
+				return output.InvalidArgument("--qos must be 0, 1 or 2")
+			}
+			prefix := strings.TrimSuffix(mqttPrefix, "/")
+			if prefix == "" || strings.ContainsAny(prefix, "+#") {
+				return output.InvalidArgument("invalid --prefix '%s': must be a topic without wildcards", mqttPrefix)
+			}
+			if mqttResync <= 0 {
+				return output.InvalidArgument("--resync must be positive")
+			}
+			if len(mqttHashes) == 0 {
+				return output.InvalidArgument("--hashes must name at least one hash")
+			}
+
+			clientID := mqttClientID
+			if clientID == "" {
+				host, _ := os.Hostname()
+				clientID = "lsc-" + host
+			}
+			password := mqttPassword
+			if password == "" {
+				password = os.Getenv("LSC_MQTT_PASSWORD")
+			}
+
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+
+			b := &mqttBridge{
+				ctx:       ctx,
+				redis:     a.Redis,
+				clock:     a.Clock,
+				allow:     a.AllowAs,
+				broker:    mqttBroker,
+				prefix:    prefix,
+				qos:       byte(mqttQoS),
+				hashes:    append([]string(nil), mqttHashes...),
+				published: make(map[string]string),
+			}
+
+			opts := mqtt.NewClientOptions().
+				AddBroker(mqttBroker).
+				SetClientID(clientID).
+				SetUsername(mqttUsername).
+				SetPassword(password).
+				SetCleanSession(true).
+				SetAutoReconnect(true).
+				SetConnectRetry(true).
+				SetConnectRetryInterval(5*time.Second).
+				SetMaxReconnectInterval(30*time.Second).
+				SetWill(b.topic("status"), "offline", b.qos, true).
+				SetOnConnectHandler(b.onConnect).
+				SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+					b.logf("Lost connection to %s: %v", mqttBroker, err)
+				}).
+				SetReconnectingHandler(func(mqtt.Client, *mqtt.ClientOptions) {
+					b.logf("Reconnecting to %s", mqttBroker)
+				})
+			b.client = mqtt.NewClient(opts)
+
+			sigChan := make(chan os.Signal, 1)
+			signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+			defer signal.Stop(sigChan)
+
+			// Subscribe before connecting, so no change is missed after the first snapshot
+			pubsub := a.Redis.Subscribe(ctx, b.hashes...)
+			defer pubsub.Close()
+			messages := pubsub.Channel()
+
+			faults := make(chan redis.XMessage, 16)
+			go a.Redis.FollowStream(ctx, "events:faults", "$", faults)
+
+			b.logf("Connecting to %s as %s", mqttBroker, clientID)
+			// With connect retry the token completes once connected; onConnect takes over
+			b.client.Connect()
+			defer func() {
+				b.publish("status", "offline", true)
+				b.client.Disconnect(250)
+			}()
+
+			resync := time.NewTicker(mqttResync)
+			defer resync.Stop()
+
+			for {
+				select {
+				case <-sigChan:
+					return nil
+				case msg := <-messages:
+					b.publishHash(msg.Channel, false)
+				case msg := <-faults:
+					b.publishJSON("events/faults", diag.EventData(msg), false)
+				case <-resync.C:
+					b.publishHashes(false)
+				}
+			}
+		},
+	}
+	cmd.Flags().StringVar(&mqttBroker, "broker", "tcp://localhost:1883", "MQTT broker URL (tcp://, ssl:// or ws://)")
+	cmd.Flags().StringVar(&mqttClientID, "client-id", "", "MQTT client ID (default lsc-<hostname>)")
+	cmd.Flags().StringVar(&mqttUsername, "username", "", "MQTT username")
+	cmd.Flags().StringVar(&mqttPassword, "password", "", "MQTT password (prefer LSC_MQTT_PASSWORD)")
+	cmd.Flags().StringVar(&mqttPrefix, "prefix", "lsc", "Topic prefix")
+	cmd.Flags().StringSliceVar(&mqttHashes, "hashes", defaultHashes, "Redis hashes to publish")
+	cmd.Flags().IntVar(&mqttQoS, "qos", 1, "MQTT quality of service (0, 1 or 2)")
+	cmd.Flags().DurationVar(&mqttResync, "resync", 30*time.Second, "Interval for publishing hashes whose change notification was missed")
+	return cmd
+}
+
+// mqttBridge connects one MQTT client to the scooter's Redis
+type mqttBridge struct {
+	ctx   context.Context
+	redis *redis.Client
+	clock func() time.Time
+	// allow checks a command against the access lists as the lsc command
+	// that sends it
+	allow  func(path, action string) error
+	broker string
+	client mqtt.Client
+	prefix string
+	qos    byte
+	hashes []string
+
+	// published holds the last payload per hash, to skip unchanged state
+	mu        sync.Mutex
+	published map[string]string
+}
+
+// commandMessage is the JSON form of a command message
+type commandMessage struct {
+	Command string `json:"command"`
+	ID      string `json:"id,omitempty"`
+	Force   bool   `json:"force,omitempty"` // send even if the scooter's state forbids it
+}
+
+// commandResult is published for every command message
+type commandResult struct {
+	Queue   string `json:"queue"`
+	Command string `json:"command"`
+	ID      string `json:"id,omitempty"`
+	Status  string `json:"status"` // sent or error
+	Error   string `json:"error,omitempty"`
+	Code    string `json:"code,omitempty"` // failure class, as in the CLI's JSON errors
+	// Violations are why a guarded command was refused
+	Violations []policy.Violation `json:"violations,omitempty"`
+	Timestamp  int64              `json:"timestamp"`
+}
+
+// onConnect runs after every (re)connect: the broker may have lost the
+// retained state and the subscription, so both are set up again
+func (b *mqttBridge) onConnect(client mqtt.Client) {
+	b.logf("Connected to %s", b.broker)
+	client.Subscribe(b.topic("command/+"), b.qos, b.handleCommand)
+
+	// Handlers must not block the client, so publish from a goroutine
+	go func() {
+		b.publish("status", "online", true)
+		b.publishHashes(true)
+	}()
+}
+
+// handleCommand pushes a command message to its Redis list
+func (b *mqttBridge) handleCommand(_ mqtt.Client, msg mqtt.Message) {
+	queue := msg.Topic()[strings.LastIndex(msg.Topic(), "/")+1:]
+
+	var command commandMessage
+	payload := strings.TrimSpace(string(msg.Payload()))
+	if strings.HasPrefix(payload, "{") {
+		if err := json.Unmarshal([]byte(payload), &command); err != nil {
+			b.publishResult(commandResult{Queue: queue, Status: "error", Error: fmt.Sprintf("invalid JSON: %v", err)})
+			return
+		}
+	} else {
+		command.Command = payload
+	}
+
+	result := commandResult{Queue: queue, Command: command.Command, ID: command.ID, Status: "sent"}
+	list, value, err := translateCommand(queue, command.Command)
+	var sent *confirm.Result
+	if err == nil {
+		err = b.allow(actionPath(queue, value), "send "+value+" to "+list)
+	}
+	if err == nil {
+		ctx, cancel := context.WithTimeout(b.ctx, publishTimeout)
+		if result.Violations, err = b.guard(ctx, queue, value, command.Force); err == nil {
+			sent, err = confirm.Send(ctx, b.redis, confirm.Command{List: list, Payload: value, Service: service.QueueUnit(list)})
+		}
+		cancel()
+	}
+	if err != nil {
+		result.Status = "error"
+		result.Error = err.Error()
+		result.Code = output.Code(err)
+		b.logf("Command %s %s failed: %v", queue, command.Command, err)
+	} else {
+		b.logf("Command %s %s sent to %s", queue, command.Command, list)
+		if sent.Warning != "" {
+			b.logf("Warning: %s", sent.Warning)
+		}
+	}
+	b.publishResult(result)
+}
+
+// guard checks the scooter's state before a guarded command and returns the
+// conditions that hold. Unless force is set, the command is then refused.
+func (b *mqttBridge) guard(ctx context.Context, queue, command string, force bool) ([]policy.Violation, error) {
+	conditions := guardedCommands[queue+" "+command]
+	if len(conditions) == 0 {
+		return nil, nil
+	}
+	state, err := policy.Read(ctx, b.redis)
+	if err != nil {
+		return nil, err
+	}
+	violations := state.Check(conditions...)
+	if len(violations) == 0 {
+		return nil, nil
+	}
+	reasons := make([]string, len(violations))
+	for i, v := range violations {
+		reasons[i] = v.Reason
+	}
+	if force {
+		b.logf("Warning: sending %s %s although %s", queue, command, strings.Join(reasons, ", "))
+		return violations, nil
+	}
+	return violations, output.Precondition(nil, "refusing to %s: %s (set force to override)", command, strings.Join(reasons, ", "))
+}
+
+func (b *mqttBridge) publishResult(result commandResult) {
+	result.Timestamp = b.clock().UnixMilli()
+	b.publishJSON("result", result, false)
+}
+
+// publishHashes publishes every hash; force publishes unchanged hashes too
+func (b *mqttBridge) publishHashes(force bool) {
+	for _, hash := range b.hashes {
+		b.publishHash(hash, force)
+	}
+}
+
+// publishHash publishes the fields of a hash as retained state if they changed
+func (b *mqttBridge) publishHash(hash string, force bool) {
+	ctx, cancel := context.WithTimeout(b.ctx, publishTimeout)
+	defer cancel()
+	data, err := b.redis.HGetAllWithContext(ctx, hash)
+	if err != nil {
+		return
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+
+	b.mu.Lock()
+	unchanged := b.published[hash] == string(payload)
+	b.published[hash] = string(payload)
+	b.mu.Unlock()
+	if unchanged && !force {
+		return
+	}
+
+	if !b.publish("state/"+strings.ReplaceAll(hash, ":", "/"), payload, true) {
+		// Try again on the next resync
+		b.mu.Lock()
+		delete(b.published, hash)
+		b.mu.Unlock()
+	}
+}
+
+func (b *mqttBridge) publishJSON(topic string, v interface{}, retained bool) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	b.publish(topic, payload, retained)
+}
+
+// publish sends a message below the prefix and reports whether the broker took it
+func (b *mqttBridge) publish(topic string, payload interface{}, retained bool) bool {
+	if !b.client.IsConnectionOpen() {
+		return false
+	}
+	token := b.client.Publish(b.topic(topic), b.qos, retained, payload)
+	if !token.WaitTimeout(publishTimeout) {
+		b.logf("Publishing to %s timed out", b.topic(topic))
+		return false
+	}
+	if err := token.Error(); err != nil {
+		b.logf("Publishing to %s failed: %v", b.topic(topic), err)
+		return false
+	}
+	return true
+}
+
+func (b *mqttBridge) topic(name string) string {
+	return b.prefix + "/" + name
+}
+
+// logf prints a status line to stderr, timestamped by the bridge's clock
+func (b *mqttBridge) logf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "%s %s\n", b.clock().Format("15:04:05"), fmt.Sprintf(format, args...))
+}
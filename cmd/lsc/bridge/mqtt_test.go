@@ -0,0 +1,257 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"librescoot/lsc/internal/config"
+	"librescoot/lsc/internal/output"
+	"librescoot/lsc/internal/redis"
+
+	"github.com/alicebob/miniredis/v2"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// fakeClient records what the bridge publishes
+type fakeClient struct {
+	mqtt.Client
+
+	mu        sync.Mutex
+	offline   bool
+	published []fakeMessage
+}
+
+func (c *fakeClient) IsConnectionOpen() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return !c.offline
+}
+
+func (c *fakeClient) setOffline(offline bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.offline = offline
+}
+
+func (c *fakeClient) Publish(topic string, qos byte, retained bool, payload interface{}) mqtt.Token {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var data []byte
+	switch p := payload.(type) {
+	case []byte:
+		data = p
+	case string:
+		data = []byte(p)
+	}
+	c.published = append(c.published, fakeMessage{topic: topic, payload: data, retained: retained})
+	return doneToken{}
+}
+
+// messages returns what was published so far and forgets it
+func (c *fakeClient) messages() []fakeMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	published := c.published
+	c.published = nil
+	return published
+}
+
+// fakeMessage is a message published by the bridge, or one it receives
+type fakeMessage struct {
+	mqtt.Message
+
+	topic    string
+	payload  []byte
+	retained bool
+}
+
+func (m fakeMessage) Topic() string   { return m.topic }
+func (m fakeMessage) Payload() []byte { return m.payload }
+
+// doneToken is a token of a completed publish
+type doneToken struct{ mqtt.Token }
+
+func (doneToken) WaitTimeout(time.Duration) bool { return true }
+func (doneToken) Error() error                   { return nil }
+
+// newTestBridge returns a bridge on a fake client and an in-process Redis
+// holding a parked scooter
+func newTestBridge(t *testing.T) (*mqttBridge, *fakeClient, *miniredis.Miniredis) {
+	t.Helper()
+	srv := miniredis.RunT(t)
+	srv.HSet("vehicle", "state", "parked")
+	srv.HSet("vehicle", "kickstand", "down")
+	srv.HSet("engine-ecu", "speed", "0")
+	client := redis.NewClient(srv.Addr())
+	t.Cleanup(func() { client.Close() })
+
+	fake := &fakeClient{}
+	b := &mqttBridge{
+		ctx:       context.Background(),
+		redis:     client,
+		clock:     func() time.Time { return time.UnixMilli(1700000000000) },
+		allow:     func(string, string) error { return nil },
+		client:    fake,
+		prefix:    "lsc",
+		qos:       1,
+		published: make(map[string]string),
+	}
+	return b, fake, srv
+}
+
+// sendCommand passes a command message to the bridge and returns the result
+func sendCommand(t *testing.T, b *mqttBridge, fake *fakeClient, queue, payload string) commandResult {
+	t.Helper()
+	b.handleCommand(nil, fakeMessage{topic: "lsc/command/" + queue, payload: []byte(payload)})
+	for _, msg := range fake.messages() {
+		if msg.topic == "lsc/result" {
+			var result commandResult
+			if err := json.Unmarshal(msg.payload, &result); err != nil {
+				t.Fatalf("invalid result %s: %v", msg.payload, err)
+			}
+			return result
+		}
+	}
+	t.Fatalf("no result for %s %s", queue, payload)
+	return commandResult{}
+}
+
+func TestCommandGuard(t *testing.T) {
+	b, fake, srv := newTestBridge(t)
+	srv.HSet("engine-ecu", "speed", "23")
+
+	result := sendCommand(t, b, fake, "state", `{"command": "force-lock", "id": "7"}`)
+	if result.Status != "error" || result.Code != "precondition_failed" || result.ID != "7" {
+		t.Fatalf("result = %+v", result)
+	}
+	if len(result.Violations) != 1 || result.Violations[0].Condition != "moving" {
+		t.Errorf("violations = %+v", result.Violations)
+	}
+	if srv.Exists("scooter:state") {
+		t.Error("force-lock was sent to a moving scooter")
+	}
+
+	// Unguarded commands are sent whatever the state
+	if result := sendCommand(t, b, fake, "blinker", "both"); result.Status != "sent" {
+		t.Errorf("blinker result = %+v", result)
+	}
+
+	result = sendCommand(t, b, fake, "state", `{"command": "force-lock", "force": true}`)
+	if result.Status != "sent" || len(result.Violations) != 1 {
+		t.Errorf("forced result = %+v", result)
+	}
+	if got, _ := srv.Lpop("scooter:state"); got != "force-lock" {
+		t.Errorf("scooter:state = %q, want force-lock", got)
+	}
+}
+
+func TestCommandGuardCharging(t *testing.T) {
+	b, fake, srv := newTestBridge(t)
+	srv.HSet("battery:0", "present", "true")
+	srv.HSet("battery:0", "current", "3500")
+
+	result := sendCommand(t, b, fake, "power", "reboot")
+	if result.Code != "precondition_failed" || result.Violations[0].Condition != "charging" {
+		t.Fatalf("result = %+v", result)
+	}
+	if srv.Exists("scooter:power") {
+		t.Error("reboot was sent to a charging scooter")
+	}
+
+	// force-lock only cares about riding
+	if result := sendCommand(t, b, fake, "state", "force-lock"); result.Status != "sent" {
+		t.Errorf("force-lock result = %+v", result)
+	}
+}
+
+func TestCommandAccess(t *testing.T) {
+	b, fake, srv := newTestBridge(t)
+	access := config.Access{Deny: []string{"power.*", "vehicle.unlock"}}
+	var checked []string
+	b.allow = func(path, action string) error {
+		checked = append(checked, path)
+		if reason := access.Denied(path); reason != "" {
+			return output.Forbidden("lsc %s is %s; it would %s", path, reason, action)
+		}
+		return nil
+	}
+
+	for _, tc := range []struct {
+		queue, command, status string
+	}{
+		{"power", "reboot", "error"},
+		{"power", "hibernate-manual", "error"},
+		{"state", "lock", "sent"},
+		{"state", "unlock", "error"},
+	} {
+		result := sendCommand(t, b, fake, tc.queue, tc.command)
+		if result.Status != tc.status {
+			t.Errorf("%s %s: result = %+v", tc.queue, tc.command, result)
+		}
+		if tc.status == "error" && result.Code != "permission_denied" {
+			t.Errorf("%s %s: code = %s", tc.queue, tc.command, result.Code)
+		}
+	}
+	if want := []string{"power.reboot", "power.hibernate", "vehicle.lock", "vehicle.unlock"}; !slices.Equal(checked, want) {
+		t.Errorf("checked %v, want %v", checked, want)
+	}
+	if srv.Exists("scooter:power") {
+		t.Error("a denied power command was sent")
+	}
+	if got, _ := srv.List("scooter:state"); !slices.Equal(got, []string{"lock"}) {
+		t.Errorf("scooter:state = %v, want [lock]", got)
+	}
+}
+
+func TestPublishHash(t *testing.T) {
+	b, fake, srv := newTestBridge(t)
+	srv.HSet("battery:0", "charge", "87")
+
+	// publishHash publishes the hash and returns the single message it sent
+	publish := func(force bool) *fakeMessage {
+		t.Helper()
+		b.publishHash("battery:0", force)
+		published := fake.messages()
+		switch len(published) {
+		case 0:
+			return nil
+		case 1:
+			return &published[0]
+		}
+		t.Fatalf("published %d messages", len(published))
+		return nil
+	}
+
+	msg := publish(false)
+	if msg == nil || msg.topic != "lsc/state/battery/0" || !msg.retained {
+		t.Fatalf("published %+v, want retained lsc/state/battery/0", msg)
+	}
+	if string(msg.payload) != `{"charge":"87"}` {
+		t.Errorf("payload = %s", msg.payload)
+	}
+
+	// Only changes are published, unless forced
+	if msg := publish(false); msg != nil {
+		t.Errorf("unchanged hash published again: %s", msg.payload)
+	}
+	if msg := publish(true); msg == nil {
+		t.Error("forced publish was skipped")
+	}
+	srv.HSet("battery:0", "charge", "86")
+	if msg := publish(false); msg == nil || string(msg.payload) != `{"charge":"86"}` {
+		t.Errorf("changed hash published %+v", msg)
+	}
+
+	// A publish that failed is repeated on the next attempt
+	srv.HSet("battery:0", "charge", "85")
+	fake.setOffline(true)
+	publish(false)
+	fake.setOffline(false)
+	if msg := publish(false); msg == nil || string(msg.payload) != `{"charge":"85"}` {
+		t.Errorf("state missed while offline published %+v", msg)
+	}
+}
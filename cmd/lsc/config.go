@@ -0,0 +1,262 @@
+package lsc
+
+import (
+	"fmt"
+	"os"
+
+	"librescoot/lsc/internal/config"
+	"librescoot/lsc/internal/format"
+	"librescoot/lsc/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage lsc configuration and scooter profiles",
+	Long: `Manage named scooter profiles stored in the lsc configuration files.
+
+Configuration is read from /etc/lsc.conf and then ~/.config/lsc/config.toml;
+values in the user file override the system file. A profile is selected with
+--profile <name>, the LSC_PROFILE environment variable, or default-profile.
+
+Example configuration:
+
+  default-profile = "deep-blue"
+
+  [profiles.deep-blue]
+  ssh = "deep-blue"
+  output = "pretty"
+  command-timeout = "15s"
+
+  [profiles.bench]
+  redis-addr = "10.0.0.12:6379"
+  username = "lsc"
+  password = "secret"
+  db = 0
+  tls-ca = "/etc/lsc/bench-ca.pem"
+  connect-timeout = "2s"`,
+	// Config commands manage profiles themselves and never connect to Redis
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return beginCommand(cmd)
+	},
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the effective configuration",
+	Long:  `Display the configuration values of the selected profile after merging all configuration files.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+
+		name := selectedProfileName(cfg)
+		profile, err := cfg.Profile(name)
+		if err != nil {
+			return output.InvalidArgument("%w", err)
+		}
+
+		userPath, _ := config.UserPath()
+
+		values := make(map[string]interface{})
+		for _, key := range config.ProfileKeys {
+			value, _ := profile.Get(key)
+			values[key] = maskSecret(key, value)
+		}
+
+		return lscApp.Render(map[string]interface{}{
+			"profile":         name,
+			"default_profile": cfg.DefaultProfile,
+			"files":           []string{config.SystemPath, userPath},
+			"values":          values,
+		}, func() {
+			printConfig(name, cfg, profile, userPath)
+		})
+	},
+}
+
+// printConfig shows the effective configuration of a profile
+func printConfig(name string, cfg *config.Config, profile *config.Profile, userPath string) {
+	format.PrintSection("Configuration")
+	format.PrintKV("Profile", format.SafeValue(name, "(none)"))
+	format.PrintKV("Default", format.SafeValue(cfg.DefaultProfile, "(none)"))
+	format.PrintKV("System file", config.SystemPath)
+	format.PrintKV("User file", userPath)
+
+	format.PrintSection("Values")
+	for _, key := range config.ProfileKeys {
+		value, _ := profile.Get(key)
+		format.PrintKV(key, format.SafeValue(maskSecret(key, value), "(not set)"))
+	}
+	fmt.Println()
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a profile value in the user configuration",
+	Long: `Set a value in the user configuration file (~/.config/lsc/config.toml).
+
+The profile is selected with --profile or LSC_PROFILE and is created if it does
+not exist yet. An empty value removes the key from the profile.
+
+Keys:
+  ssh               Reach the scooter through an SSH tunnel (ssh config alias or user@host)
+  redis-addr        Redis server address (host:port)
+  socket            Redis unix socket path (overrides redis-addr)
+  username          Redis ACL username
+  password          Redis password
+  db                Redis database index
+  tls               Use TLS for the Redis connection (true/false)
+  tls-ca            CA bundle for verifying the Redis server
+  tls-cert          Client certificate for TLS authentication
+  tls-key           Client key for TLS authentication
+  output            Default output format (pretty, json, yaml, csv, table or
+                    template=<go-template>)
+  connect-timeout   Timeout for the initial Redis ping (e.g. 5s)
+  dial-timeout      Timeout for establishing Redis connections
+  read-timeout      Timeout for Redis socket reads
+  command-timeout   Confirmation timeout for control commands (e.g. 15s)
+  default-profile   Profile used when none is selected (no --profile needed)
+
+Examples:
+  lsc config set --profile deep-blue redis-addr 192.168.7.1:6379
+  lsc config set --profile bench command-timeout 20s
+  lsc config set default-profile deep-blue`,
+	Args: cobra.ExactArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return append(config.ProfileKeys, "default-profile"), cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key := args[0]
+		value := args[1]
+
+		userPath, err := config.UserPath()
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.ReadFile(userPath)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				return err
+			}
+			cfg = &config.Config{Profiles: make(map[string]*config.Profile)}
+		}
+
+		name := ""
+		if key == "default-profile" {
+			cfg.DefaultProfile = value
+		} else {
+			name = profileName
+			if name == "" {
+				name = os.Getenv("LSC_PROFILE")
+			}
+			if name == "" {
+				return output.InvalidArgument("no profile selected: use --profile <name>")
+			}
+
+			profile, ok := cfg.Profiles[name]
+			if !ok {
+				profile = &config.Profile{}
+				cfg.Profiles[name] = profile
+			}
+			if err := profile.Set(key, value); err != nil {
+				return output.InvalidArgument("%w", err)
+			}
+		}
+
+		if err := cfg.WriteFile(userPath); err != nil {
+			return err
+		}
+
+		return lscApp.Render(map[string]interface{}{
+			"profile": name,
+			"key":     key,
+			"value":   maskSecret(key, value),
+			"file":    userPath,
+		}, func() {
+			if name != "" {
+				fmt.Println(format.Success(fmt.Sprintf("[%s] %s = '%s'", name, key, maskSecret(key, value))))
+			} else {
+				fmt.Println(format.Success(fmt.Sprintf("%s = '%s'", key, value)))
+			}
+		})
+	},
+}
+
+var configProfilesCmd = &cobra.Command{
+	Use:   "profiles",
+	Short: "List configured profiles",
+	Long:  `List all profiles from the configuration files. The default profile is marked with *.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+
+		names := cfg.ProfileNames()
+
+		profiles := make([]map[string]interface{}, 0, len(names))
+		for _, name := range names {
+			p := cfg.Profiles[name]
+			profiles = append(profiles, map[string]interface{}{
+				"name":       name,
+				"default":    name == cfg.DefaultProfile,
+				"ssh":        p.SSH,
+				"redis_addr": p.RedisAddr,
+				"output":     p.Output,
+			})
+		}
+
+		return lscApp.Render(profiles, func() {
+			if len(names) == 0 {
+				fmt.Println(format.Dim("No profiles configured (see 'lsc config set --help')"))
+				return
+			}
+
+			rows := make([][]string, 0, len(names))
+			for _, name := range names {
+				marker := " "
+				if name == cfg.DefaultProfile {
+					marker = "*"
+				}
+				p := cfg.Profiles[name]
+				rows = append(rows, []string{marker, name, p.SSH, p.RedisAddr, p.Output})
+			}
+			format.PrintTable([]string{" ", "PROFILE", "SSH", "REDIS", "OUTPUT"}, rows)
+		})
+	},
+}
+
+// selectedProfileName returns the profile chosen by flag, environment or default-profile
+func selectedProfileName(cfg *config.Config) string {
+	if profileName != "" {
+		return profileName
+	}
+	if env := os.Getenv("LSC_PROFILE"); env != "" {
+		return env
+	}
+	return cfg.DefaultProfile
+}
+
+// maskSecret hides secret values in output
+func maskSecret(key, value string) string {
+	if key == "password" && value != "" {
+		return "********"
+	}
+	return value
+}
+
+func init() {
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configProfilesCmd)
+	rootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,320 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"librescoot/lsc/cmd/lsc/service"
+	"librescoot/lsc/internal/app"
+	"librescoot/lsc/internal/confirm"
+	"librescoot/lsc/internal/model"
+	"librescoot/lsc/internal/output"
+	"librescoot/lsc/internal/redis"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// watchedHashes are subscribed to and shown; each channel announces changes
+// of the hash with the same name
+var watchedHashes = []string{
+	model.VehicleKey, model.EngineECUKey, model.BatteryKey(0), model.BatteryKey(1), model.GPSKey,
+	model.PowerManagerKey, model.AlarmKey, model.InternetKey, model.ModemKey,
+}
+
+const (
+	// maxFaults is the number of fault events kept for scrolling
+	maxFaults = 200
+	// maxSamples is the number of sparkline samples kept per series
+	maxSamples = 120
+	// messageTimeout is how long the result of a key command is shown
+	messageTimeout = 5 * time.Second
+)
+
+// NewCommand creates the dashboard command
+func NewCommand(a *app.App) *cobra.Command {
+	var sampleInterval time.Duration
+	cmd := &cobra.Command{
+		Use:   "dashboard",
+		Short: "Live full-screen dashboard",
+		Long: `Show a full-screen dashboard of vehicle, motor, battery, GPS, power, alarm
+and modem state that updates in place from Redis pub/sub, with sparklines for
+speed, battery current and state of charge and a scrolling pane of fault events.
+
+Keys:
+  l / u      Lock / unlock
+  ← / →      Blinkers left / right
+  b / o      Hazard blinkers (both) / blinkers off
+  ↑ / ↓      Scroll the fault events
+  q, Ctrl-C  Quit`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if a.Structured() {
+				return output.InvalidArgument("dashboard has no structured output; use 'lsc watch --json' instead")
+			}
+			if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+				return output.InvalidArgument("dashboard needs an interactive terminal")
+			}
+			if sampleInterval <= 0 {
+				return output.InvalidArgument("--interval must be positive")
+			}
+
+			return newDashboard(a, sampleInterval).run()
+		},
+	}
+	cmd.Flags().DurationVar(&sampleInterval, "interval", time.Second, "Interval between sparkline samples and full refreshes")
+	return cmd
+}
+
+// dashboard holds everything shown on screen. It is only modified by the
+// event loop in run.
+type dashboard struct {
+	redis    *redis.Client
+	clock    func() time.Time
+	allow    func(path, action string) error
+	interval time.Duration
+
+	hashes  map[string]map[string]string
+	samples map[string]*series
+	faults  []redis.XMessage // oldest first
+	scroll  int              // fault lines scrolled back from the newest
+
+	message   string
+	messageAt time.Time
+}
+
+func newDashboard(a *app.App, interval time.Duration) *dashboard {
+	return &dashboard{
+		redis:    a.Redis,
+		clock:    a.Clock,
+		allow:    a.AllowAs,
+		interval: interval,
+		hashes:   make(map[string]map[string]string),
+		samples:  make(map[string]*series),
+	}
+}
+
+// run shows the dashboard until the user quits
+func (d *dashboard) run() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for _, hash := range watchedHashes {
+		d.refresh(ctx, hash)
+	}
+	d.sample()
+
+	lastID := "$"
+	if faults, err := d.redis.XRevRangeN(ctx, "events:faults", maxFaults); err == nil {
+		for i := len(faults) - 1; i >= 0; i-- {
+			d.faults = append(d.faults, faults[i])
+		}
+		if len(faults) > 0 {
+			lastID = faults[0].ID
+		}
+	}
+
+	pubsub := d.redis.Subscribe(ctx, watchedHashes...)
+	defer pubsub.Close()
+	messages := pubsub.Channel()
+
+	faults := make(chan redis.XMessage, 16)
+	go d.redis.FollowStream(ctx, "events:faults", lastID, faults)
+
+	input, err := openInput()
+	if err != nil {
+		return fmt.Errorf("failed to read keyboard input: %w", err)
+	}
+	defer input.Close()
+	keys := make(chan string, 16)
+	go readKeys(input, keys)
+
+	stdin := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(stdin)
+	if err != nil {
+		return fmt.Errorf("failed to set up terminal: %w", err)
+	}
+	defer term.Restore(stdin, state)
+
+	// Alternate screen, hidden cursor
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer fmt.Print("\x1b[?25h\x1b[?1049l")
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		d.draw()
+
+		select {
+		case msg := <-messages:
+			d.refresh(ctx, msg.Channel)
+		case event := <-faults:
+			d.faults = append(d.faults, event)
+			if len(d.faults) > maxFaults {
+				d.faults = d.faults[len(d.faults)-maxFaults:]
+			}
+		case key, ok := <-keys:
+			if !ok || key == "q" || key == "ctrl-c" {
+				return nil
+			}
+			d.handleKey(ctx, key)
+		case <-ticker.C:
+			// Poll as well, services do not publish every field change
+			for _, hash := range watchedHashes {
+				d.refresh(ctx, hash)
+			}
+			d.sample()
+		case <-sigChan:
+			return nil
+		}
+	}
+}
+
+// refresh reloads one hash
+func (d *dashboard) refresh(ctx context.Context, hash string) {
+	data, err := d.redis.HGetAllWithContext(ctx, hash)
+	if err != nil {
+		return
+	}
+	d.hashes[hash] = data
+}
+
+// sample records the current values of the sparkline series
+func (d *dashboard) sample() {
+	if ecu := model.ParseEngineECU(d.hashes[model.EngineECUKey]); ecu.Has("speed") {
+		d.record("speed", float64(ecu.Speed))
+	}
+	for _, slot := range model.BatterySlots {
+		key := model.BatteryKey(slot)
+		battery := model.ParseBattery(slot, d.hashes[key])
+		if !battery.Present {
+			continue
+		}
+		if battery.Has("charge") {
+			d.record(key+":charge", float64(battery.Charge))
+		}
+		if battery.Has("current") {
+			d.record(key+":current", battery.Current)
+		}
+	}
+}
+
+func (d *dashboard) record(name string, v float64) {
+	s, ok := d.samples[name]
+	if !ok {
+		s = &series{}
+		d.samples[name] = s
+	}
+	s.add(v)
+}
+
+// handleKey runs the command bound to a key
+func (d *dashboard) handleKey(ctx context.Context, key string) {
+	switch key {
+	case "l":
+		d.send(ctx, "vehicle.lock", "scooter:state", "lock", "Lock sent")
+	case "u":
+		d.send(ctx, "vehicle.unlock", "scooter:state", "unlock", "Unlock sent")
+	case "left":
+		d.send(ctx, "diag.blinkers", "scooter:blinker", "left", "Blinkers left")
+	case "right":
+		d.send(ctx, "diag.blinkers", "scooter:blinker", "right", "Blinkers right")
+	case "b":
+		d.send(ctx, "diag.blinkers", "scooter:blinker", "both", "Hazard blinkers on")
+	case "o":
+		d.send(ctx, "diag.blinkers", "scooter:blinker", "off", "Blinkers off")
+	case "up":
+		d.scroll++
+	case "down":
+		if d.scroll > 0 {
+			d.scroll--
+		}
+	}
+}
+
+// send pushes a command to a scooter command list and shows the outcome.
+// The access lists check it as the lsc command at path.
+func (d *dashboard) send(ctx context.Context, path, list, command, done string) {
+	if err := d.allow(path, "send "+command+" to "+list); err != nil {
+		d.setMessage(err.Error())
+		return
+	}
+	result, err := confirm.Send(ctx, d.redis, confirm.Command{List: list, Payload: command, Service: service.QueueUnit(list)})
+	if err != nil {
+		d.setMessage(fmt.Sprintf("Failed to send %s: %v", command, err))
+		return
+	}
+	if result.Warning != "" {
+		done = fmt.Sprintf("%s (%s)", done, result.Warning)
+	}
+	d.setMessage(done)
+}
+
+func (d *dashboard) setMessage(message string) {
+	d.message = message
+	d.messageAt = d.clock()
+}
+
+// readKeys translates terminal input into key names until the input is closed
+func readKeys(input io.Reader, keys chan<- string) {
+	defer close(keys)
+	buf := make([]byte, 32)
+	for {
+		n, err := input.Read(buf)
+		if err != nil {
+			return
+		}
+		for _, key := range parseKeys(buf[:n]) {
+			keys <- key
+		}
+	}
+}
+
+// parseKeys splits raw terminal input into key names: printable characters
+// as themselves, arrow keys as up/down/left/right and Ctrl-C as ctrl-c
+func parseKeys(data []byte) []string {
+	var keys []string
+	for i := 0; i < len(data); i++ {
+		switch {
+		case data[i] == 0x03:
+			keys = append(keys, "ctrl-c")
+		case data[i] == 0x1b && i+2 < len(data) && (data[i+1] == '[' || data[i+1] == 'O'):
+			switch data[i+2] {
+			case 'A':
+				keys = append(keys, "up")
+			case 'B':
+				keys = append(keys, "down")
+			case 'C':
+				keys = append(keys, "right")
+			case 'D':
+				keys = append(keys, "left")
+			}
+			i += 2
+		case data[i] >= 0x20 && data[i] < 0x7f:
+			keys = append(keys, string(data[i]))
+		}
+	}
+	return keys
+}
+
+// series is a bounded list of samples for a sparkline
+type series struct {
+	values []float64
+}
+
+func (s *series) add(v float64) {
+	s.values = append(s.values, v)
+	if len(s.values) > maxSamples {
+		s.values = s.values[len(s.values)-maxSamples:]
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+package dashboard
+
+import (
+	"io"
+	"os"
+)
+
+func openInput() (io.ReadCloser, error) {
+	return io.NopCloser(os.Stdin), nil
+}
@@ -0,0 +1,35 @@
+//go:build unix
+
+package dashboard
+
+import (
+	"io"
+	"os"
+	"syscall"
+)
+
+// input reads keys from a non-blocking duplicate of stdin, so closing it
+// stops the pending read instead of leaving a reader that swallows the next
+// keystroke (e.g. when the dashboard runs inside 'lsc shell')
+type input struct {
+	*os.File
+}
+
+func openInput() (io.ReadCloser, error) {
+	fd, err := syscall.Dup(int(os.Stdin.Fd()))
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.SetNonblock(fd, true); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+	return &input{os.NewFile(uintptr(fd), "stdin")}, nil
+}
+
+// Close closes the duplicate and puts stdin, which shares its flags, back into blocking mode
+func (in *input) Close() error {
+	err := in.File.Close()
+	syscall.SetNonblock(int(os.Stdin.Fd()), false)
+	return err
+}
@@ -0,0 +1,349 @@
+package dashboard
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"librescoot/lsc/internal/format"
+	"librescoot/lsc/internal/model"
+	"librescoot/lsc/internal/redis"
+
+	"golang.org/x/term"
+)
+
+// sparkBlocks are the sparkline levels, lowest first
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// ansiPattern matches the color escape sequences of the format package
+var ansiPattern = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// twoColumnWidth is the terminal width from which panels are shown side by side
+const twoColumnWidth = 90
+
+// panel is a titled box of rows
+type panel struct {
+	title string
+	rows  []string
+}
+
+// draw repaints the whole screen
+func (d *dashboard) draw() {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 || height <= 0 {
+		width, height = 80, 24
+	}
+
+	lines := d.render(width, height)
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	for i, line := range lines {
+		b.WriteString(line)
+		b.WriteString("\x1b[K")
+		if i < len(lines)-1 {
+			b.WriteString("\r\n")
+		}
+	}
+	os.Stdout.WriteString(b.String())
+}
+
+// render lays out the screen as exactly height lines
+func (d *dashboard) render(width, height int) []string {
+	header := format.Info("lsc dashboard") + "  " + format.Dim(d.clock().Format("15:04:05"))
+	lines := []string{fit(header, width)}
+
+	left := []panel{d.vehiclePanel(), d.motorPanel(), d.powerPanel()}
+	right := []panel{d.batteryPanel(0), d.batteryPanel(1), d.gpsPanel(), d.modemPanel()}
+
+	var body []string
+	if width >= twoColumnWidth {
+		leftWidth := width / 2
+		body = joinColumns(stackPanels(left, leftWidth), stackPanels(right, width-leftWidth), leftWidth)
+	} else {
+		body = stackPanels(append(left, right...), width)
+	}
+
+	// The fault pane gets what is left, but at least a few lines
+	footer := d.footer(width)
+	faultHeight := height - len(lines) - len(body) - 1
+	if faultHeight < 5 {
+		faultHeight = 5
+	}
+	if room := max(height-len(lines)-1-faultHeight, 0); len(body) > room {
+		body = body[:room]
+	}
+
+	lines = append(lines, body...)
+	lines = append(lines, d.faultPane(width, faultHeight)...)
+	lines = append(lines, footer)
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+	return lines[:height]
+}
+
+func (d *dashboard) vehiclePanel() panel {
+	v := model.ParseVehicle(d.hashes[model.VehicleKey])
+	blinker := format.SafeValueOr(v.BlinkerSwitch, "off")
+	if v.BlinkerState == "on" {
+		blinker = format.Warning(blinker)
+	}
+	return panel{"Vehicle", []string{
+		kv("State", format.ColorizeState(format.SafeValue(v.State, "unknown"))),
+		kv("Kickstand", format.ColorizeState(format.SafeValue(v.Kickstand, "-"))),
+		kv("Brakes", fmt.Sprintf("L:%s R:%s", format.FormatOnOff(v.BrakeLeft), format.FormatOnOff(v.BrakeRight))),
+		kv("Blinker", blinker),
+		kv("Seatbox", format.SafeValueOr(v.SeatboxLock, "closed")),
+		kv("Handlebar", format.SafeValue(v.HandlebarLock, "-")),
+	}}
+}
+
+func (d *dashboard) motorPanel() panel {
+	e := model.ParseEngineECU(d.hashes[model.EngineECUKey])
+	return panel{"Motor", []string{
+		kv("Speed", fmt.Sprintf("%s %s", pad(fmt.Sprintf("%d km/h", e.Speed), 10), d.spark("speed"))),
+		kv("RPM", fmt.Sprintf("%d RPM", e.RPM)),
+		kv("Throttle", format.FormatOnOff(fmt.Sprint(e.Throttle))),
+		kv("Current", format.FormatAmps(e.Current)),
+		kv("Voltage", format.FormatVolts(e.Voltage)),
+		kv("Temp", format.ColorizeTemperature(e.Temperature)),
+		kv("Odometer", format.FormatKilometers(e.Odometer)),
+	}}
+}
+
+func (d *dashboard) powerPanel() panel {
+	pm := model.ParsePowerManager(d.hashes[model.PowerManagerKey])
+	alarm := format.SafeValue(model.ParseAlarm(d.hashes[model.AlarmKey]).Status, "unknown")
+	if strings.Contains(alarm, "triggered") {
+		alarm = format.Error(alarm)
+	}
+	return panel{"Power & Alarm", []string{
+		kv("Power", format.ColorizeState(format.SafeValue(pm.State, "unknown"))),
+		kv("Wakeup", format.SafeValue(pm.WakeupSource, "-")),
+		kv("Alarm", alarm),
+	}}
+}
+
+func (d *dashboard) batteryPanel(slot int) panel {
+	key := model.BatteryKey(slot)
+	title := fmt.Sprintf("Battery %d", slot)
+	b := model.ParseBattery(slot, d.hashes[key])
+	if !b.Present {
+		return panel{title, []string{format.Dim("Not present")}}
+	}
+	return panel{title, []string{
+		kv("State", format.ColorizeState(b.State)),
+		kv("Charge", fmt.Sprintf("%s %s", pad(format.ColorizePercentage(b.Charge), 10), d.spark(key+":charge"))),
+		kv("Current", fmt.Sprintf("%s %s", pad(format.FormatAmps(b.Current), 10), d.spark(key+":current"))),
+		kv("Voltage", format.FormatVoltsColored(b.Voltage)),
+		kv("Temp", format.ColorizeTemperature(b.Temperatures[0])),
+	}}
+}
+
+func (d *dashboard) gpsPanel() panel {
+	g := model.ParseGPS(d.hashes[model.GPSKey])
+	position := format.Dim("no fix")
+	if g.Has("latitude") && g.Has("longitude") && (g.Latitude != 0 || g.Longitude != 0) {
+		position = fmt.Sprintf("%.5f, %.5f", g.Latitude, g.Longitude)
+	}
+	return panel{"GPS", []string{
+		kv("State", format.ColorizeState(format.SafeValue(g.State, "unknown"))),
+		kv("Position", position),
+		kv("Speed", fmt.Sprintf("%.0f km/h", g.Speed)),
+	}}
+}
+
+func (d *dashboard) modemPanel() panel {
+	internet := model.ParseInternet(d.hashes[model.InternetKey])
+	state := internet.ModemState
+	if state == "" {
+		state = model.ParseModem(d.hashes[model.ModemKey]).State
+	}
+	signal := "-"
+	if internet.Has("signal-quality") {
+		signal = fmt.Sprint(internet.SignalQuality)
+	}
+	return panel{"Modem", []string{
+		kv("State", format.ColorizeState(format.SafeValue(state, "unknown"))),
+		kv("Status", format.ColorizeState(format.SafeValue(internet.Status, "-"))),
+		kv("Access", format.SafeValue(internet.AccessTech, "-")),
+		kv("Signal", signal),
+	}}
+}
+
+// faultPane shows the newest fault events that fit, scrolled back by d.scroll
+func (d *dashboard) faultPane(width, height int) []string {
+	visible := height - 2
+	if maxScroll := len(d.faults) - visible; d.scroll > maxScroll {
+		d.scroll = max(maxScroll, 0)
+	}
+
+	end := len(d.faults) - d.scroll
+	start := max(end-visible, 0)
+
+	var rows []string
+	for _, msg := range d.faults[start:end] {
+		rows = append(rows, faultRow(msg))
+	}
+	if len(d.faults) == 0 {
+		rows = append(rows, format.Dim("No fault events"))
+	}
+	for len(rows) < visible {
+		rows = append(rows, "")
+	}
+
+	title := "Faults"
+	if d.scroll > 0 {
+		title = fmt.Sprintf("Faults (%d older)", d.scroll)
+	}
+	return box(panel{title, rows}, width)
+}
+
+// faultRow formats a fault event like 'lsc diag events'
+func faultRow(msg redis.XMessage) string {
+	timestamp := ""
+	id, _, _ := strings.Cut(msg.ID, "-")
+	if ms, err := strconv.ParseInt(id, 10, 64); err == nil {
+		timestamp = time.UnixMilli(ms).Format("01-02 15:04:05")
+	}
+	return fmt.Sprintf("%s [%s:%s] %s",
+		format.Dim(timestamp),
+		format.Warning(field(msg.Values, "group")),
+		format.Warning(field(msg.Values, "code")),
+		field(msg.Values, "description"))
+}
+
+func field(values map[string]interface{}, key string) string {
+	if v, ok := values[key]; ok {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+func (d *dashboard) footer(width int) string {
+	help := format.Dim("l lock  u unlock  ←/→ blink  b hazard  o off  ↑/↓ scroll  q quit")
+	if d.message != "" && d.clock().Sub(d.messageAt) < messageTimeout {
+		help = format.Success(d.message) + "  " + help
+	}
+	return fit(help, width)
+}
+
+// spark renders the samples of a series as a sparkline
+func (d *dashboard) spark(name string) string {
+	s, ok := d.samples[name]
+	if !ok {
+		return ""
+	}
+	return sparkline(s.values, 20)
+}
+
+// sparkline scales the last width values between their minimum and maximum
+func sparkline(values []float64, width int) string {
+	if len(values) > width {
+		values = values[len(values)-width:]
+	}
+	if len(values) == 0 {
+		return ""
+	}
+
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo = min(lo, v)
+		hi = max(hi, v)
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		level := 0
+		if hi > lo {
+			level = int((v - lo) / (hi - lo) * float64(len(sparkBlocks)-1))
+		}
+		b.WriteRune(sparkBlocks[level])
+	}
+	return format.Info(b.String())
+}
+
+func kv(label, value string) string {
+	return format.Dim(fmt.Sprintf("%-10s", label)) + " " + value
+}
+
+// stackPanels draws panels below each other at the given width
+func stackPanels(panels []panel, width int) []string {
+	var lines []string
+	for _, p := range panels {
+		lines = append(lines, box(p, width)...)
+	}
+	return lines
+}
+
+// joinColumns puts two columns of lines side by side
+func joinColumns(left, right []string, leftWidth int) []string {
+	n := max(len(left), len(right))
+	lines := make([]string, n)
+	for i := 0; i < n; i++ {
+		l, r := "", ""
+		if i < len(left) {
+			l = left[i]
+		}
+		if i < len(right) {
+			r = right[i]
+		}
+		lines[i] = fit(l, leftWidth) + r
+	}
+	return lines
+}
+
+// box draws a panel with a border at the given width
+func box(p panel, width int) []string {
+	inner := width - 4
+	if inner < 1 {
+		return nil
+	}
+
+	title := " " + p.title + " "
+	top := "┌─" + title + strings.Repeat("─", max(width-3-utf8.RuneCountInString(title), 0)) + "┐"
+	lines := []string{format.Dim(top)}
+	for _, row := range p.rows {
+		lines = append(lines, format.Dim("│")+" "+fit(row, inner)+" "+format.Dim("│"))
+	}
+	lines = append(lines, format.Dim("└"+strings.Repeat("─", width-2)+"┘"))
+	return lines
+}
+
+// pad appends spaces to s up to width visible characters
+func pad(s string, width int) string {
+	visible := utf8.RuneCountInString(ansiPattern.ReplaceAllString(s, ""))
+	if visible >= width {
+		return s
+	}
+	return s + strings.Repeat(" ", width-visible)
+}
+
+// fit truncates or pads s to exactly width visible characters, ignoring
+// color escape sequences
+func fit(s string, width int) string {
+	if utf8.RuneCountInString(ansiPattern.ReplaceAllString(s, "")) <= width {
+		return pad(s, width)
+	}
+
+	var b strings.Builder
+	n := 0
+	for i := 0; i < len(s) && n < width; {
+		if loc := ansiPattern.FindStringIndex(s[i:]); loc != nil && loc[0] == 0 {
+			b.WriteString(s[i : i+loc[1]])
+			i += loc[1]
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		b.WriteRune(r)
+		i += size
+		n++
+	}
+	// Reset colors cut off with the rest of the string
+	b.WriteString("\x1b[0m")
+	return b.String()
+}
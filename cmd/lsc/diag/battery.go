@@ -1,155 +1,155 @@
 package diag
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"strconv"
 
+	"librescoot/lsc/internal/app"
 	"librescoot/lsc/internal/format"
+	"librescoot/lsc/internal/model"
+	"librescoot/lsc/internal/output"
+	"librescoot/lsc/internal/redis"
 
 	"github.com/spf13/cobra"
 )
 
-var batteryCmd = &cobra.Command{
-	Use:   "battery [id...]",
-	Short: "Show detailed battery information",
-	Long:  `Display comprehensive battery information for one or more batteries. If no IDs specified, shows all batteries.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		// Determine which batteries to show
-		batteryIDs := []string{"0", "1"}
-		if len(args) > 0 {
-			batteryIDs = args
-		}
+func newBatteryCmd(a *app.App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "battery [id...]",
+		Short: "Show detailed battery information",
+		Long:  `Display comprehensive battery information for one or more batteries. If no IDs specified, shows all batteries.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Determine which batteries to show
+			slots := model.BatterySlots
+			if len(args) > 0 {
+				slots = make([]int, len(args))
+				for i, arg := range args {
+					slot, err := strconv.Atoi(arg)
+					if err != nil || slot < 0 {
+						return output.InvalidArgument("invalid battery id '%s'", arg)
+					}
+					slots[i] = slot
+				}
+			}
 
-		if JSONOutput != nil && *JSONOutput {
-			// Collect all battery data for JSON output
 			batteries := make([]interface{}, 0)
-			for _, id := range batteryIDs {
-				batteryData := getBatteryData(id)
+			for _, slot := range slots {
+				batteryData := getBatteryData(a.Redis, slot)
 				if batteryData != nil {
 					batteries = append(batteries, batteryData)
 				}
 			}
-			jsonBytes, _ := json.MarshalIndent(map[string]interface{}{
+
+			return a.Render(map[string]interface{}{
 				"batteries": batteries,
-			}, "", "  ")
-			fmt.Println(string(jsonBytes))
-		} else {
-			for _, id := range batteryIDs {
-				showBattery(id)
-			}
-		}
-	},
+			}, func() {
+				for _, slot := range slots {
+					showBattery(a.Redis, slot)
+				}
+			})
+		},
+	}
 }
 
-func getBatteryData(id string) map[string]interface{} {
-	data, err := RedisClient.HGetAll(fmt.Sprintf("battery:%s", id))
+func getBatteryData(client *redis.Client, slot int) map[string]interface{} {
+	data, err := client.HGetAll(model.BatteryKey(slot))
 	if err != nil {
 		return nil
 	}
+	battery := model.ParseBattery(slot, data)
+	id := strconv.Itoa(slot)
 
 	// Check if battery is present
-	if data["present"] != "true" {
+	if !battery.Present {
 		return map[string]interface{}{
 			"id":      id,
 			"present": false,
 		}
 	}
 
-	// Parse numeric values
-	parseInt := func(s string) int {
-		v, _ := strconv.Atoi(s)
-		return v
-	}
-	parseFloat := func(s string) float64 {
-		v, _ := strconv.ParseFloat(s, 64)
-		return v
-	}
-
 	// Get faults
-	faults, _ := RedisClient.SMembers(fmt.Sprintf("battery:%s:faults", id))
+	faults, _ := client.SMembers(model.BatteryFaultsKey(slot))
 
 	return map[string]interface{}{
 		"id":      id,
 		"present": true,
-		"state":   data["state"],
+		"state":   battery.State,
 		"charge": map[string]interface{}{
-			"charge_percent": parseInt(data["charge"]),
-			"voltage_v":      parseFloat(data["voltage"]) / 1000.0,
-			"current_a":      parseFloat(data["current"]) / 1000.0,
+			"charge_percent": battery.Charge,
+			"voltage_v":      battery.Voltage,
+			"current_a":      battery.Current,
 		},
 		"temperature": map[string]interface{}{
-			"sensor_0_c": parseInt(data["temperature:0"]),
-			"sensor_1_c": parseInt(data["temperature:1"]),
-			"sensor_2_c": parseInt(data["temperature:2"]),
-			"sensor_3_c": parseInt(data["temperature:3"]),
-			"state":      data["temperature-state"],
+			"sensor_0_c": battery.Temperatures[0],
+			"sensor_1_c": battery.Temperatures[1],
+			"sensor_2_c": battery.Temperatures[2],
+			"sensor_3_c": battery.Temperatures[3],
+			"state":      battery.TemperatureState,
 		},
 		"health": map[string]interface{}{
-			"cycles":         parseInt(data["cycle-count"]),
-			"health_percent": parseInt(data["state-of-health"]),
+			"cycles":         battery.CycleCount,
+			"health_percent": battery.StateOfHealth,
 		},
 		"identity": map[string]interface{}{
-			"serial_number":      data["serial-number"],
-			"manufacturing_date": data["manufacturing-date"],
-			"firmware_version":   data["fw-version"],
+			"serial_number":      battery.SerialNumber,
+			"manufacturing_date": battery.ManufacturingDate,
+			"firmware_version":   battery.FirmwareVersion,
 		},
 		"faults": faults,
 	}
 }
 
-func showBattery(id string) {
-	data, err := RedisClient.HGetAll(fmt.Sprintf("battery:%s", id))
+func showBattery(client *redis.Client, slot int) {
+	data, err := client.HGetAll(model.BatteryKey(slot))
 	if err != nil {
-		fmt.Fprintf(os.Stderr, format.Error("Failed to fetch battery:%s data: %v\n"), id, err)
+		fmt.Fprintf(os.Stderr, format.Error("Failed to fetch battery:%d data: %v\n"), slot, err)
 		return
 	}
+	battery := model.ParseBattery(slot, data)
 
-	format.PrintSection(fmt.Sprintf("Battery %s", id))
+	format.PrintSection(fmt.Sprintf("Battery %d", slot))
 
 	// Check if battery is present
-	if data["present"] != "true" {
+	if !battery.Present {
 		fmt.Println(format.Dim("  Not Present\n"))
 		return
 	}
 
 	// Basic status
-	format.PrintKV("State", format.ColorizeState(data["state"]))
-	format.PrintKV("Present", format.FormatPresence(data["present"]))
+	format.PrintKV("State", format.ColorizeState(battery.State))
+	format.PrintKV("Present", format.FormatPresence("true"))
 
 	// Charge information
 	format.PrintSubsection("Charge")
-	format.PrintKV("Level", format.FormatChargeColored(data["charge"]))
-	format.PrintKV("Voltage", format.FormatVoltageColored(data["voltage"]))
-	format.PrintKV("Current", format.MilliampsToAmps(data["current"]))
+	format.PrintKV("Level", format.ColorizePercentage(battery.Charge))
+	format.PrintKV("Voltage", format.FormatVoltsColored(battery.Voltage))
+	format.PrintKV("Current", format.FormatAmps(battery.Current))
 
 	// Temperature information
 	format.PrintSubsection("Temperature")
-	format.PrintKV("Sensor 0", format.FormatTemperatureColored(data["temperature:0"]))
-	format.PrintKV("Sensor 1", format.FormatTemperatureColored(data["temperature:1"]))
-	format.PrintKV("Sensor 2", format.FormatTemperatureColored(data["temperature:2"]))
-	format.PrintKV("Sensor 3", format.FormatTemperatureColored(data["temperature:3"]))
-	format.PrintKV("State", format.ColorizeState(data["temperature-state"]))
+	for i, temperature := range battery.Temperatures {
+		format.PrintKV(fmt.Sprintf("Sensor %d", i), format.ColorizeTemperature(temperature))
+	}
+	format.PrintKV("State", format.ColorizeState(battery.TemperatureState))
 
 	// Health information
 	format.PrintSubsection("Health")
-	format.PrintKV("Cycle Count", format.SafeValueOr(data["cycle-count"], "0"))
-	soh, _ := strconv.Atoi(data["state-of-health"])
-	if soh > 0 {
-		format.PrintKV("State of Health", format.ColorizePercentage(soh))
+	format.PrintKV("Cycle Count", fmt.Sprint(battery.CycleCount))
+	if battery.StateOfHealth > 0 {
+		format.PrintKV("State of Health", format.ColorizePercentage(battery.StateOfHealth))
 	} else {
 		format.PrintKV("State of Health", format.Dim("N/A"))
 	}
 
 	// Identity
 	format.PrintSubsection("Identity")
-	format.PrintKV("Serial Number", format.SafeValueOr(data["serial-number"], "N/A"))
-	format.PrintKV("Mfg Date", format.SafeValueOr(data["manufacturing-date"], "N/A"))
-	format.PrintKV("Firmware", format.SafeValueOr(data["fw-version"], "N/A"))
+	format.PrintKV("Serial Number", format.SafeValueOr(battery.SerialNumber, "N/A"))
+	format.PrintKV("Mfg Date", format.SafeValueOr(battery.ManufacturingDate, "N/A"))
+	format.PrintKV("Firmware", format.SafeValueOr(battery.FirmwareVersion, "N/A"))
 
 	// Faults
-	faults, err := RedisClient.SMembers(fmt.Sprintf("battery:%s:faults", id))
+	faults, err := client.SMembers(model.BatteryFaultsKey(slot))
 	if err == nil && len(faults) > 0 {
 		format.PrintSubsection("Active Faults")
 		for _, fault := range faults {
@@ -161,7 +161,3 @@ func showBattery(id string) {
 
 	fmt.Println()
 }
-
-func init() {
-	DiagCmd.AddCommand(batteryCmd)
-}
@@ -12,25 +12,30 @@ import (
 	"syscall"
 	"time"
 
+	"librescoot/lsc/internal/app"
 	"librescoot/lsc/internal/format"
+	"librescoot/lsc/internal/output"
 	"librescoot/lsc/internal/redis"
 
 	"github.com/spf13/cobra"
 )
 
-var (
-	eventsSince   string
-	eventsUntil   string
-	eventsCount   int
-	eventsFollow  bool
-	eventsFilter  string
-	eventsReverse bool
-)
+// eventsOptions are the flags of the events command
+type eventsOptions struct {
+	since   string
+	until   string
+	count   int
+	follow  bool
+	filter  string
+	reverse bool
+}
 
-var eventsCmd = &cobra.Command{
-	Use:   "events",
-	Short: "View fault event stream",
-	Long: `Display fault events from the events:faults stream with filtering and follow mode.
+func newEventsCmd(a *app.App) *cobra.Command {
+	var opts eventsOptions
+	cmd := &cobra.Command{
+		Use:   "events",
+		Short: "View fault event stream",
+		Long: `Display fault events from the events:faults stream with filtering and follow mode.
 
 Time range filtering (similar to journalctl):
   --since <duration>   Show events since duration ago (e.g., 1h, 24h, 7d, 1w)
@@ -50,85 +55,92 @@ Examples:
   lsc events -n 10 -r                   # Last 10 events, newest first
   lsc events -f                         # Follow events in real-time
   lsc events --filter "battery"         # Events containing "battery"`,
-	Run: func(cmd *cobra.Command, args []string) {
-		var filterRegex *regexp.Regexp
-		if eventsFilter != "" {
-			var err error
-			filterRegex, err = regexp.Compile(eventsFilter)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, format.Error("Invalid filter regex: %v\n"), err)
-				return
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var filterRegex *regexp.Regexp
+			if opts.filter != "" {
+				var err error
+				filterRegex, err = regexp.Compile(opts.filter)
+				if err != nil {
+					return output.InvalidArgument("invalid filter regex: %w", err)
+				}
 			}
-		}
 
-		ctx := context.Background()
-		if eventsFollow {
-			ctx, cancel := context.WithCancel(context.Background())
-			defer cancel()
-
-			// Handle Ctrl+C
-			sigChan := make(chan os.Signal, 1)
-			signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-			go func() {
-				<-sigChan
-				cancel()
-			}()
-
-			followEvents(ctx, filterRegex)
-		} else {
-			showEvents(ctx, filterRegex)
-		}
-	},
+			ctx := cmd.Context()
+			if opts.follow {
+				ctx, cancel := context.WithCancel(cmd.Context())
+				defer cancel()
+
+				// Handle Ctrl+C
+				sigChan := make(chan os.Signal, 1)
+				signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+				go func() {
+					<-sigChan
+					cancel()
+				}()
+
+				return followEvents(ctx, a, filterRegex)
+			}
+			return showEvents(ctx, a, opts, filterRegex)
+		},
+	}
+	cmd.Flags().StringVar(&opts.since, "since", "", "Show events since duration ago (1h, 24h, 7d, 1w)")
+	cmd.Flags().StringVar(&opts.until, "until", "", "Show events until duration ago (1h, 24h, 7d, 1w)")
+	cmd.Flags().IntVarP(&opts.count, "lines", "n", 50, "Maximum number of events to show")
+	cmd.Flags().BoolVarP(&opts.follow, "follow", "f", false, "Follow the stream (like tail -f)")
+	cmd.Flags().BoolVarP(&opts.reverse, "reverse", "r", false, "Show newest events first")
+	cmd.Flags().StringVar(&opts.filter, "filter", "", "Filter events by regex pattern")
+
+	// Keep --count as deprecated alias for --lines
+	cmd.Flags().IntVar(&opts.count, "count", 50, "Maximum number of events to show (deprecated: use -n/--lines)")
+	cmd.Flags().MarkDeprecated("count", "use -n or --lines instead")
+	return cmd
 }
 
-func showEvents(ctx context.Context, filterRegex *regexp.Regexp) {
+func showEvents(ctx context.Context, a *app.App, opts eventsOptions, filterRegex *regexp.Regexp) error {
 	// Determine the start ID based on --since
 	startID := "0"
 	var sinceTime time.Time
-	if eventsSince != "" {
-		duration, err := parseDuration(eventsSince)
+	if opts.since != "" {
+		duration, err := parseDuration(opts.since)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, format.Error("Invalid duration '%s': %v\n"), eventsSince, err)
-			return
+			return output.InvalidArgument("invalid duration '%s': %w", opts.since, err)
 		}
 		// Calculate the approximate stream ID from timestamp
-		sinceTime = time.Now().Add(-duration)
+		sinceTime = a.Now().Add(-duration)
 		startID = fmt.Sprintf("%d-0", sinceTime.UnixMilli())
 	}
 
 	// Determine the end time based on --until
 	var untilTime time.Time
-	if eventsUntil != "" {
-		duration, err := parseDuration(eventsUntil)
+	if opts.until != "" {
+		duration, err := parseDuration(opts.until)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, format.Error("Invalid duration '%s': %v\n"), eventsUntil, err)
-			return
+			return output.InvalidArgument("invalid duration '%s': %w", opts.until, err)
 		}
-		untilTime = time.Now().Add(-duration)
+		untilTime = a.Now().Add(-duration)
 	}
 
 	// Read from stream (get more than count to allow for filtering)
-	readCount := int64(eventsCount * 2)
+	readCount := int64(opts.count * 2)
 	if readCount < 100 {
 		readCount = 100
 	}
-	streams, err := RedisClient.XRead(ctx, &redis.XReadArgs{
+	streams, err := a.Redis.XRead(ctx, &redis.XReadArgs{
 		Streams: []string{"events:faults", startID},
 		Count:   readCount,
 	})
 	if err != nil {
-		fmt.Fprintf(os.Stderr, format.Error("Failed to read events: %v\n"), err)
-		return
+		return fmt.Errorf("failed to read events: %w", err)
 	}
 
-	if len(streams) == 0 || len(streams[0].Messages) == 0 {
-		fmt.Println(format.Dim("No events found"))
-		return
+	var messages []redis.XMessage
+	if len(streams) > 0 {
+		messages = streams[0].Messages
 	}
 
 	// Filter and collect events
 	var filteredEvents []redis.XMessage
-	for _, msg := range streams[0].Messages {
+	for _, msg := range messages {
 		// Parse timestamp from message ID
 		idParts := strings.Split(msg.ID, "-")
 		if len(idParts) > 0 {
@@ -154,7 +166,7 @@ func showEvents(ctx context.Context, filterRegex *regexp.Regexp) {
 	}
 
 	// Apply reverse if requested
-	if eventsReverse {
+	if opts.reverse {
 		// Reverse the slice
 		for i, j := 0, len(filteredEvents)-1; i < j; i, j = i+1, j-1 {
 			filteredEvents[i], filteredEvents[j] = filteredEvents[j], filteredEvents[i]
@@ -162,34 +174,41 @@ func showEvents(ctx context.Context, filterRegex *regexp.Regexp) {
 	}
 
 	// Limit to count
-	if len(filteredEvents) > eventsCount {
-		filteredEvents = filteredEvents[:eventsCount]
-	}
-
-	// Display events
-	if len(filteredEvents) == 0 {
-		fmt.Println(format.Dim("No events found matching criteria"))
-		return
+	if len(filteredEvents) > opts.count {
+		filteredEvents = filteredEvents[:opts.count]
 	}
 
+	events := make([]map[string]interface{}, 0, len(filteredEvents))
 	for _, msg := range filteredEvents {
-		printEvent(msg)
+		events = append(events, EventData(msg))
 	}
+
+	return a.Render(events, func() {
+		if len(filteredEvents) == 0 {
+			fmt.Println(format.Dim("No events found"))
+			return
+		}
+		for _, msg := range filteredEvents {
+			printEvent(msg)
+		}
+	})
 }
 
-func followEvents(ctx context.Context, filterRegex *regexp.Regexp) {
+// followEvents prints new events as they arrive, one JSON object per line in
+// JSON mode, until ctx is cancelled
+func followEvents(ctx context.Context, a *app.App, filterRegex *regexp.Regexp) error {
 	// Start from the latest event
 	lastID := "$"
 
 	for {
 		select {
 		case <-ctx.Done():
-			return
+			return nil
 		default:
 		}
 
 		// Read with blocking
-		streams, err := RedisClient.XRead(ctx, &redis.XReadArgs{
+		streams, err := a.Redis.XRead(ctx, &redis.XReadArgs{
 			Streams: []string{"events:faults", lastID},
 			Count:   10,
 			Block:   1 * time.Second,
@@ -200,8 +219,10 @@ func followEvents(ctx context.Context, filterRegex *regexp.Regexp) {
 			if strings.Contains(err.Error(), "timeout") || strings.Contains(err.Error(), "nil") {
 				continue
 			}
-			fmt.Fprintf(os.Stderr, format.Error("Error reading events: %v\n"), err)
-			return
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to read events: %w", err)
 		}
 
 		if len(streams) == 0 || len(streams[0].Messages) == 0 {
@@ -211,20 +232,21 @@ func followEvents(ctx context.Context, filterRegex *regexp.Regexp) {
 		// Process new events
 		events := streams[0].Messages
 		for _, msg := range events {
-			if matchesFilter(msg, filterRegex) {
+			lastID = msg.ID
+			if !matchesFilter(msg, filterRegex) {
+				continue
+			}
+			if a.Structured() {
+				jsonBytes, _ := json.Marshal(EventData(msg))
+				fmt.Println(string(jsonBytes))
+			} else {
 				printEvent(msg)
 			}
-			lastID = msg.ID
 		}
 	}
 }
 
 func printEvent(msg redis.XMessage) {
-	if JSONOutput != nil && *JSONOutput {
-		printEventJSON(msg)
-		return
-	}
-
 	// Parse timestamp from ID (format: "milliseconds-sequence")
 	idParts := strings.Split(msg.ID, "-")
 	ts := "N/A"
@@ -251,7 +273,8 @@ func printEvent(msg redis.XMessage) {
 	)
 }
 
-func printEventJSON(msg redis.XMessage) {
+// EventData converts a stream entry to its JSON representation
+func EventData(msg redis.XMessage) map[string]interface{} {
 	// Parse timestamp from ID
 	idParts := strings.Split(msg.ID, "-")
 	var timestamp int64
@@ -274,8 +297,7 @@ func printEventJSON(msg redis.XMessage) {
 		}
 	}
 
-	jsonBytes, _ := json.Marshal(event)
-	fmt.Println(string(jsonBytes))
+	return event
 }
 
 func matchesFilter(msg redis.XMessage, filterRegex *regexp.Regexp) bool {
@@ -334,18 +356,3 @@ func parseDuration(s string) (time.Duration, error) {
 	// Let time.ParseDuration handle standard formats (h, m, s)
 	return time.ParseDuration(s)
 }
-
-func init() {
-	eventsCmd.Flags().StringVar(&eventsSince, "since", "", "Show events since duration ago (1h, 24h, 7d, 1w)")
-	eventsCmd.Flags().StringVar(&eventsUntil, "until", "", "Show events until duration ago (1h, 24h, 7d, 1w)")
-	eventsCmd.Flags().IntVarP(&eventsCount, "lines", "n", 50, "Maximum number of events to show")
-	eventsCmd.Flags().BoolVarP(&eventsFollow, "follow", "f", false, "Follow the stream (like tail -f)")
-	eventsCmd.Flags().BoolVarP(&eventsReverse, "reverse", "r", false, "Show newest events first")
-	eventsCmd.Flags().StringVar(&eventsFilter, "filter", "", "Filter events by regex pattern")
-
-	// Keep --count as deprecated alias for --lines
-	eventsCmd.Flags().IntVar(&eventsCount, "count", 50, "Maximum number of events to show (deprecated: use -n/--lines)")
-	eventsCmd.Flags().MarkDeprecated("count", "use -n or --lines instead")
-
-	DiagCmd.AddCommand(eventsCmd)
-}
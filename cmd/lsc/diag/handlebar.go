@@ -1,69 +1,52 @@
 package diag
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
 
+	"librescoot/lsc/internal/app"
+	"librescoot/lsc/internal/confirm"
 	"librescoot/lsc/internal/format"
+	"librescoot/lsc/internal/output"
+	"librescoot/lsc/internal/policy"
 
 	"github.com/spf13/cobra"
 )
 
-var handlebarCmd = &cobra.Command{
-	Use:       "handlebar [lock|unlock]",
-	Short:     "Control handlebar lock",
-	Long:      `Manually control the handlebar lock mechanism. Use with caution - normally handled automatically by vehicle state.`,
-	Args:      cobra.ExactArgs(1),
-	ValidArgs: []string{"lock", "unlock"},
-	Run: func(cmd *cobra.Command, args []string) {
-		action := args[0]
+func newHandlebarCmd(a *app.App) *cobra.Command {
+	var force bool
+	cmd := &cobra.Command{
+		Use:       "handlebar [lock|unlock]",
+		Short:     "Control handlebar lock",
+		Long:      `Manually control the handlebar lock mechanism. Use with caution - normally handled automatically by vehicle state.`,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{"lock", "unlock"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			action := args[0]
+
+			// Validate argument
+			if action != "lock" && action != "unlock" {
+				return output.InvalidArgument("invalid action '%s'; must be 'lock' or 'unlock'", action)
+			}
 
-		// Validate argument
-		if action != "lock" && action != "unlock" {
-			if JSONOutput != nil && *JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "handlebar",
-					"status":  "error",
-					"error":   fmt.Sprintf("invalid action: %s", action),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Invalid action '%s'. Must be 'lock' or 'unlock'\n"), action)
+			ctx := cmd.Context()
+			if err := a.Guard(ctx, action+" the handlebar", force, policy.Riding...); err != nil {
+				return err
 			}
-			return
-		}
 
-		// Send command
-		command := fmt.Sprintf("handlebar:%s", action)
-		if err := RedisClient.LPush("scooter:hardware", command); err != nil {
-			if JSONOutput != nil && *JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "handlebar",
-					"status":  "error",
-					"error":   err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Failed to send handlebar command: %v\n"), err)
+			// Send command
+			command := fmt.Sprintf("handlebar:%s", action)
+			if err := a.Send(ctx, confirm.Command{List: "scooter:hardware", Payload: command}); err != nil {
+				return fmt.Errorf("failed to send handlebar command: %w", err)
 			}
-			return
-		}
 
-		if JSONOutput != nil && *JSONOutput {
-			output, _ := json.Marshal(map[string]interface{}{
-				"command": "handlebar",
-				"status":  "success",
-				"action":  action,
+			return a.Render(map[string]interface{}{
+				"action": action,
+			}, func() {
+				fmt.Printf("%s Handlebar %s command sent\n", format.Success("✓"), action)
+				fmt.Println(format.Dim("Note: This bypasses the automatic handlebar control"))
 			})
-			fmt.Println(string(output))
-		} else {
-			fmt.Printf("%s Handlebar %s command sent\n", format.Success("✓"), action)
-			fmt.Println(format.Dim("Note: This bypasses the automatic handlebar control"))
-		}
-	},
-}
-
-func init() {
-	DiagCmd.AddCommand(handlebarCmd)
+		},
+	}
+	cmd.Flags().BoolVar(&force, "force", false, "Move the handlebar lock even while the scooter is ridden")
+	return cmd
 }
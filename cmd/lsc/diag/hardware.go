@@ -1,286 +1,225 @@
 package diag
 
 import (
-	"context"
-	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"strings"
 	"time"
 
+	"librescoot/lsc/internal/app"
+	"librescoot/lsc/internal/confirm"
 	"librescoot/lsc/internal/format"
+	"librescoot/lsc/internal/output"
+	"librescoot/lsc/internal/policy"
+	"librescoot/lsc/internal/runner"
 
 	"github.com/spf13/cobra"
 )
 
-var dashboardCmd = &cobra.Command{
-	Use:     "dashboard [on|off]",
-	Aliases: []string{"dbc", "dash"},
-	Short:   "Control dashboard power and connectivity",
-	Long:    `Control dashboard power (on/off) and check connectivity (ping, on-wait).`,
-	Args:    cobra.MaximumNArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		// If no args, show help
-		if len(args) == 0 {
-			cmd.Help()
-			return
-		}
+func newDashboardCmd(a *app.App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "dashboard [on|off]",
+		Aliases: []string{"dbc", "dash"},
+		Short:   "Control dashboard power and connectivity",
+		Long:    `Control dashboard power (on/off) and check connectivity (ping, on-wait).`,
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// If no args, show help
+			if len(args) == 0 {
+				return cmd.Help()
+			}
 
-		action := args[0]
+			action := args[0]
 
-		if action != "on" && action != "off" {
-			if JSONOutput != nil && *JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "dashboard",
-					"status":  "error",
-					"error":   fmt.Sprintf("invalid action: %s", action),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Invalid action '%s'. Must be 'on' or 'off'\n"), action)
+			if action != "on" && action != "off" {
+				return output.InvalidArgument("invalid action '%s'; must be 'on' or 'off'", action)
 			}
-			return
-		}
 
-		command := fmt.Sprintf("dashboard:%s", action)
-		if err := RedisClient.LPush("scooter:hardware", command); err != nil {
-			if JSONOutput != nil && *JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "dashboard",
-					"action":  action,
-					"status":  "error",
-					"error":   err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Failed to send dashboard command: %v\n"), err)
+			command := fmt.Sprintf("dashboard:%s", action)
+			if err := a.Send(cmd.Context(), confirm.Command{List: "scooter:hardware", Payload: command}); err != nil {
+				return fmt.Errorf("failed to send dashboard command: %w", err)
 			}
-			return
-		}
 
-		if JSONOutput != nil && *JSONOutput {
-			output, _ := json.Marshal(map[string]interface{}{
-				"command": "dashboard",
-				"action":  action,
-				"status":  "success",
+			return a.Render(map[string]interface{}{
+				"action": action,
+			}, func() {
+				fmt.Printf("%s Dashboard power: %s\n", format.Success("✓"), action)
 			})
-			fmt.Println(string(output))
-		} else {
-			fmt.Printf("%s Dashboard power: %s\n", format.Success("✓"), action)
-		}
-	},
+		},
+	}
+	cmd.AddCommand(newDbcStatusCmd(a))
+	cmd.AddCommand(newDbcPingCmd(a))
+	cmd.AddCommand(newDbcOnWaitCmd(a))
+	cmd.AddCommand(newDbcOffWaitCmd(a))
+	return cmd
 }
 
-var engineCmd = &cobra.Command{
-	Use:       "engine [on|off]",
-	Short:     "Control engine power",
-	Args:      cobra.ExactArgs(1),
-	ValidArgs: []string{"on", "off"},
-	Run: func(cmd *cobra.Command, args []string) {
-		action := args[0]
+func newEngineCmd(a *app.App) *cobra.Command {
+	var force bool
+	cmd := &cobra.Command{
+		Use:       "engine [on|off]",
+		Short:     "Control engine power",
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{"on", "off"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			action := args[0]
+
+			if action != "on" && action != "off" {
+				return output.InvalidArgument("invalid action '%s'; must be 'on' or 'off'", action)
+			}
 
-		if action != "on" && action != "off" {
-			if JSONOutput != nil && *JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "engine",
-					"status":  "error",
-					"error":   fmt.Sprintf("invalid action: %s", action),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Invalid action '%s'. Must be 'on' or 'off'\n"), action)
+			ctx := cmd.Context()
+			if action == "off" {
+				if err := a.Guard(ctx, "turn the engine off", force, policy.Riding...); err != nil {
+					return err
+				}
 			}
-			return
-		}
 
-		command := fmt.Sprintf("engine:%s", action)
-		if err := RedisClient.LPush("scooter:hardware", command); err != nil {
-			if JSONOutput != nil && *JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "engine",
-					"action":  action,
-					"status":  "error",
-					"error":   err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Failed to send engine command: %v\n"), err)
+			command := fmt.Sprintf("engine:%s", action)
+			if err := a.Send(ctx, confirm.Command{List: "scooter:hardware", Payload: command}); err != nil {
+				return fmt.Errorf("failed to send engine command: %w", err)
 			}
-			return
-		}
 
-		if JSONOutput != nil && *JSONOutput {
-			output, _ := json.Marshal(map[string]interface{}{
-				"command": "engine",
-				"action":  action,
-				"status":  "success",
+			return a.Render(map[string]interface{}{
+				"action": action,
+			}, func() {
+				fmt.Printf("%s Engine power: %s\n", format.Success("✓"), action)
 			})
-			fmt.Println(string(output))
-		} else {
-			fmt.Printf("%s Engine power: %s\n", format.Success("✓"), action)
-		}
-	},
+		},
+	}
+	cmd.Flags().BoolVar(&force, "force", false, "Turn the engine off even while the scooter is ridden")
+	return cmd
 }
 
-var (
-	onWaitTimeout int
-)
-
-var dbcStatusCmd = &cobra.Command{
-	Use:   "status",
-	Short: "Show DBC status (ready state and power)",
-	Long:  `Display dashboard ready state and power output status.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		// Get dashboard ready state
-		ready, err := RedisClient.HGet("dashboard", "ready")
-		if err != nil {
-			if JSONOutput != nil && *JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"status": "error",
-					"error":  err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Failed to get dashboard state: %v\n"), err)
+func newDbcStatusCmd(a *app.App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show DBC status (ready state and power)",
+		Long:  `Display dashboard ready state and power output status.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Get dashboard ready state
+			ready, err := a.Redis.HGet("dashboard", "ready")
+			if err != nil {
+				return fmt.Errorf("failed to get dashboard state: %w", err)
 			}
-			return
-		}
 
-		if JSONOutput != nil && *JSONOutput {
-			output := map[string]interface{}{
+			return a.Render(map[string]interface{}{
 				"ready": ready == "true",
-			}
-			data, _ := json.Marshal(output)
-			fmt.Println(string(data))
-		} else {
-			fmt.Println("Dashboard Status:")
-			fmt.Println(strings.Repeat("─", 40))
-
-			// Ready state
-			if ready == "true" {
-				fmt.Printf("Ready: %s\n", format.Success("yes"))
-			} else {
-				fmt.Printf("Ready: %s\n", format.Warning("no"))
-			}
-		}
-	},
+			}, func() {
+				fmt.Println("Dashboard Status:")
+				fmt.Println(strings.Repeat("─", 40))
+
+				// Ready state
+				if ready == "true" {
+					fmt.Printf("Ready: %s\n", format.Success("yes"))
+				} else {
+					fmt.Printf("Ready: %s\n", format.Warning("no"))
+				}
+			})
+		},
+	}
 }
 
-var dbcPingCmd = &cobra.Command{
-	Use:   "ping",
-	Short: "Ping the DBC to check connectivity",
-	Long:  `Ping the Dashboard Computer at 192.168.7.2 to verify network connectivity.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		pingCmd := exec.Command("ping", "192.168.7.2")
-		pingCmd.Stdout = os.Stdout
-		pingCmd.Stderr = os.Stderr
-		pingCmd.Stdin = os.Stdin
-		pingCmd.Run()
-	},
+func newDbcPingCmd(a *app.App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "ping",
+		Short: "Ping the DBC to check connectivity",
+		Long:  `Ping the Dashboard Computer at 192.168.7.2 to verify network connectivity.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pingCmd := runner.Command(a.Runner, "ping", "192.168.7.2")
+			pingCmd.Stdout = os.Stdout
+			pingCmd.Stderr = os.Stderr
+			pingCmd.Stdin = os.Stdin
+			return pingCmd.Run()
+		},
+	}
 }
 
-var dbcOnWaitCmd = &cobra.Command{
-	Use:   "on-wait",
-	Short: "Turn on DBC and wait until ready",
-	Long:  `Send dashboard:on command and wait for the dashboard to publish 'ready' state.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		ctx := context.Background()
-
-		// Subscribe to dashboard channel before sending command
-		pubsub := RedisClient.Subscribe(ctx, "dashboard")
-		defer pubsub.Close()
-
-		ch := pubsub.Channel()
-
-		// Allow subscription to establish
-		time.Sleep(100 * time.Millisecond)
-
-		// Send dashboard:on command
-		fmt.Println("Turning on dashboard...")
-		err := RedisClient.LPush("scooter:hardware", "dashboard:on")
-		if err != nil {
-			fmt.Printf("Error sending dashboard:on command: %v\n", err)
-			return
-		}
-
-		// Wait for ready notification
-		fmt.Println("Waiting for dashboard ready notification...")
-		timeoutChan := time.After(time.Duration(onWaitTimeout) * time.Second)
-
-		for {
-			select {
-			case msg := <-ch:
-				// Check if it's a ready notification
-				if msg.Payload == "ready" {
-					// Verify ready state
-					ready, err := RedisClient.HGet("dashboard", "ready")
-					if err == nil && ready == "true" {
-						fmt.Println("Dashboard is ready!")
-						return
-					}
-				}
-			case <-timeoutChan:
-				fmt.Printf("Timeout waiting for dashboard ready after %d seconds\n", onWaitTimeout)
-				return
+func newDbcOnWaitCmd(a *app.App) *cobra.Command {
+	var onWaitTimeout int
+	cmd := &cobra.Command{
+		Use:   "on-wait",
+		Short: "Turn on DBC and wait until ready",
+		Long:  `Send dashboard:on command and wait for the dashboard to publish 'ready' state.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !a.Structured() {
+				fmt.Println("Turning on dashboard...")
 			}
-		}
-	},
-}
-
-var dbcOffWaitCmd = &cobra.Command{
-	Use:   "off-wait",
-	Short: "Turn off DBC and wait until unreachable",
-	Long:  `Send dashboard:off command and wait for the DBC to become unreachable via ping.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		// Send dashboard:off command
-		fmt.Println("Turning off dashboard...")
-		err := RedisClient.LPush("scooter:hardware", "dashboard:off")
-		if err != nil {
-			fmt.Printf("Error sending dashboard:off command: %v\n", err)
-			return
-		}
-
-		// Wait for DBC to become unreachable
-		fmt.Println("Waiting for dashboard to become unreachable...")
-		startTime := time.Now()
-		timeout := time.Duration(onWaitTimeout) * time.Second
-
-		// Give it a moment to start shutting down
-		time.Sleep(2 * time.Second)
-
-		for {
-			// Check if timeout exceeded
-			if time.Since(startTime) > timeout {
-				fmt.Printf("Timeout waiting for dashboard off after %d seconds\n", onWaitTimeout)
-				return
+			result, err := a.Confirm(cmd.Context(), confirm.Command{
+				List:    "scooter:hardware",
+				Payload: "dashboard:on",
+				Hash:    "dashboard",
+				Field:   "ready",
+				Accept:  []string{"true"},
+				Timeout: time.Duration(onWaitTimeout) * time.Second,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to confirm dashboard ready: %w", err)
 			}
 
-			// Try to ping DBC
-			pingCmd := exec.Command("ping", "-c", "1", "-W", "1", "192.168.7.2")
-			err := pingCmd.Run()
+			return a.Render(map[string]interface{}{
+				"ready":      true,
+				"elapsed_ms": result.Elapsed.Milliseconds(),
+			}, func() {
+				fmt.Println("Dashboard is ready!")
+			})
+		},
+	}
+	cmd.Flags().IntVarP(&onWaitTimeout, "timeout", "t", 60, "Timeout in seconds to wait for DBC ready")
+	return cmd
+}
 
-			// If ping fails, DBC is unreachable (off)
+func newDbcOffWaitCmd(a *app.App) *cobra.Command {
+	var onWaitTimeout int
+	cmd := &cobra.Command{
+		Use:   "off-wait",
+		Short: "Turn off DBC and wait until unreachable",
+		Long:  `Send dashboard:off command and wait for the DBC to become unreachable via ping.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Send dashboard:off command
+			if !a.Structured() {
+				fmt.Println("Turning off dashboard...")
+			}
+			err := a.Send(cmd.Context(), confirm.Command{List: "scooter:hardware", Payload: "dashboard:off"})
 			if err != nil {
-				fmt.Println("Dashboard is off!")
-				return
+				return fmt.Errorf("failed to send dashboard:off command: %w", err)
 			}
 
-			// Wait a bit before trying again
-			time.Sleep(1 * time.Second)
-		}
-	},
-}
+			// Wait for DBC to become unreachable
+			if !a.Structured() {
+				fmt.Println("Waiting for dashboard to become unreachable...")
+			}
+			startTime := a.Now()
+			timeout := time.Duration(onWaitTimeout) * time.Second
 
-func init() {
-	dbcOnWaitCmd.Flags().IntVarP(&onWaitTimeout, "timeout", "t", 60, "Timeout in seconds to wait for DBC ready")
-	dbcOffWaitCmd.Flags().IntVarP(&onWaitTimeout, "timeout", "t", 60, "Timeout in seconds to wait for DBC off")
+			// Give it a moment to start shutting down
+			time.Sleep(2 * time.Second)
 
-	dashboardCmd.AddCommand(dbcStatusCmd)
-	dashboardCmd.AddCommand(dbcPingCmd)
-	dashboardCmd.AddCommand(dbcOnWaitCmd)
-	dashboardCmd.AddCommand(dbcOffWaitCmd)
+			for {
+				// Check if timeout exceeded
+				if a.Now().Sub(startTime) > timeout {
+					return output.Timeout("timeout waiting for dashboard off after %d seconds", onWaitTimeout)
+				}
+
+				// Try to ping DBC
+				pingCmd := runner.Command(a.Runner, "ping", "-c", "1", "-W", "1", "192.168.7.2")
+				err := pingCmd.Run()
+
+				// If ping fails, DBC is unreachable (off)
+				if err != nil {
+					return a.Render(map[string]interface{}{
+						"reachable":  false,
+						"elapsed_ms": a.Now().Sub(startTime).Milliseconds(),
+					}, func() {
+						fmt.Println("Dashboard is off!")
+					})
+				}
 
-	DiagCmd.AddCommand(dashboardCmd)
-	DiagCmd.AddCommand(engineCmd)
+				// Wait a bit before trying again
+				time.Sleep(1 * time.Second)
+			}
+		},
+	}
+	cmd.Flags().IntVarP(&onWaitTimeout, "timeout", "t", 60, "Timeout in seconds to wait for DBC off")
+	return cmd
 }
@@ -1,67 +1,41 @@
 package diag
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
 
+	"librescoot/lsc/internal/app"
+	"librescoot/lsc/internal/confirm"
 	"librescoot/lsc/internal/format"
+	"librescoot/lsc/internal/output"
 
 	"github.com/spf13/cobra"
 )
 
-var hornCmd = &cobra.Command{
-	Use:       "horn [on|off]",
-	Short:     "Control horn",
-	Long:      `Control the scooter's horn.`,
-	Args:      cobra.ExactArgs(1),
-	ValidArgs: []string{"on", "off"},
-	Run: func(cmd *cobra.Command, args []string) {
-		state := args[0]
-
-		// Validate argument
-		if state != "on" && state != "off" {
-			if JSONOutput != nil && *JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "horn",
-					"status":  "error",
-					"error":   fmt.Sprintf("invalid state: %s", state),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Invalid state '%s'. Must be 'on' or 'off'\n"), state)
+func newHornCmd(a *app.App) *cobra.Command {
+	return &cobra.Command{
+		Use:       "horn [on|off]",
+		Short:     "Control horn",
+		Long:      `Control the scooter's horn.`,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{"on", "off"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			state := args[0]
+
+			// Validate argument
+			if state != "on" && state != "off" {
+				return output.InvalidArgument("invalid state '%s'; must be 'on' or 'off'", state)
 			}
-			return
-		}
 
-		// Send command
-		if err := RedisClient.LPush("scooter:horn", state); err != nil {
-			if JSONOutput != nil && *JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "horn",
-					"status":  "error",
-					"error":   err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Failed to send horn command: %v\n"), err)
+			// Send command
+			if err := a.Send(cmd.Context(), confirm.Command{List: "scooter:horn", Payload: state}); err != nil {
+				return fmt.Errorf("failed to send horn command: %w", err)
 			}
-			return
-		}
 
-		if JSONOutput != nil && *JSONOutput {
-			output, _ := json.Marshal(map[string]interface{}{
-				"command": "horn",
-				"status":  "success",
-				"state":   state,
+			return a.Render(map[string]interface{}{
+				"state": state,
+			}, func() {
+				fmt.Printf("%s Horn: %s\n", format.Success("✓"), state)
 			})
-			fmt.Println(string(output))
-		} else {
-			fmt.Printf("%s Horn: %s\n", format.Success("✓"), state)
-		}
-	},
-}
-
-func init() {
-	DiagCmd.AddCommand(hornCmd)
+		},
+	}
 }
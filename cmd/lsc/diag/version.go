@@ -1,132 +1,112 @@
 package diag
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
+	"strconv"
 
+	"librescoot/lsc/internal/app"
 	"librescoot/lsc/internal/format"
+	"librescoot/lsc/internal/model"
 
 	"github.com/spf13/cobra"
 )
 
-var versionCmd = &cobra.Command{
-	Use:   "version",
-	Short: "Show firmware versions",
-	Long:  `Display firmware versions for all system components.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		// Fetch version data from various sources
-		system, err := RedisClient.HGetAll("system")
-		if err != nil {
-			if JSONOutput != nil && *JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"error": err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Failed to fetch system data: %v\n"), err)
+func newVersionCmd(a *app.App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Show firmware versions",
+		Long:  `Display firmware versions for all system components.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Fetch version data from various sources
+			systemData, err := a.Redis.HGetAll(model.SystemKey)
+			if err != nil {
+				return fmt.Errorf("failed to fetch system data: %w", err)
 			}
-			return
-		}
+			system := model.ParseSystem(systemData)
 
-		ecuData, _ := RedisClient.HGetAll("engine-ecu")
-		battery0Data, _ := RedisClient.HGetAll("battery:0")
-		battery1Data, _ := RedisClient.HGetAll("battery:1")
-		otaData, _ := RedisClient.HGetAll("ota")
+			ecuData, _ := a.Redis.HGetAll(model.EngineECUKey)
+			ecu := model.ParseEngineECU(ecuData)
+			otaData, _ := a.Redis.HGetAll(model.OTAKey)
+			ota := model.ParseOTA(otaData)
+			var batteries []*model.Battery
+			for _, slot := range model.BatterySlots {
+				batteryData, _ := a.Redis.HGetAll(model.BatteryKey(slot))
+				batteries = append(batteries, model.ParseBattery(slot, batteryData))
+			}
 
-		if JSONOutput != nil && *JSONOutput {
-			// Build JSON output
-			output := map[string]interface{}{
+			data := map[string]interface{}{
 				"system": map[string]interface{}{
-					"mdb":         system["mdb-version"],
-					"dbc":         system["dbc-version"],
-					"nrf":         system["nrf-fw-version"],
-					"environment": system["environment"],
+					"mdb":         system.MDBVersion,
+					"dbc":         system.DBCVersion,
+					"nrf":         system.NRFVersion,
+					"environment": system.Environment,
 				},
 				"components": map[string]interface{}{
-					"ecu": ecuData["fw-version"],
+					"ecu": ecu.FirmwareVersion,
 				},
 				"ota": map[string]interface{}{
-					"system":       otaData["system"],
-					"status":       otaData["status"],
-					"fresh_update": otaData["fresh-update"] == "true",
+					"system":       ota.System,
+					"status":       ota.Status,
+					"fresh_update": ota.FreshUpdate,
 				},
 			}
 
 			// Add battery info
-			batteries := make(map[string]interface{})
-			if battery0Data["present"] == "true" {
-				batteries["0"] = map[string]interface{}{
-					"present":       true,
-					"version":       battery0Data["fw-version"],
-					"serial_number": battery0Data["serial-number"],
-				}
-			} else {
-				batteries["0"] = map[string]interface{}{"present": false}
-			}
-			if battery1Data["present"] == "true" {
-				batteries["1"] = map[string]interface{}{
-					"present":       true,
-					"version":       battery1Data["fw-version"],
-					"serial_number": battery1Data["serial-number"],
+			batteryData := make(map[string]interface{})
+			for _, battery := range batteries {
+				if battery.Present {
+					batteryData[strconv.Itoa(battery.Slot)] = map[string]interface{}{
+						"present":       true,
+						"version":       battery.FirmwareVersion,
+						"serial_number": battery.SerialNumber,
+					}
+				} else {
+					batteryData[strconv.Itoa(battery.Slot)] = map[string]interface{}{"present": false}
 				}
-			} else {
-				batteries["1"] = map[string]interface{}{"present": false}
 			}
-			output["batteries"] = batteries
+			data["batteries"] = batteryData
 
-			jsonBytes, _ := json.MarshalIndent(output, "", "  ")
-			fmt.Println(string(jsonBytes))
-			return
-		}
+			return a.Render(data, func() {
+				printVersions(system, ecu, batteries, ota)
+			})
+		},
+	}
+}
 
-		// Display system versions
-		format.PrintSection("System Versions")
-		format.PrintKV("MDB", format.SafeValueOr(system["mdb-version"], "N/A"))
-		format.PrintKV("DBC", format.SafeValueOr(system["dbc-version"], "N/A"))
-		format.PrintKV("nRF", format.SafeValueOr(system["nrf-fw-version"], "N/A"))
-		format.PrintKV("Environment", format.SafeValueOr(system["environment"], "N/A"))
+// printVersions prints the system, component and OTA version sections
+func printVersions(system *model.System, ecu *model.EngineECU, batteries []*model.Battery, ota *model.OTA) {
+	// Display system versions
+	format.PrintSection("System Versions")
+	format.PrintKV("MDB", format.SafeValueOr(system.MDBVersion, "N/A"))
+	format.PrintKV("DBC", format.SafeValueOr(system.DBCVersion, "N/A"))
+	format.PrintKV("nRF", format.SafeValueOr(system.NRFVersion, "N/A"))
+	format.PrintKV("Environment", format.SafeValueOr(system.Environment, "N/A"))
 
-		// Display component versions
-		format.PrintSection("Component Versions")
-		format.PrintKV("ECU", format.SafeValueOr(ecuData["fw-version"], "N/A"))
+	// Display component versions
+	format.PrintSection("Component Versions")
+	format.PrintKV("ECU", format.SafeValueOr(ecu.FirmwareVersion, "N/A"))
 
-		if battery0Data["present"] == "true" {
-			serial := format.SafeValueOr(battery0Data["serial-number"], "")
-			version := format.SafeValueOr(battery0Data["fw-version"], "N/A")
-			if serial != "" {
-				format.PrintKV("Battery 0", fmt.Sprintf("%s (S/N: %s)", version, serial))
-			} else {
-				format.PrintKV("Battery 0", version)
-			}
-		} else {
-			format.PrintKV("Battery 0", format.Dim("Not Present"))
+	for _, battery := range batteries {
+		label := fmt.Sprintf("Battery %d", battery.Slot)
+		if !battery.Present {
+			format.PrintKV(label, format.Dim("Not Present"))
+			continue
 		}
-
-		if battery1Data["present"] == "true" {
-			serial := format.SafeValueOr(battery1Data["serial-number"], "")
-			version := format.SafeValueOr(battery1Data["fw-version"], "N/A")
-			if serial != "" {
-				format.PrintKV("Battery 1", fmt.Sprintf("%s (S/N: %s)", version, serial))
-			} else {
-				format.PrintKV("Battery 1", version)
-			}
+		version := format.SafeValueOr(battery.FirmwareVersion, "N/A")
+		if battery.SerialNumber != "" {
+			format.PrintKV(label, fmt.Sprintf("%s (S/N: %s)", version, battery.SerialNumber))
 		} else {
-			format.PrintKV("Battery 1", format.Dim("Not Present"))
+			format.PrintKV(label, version)
 		}
+	}
 
-		// Display OTA info
-		format.PrintSection("OTA System")
-		format.PrintKV("System", format.SafeValueOr(otaData["system"], "N/A"))
-		format.PrintKV("Status", format.SafeValueOr(otaData["status"], "N/A"))
-		if otaData["fresh-update"] == "true" {
-			format.PrintKV("Fresh Update", format.Success("Yes"))
-		}
-
-		fmt.Println()
-	},
-}
+	// Display OTA info
+	format.PrintSection("OTA System")
+	format.PrintKV("System", format.SafeValueOr(ota.System, "N/A"))
+	format.PrintKV("Status", format.SafeValueOr(ota.Status, "N/A"))
+	if ota.FreshUpdate {
+		format.PrintKV("Fresh Update", format.Success("Yes"))
+	}
 
-func init() {
-	DiagCmd.AddCommand(versionCmd)
+	fmt.Println()
 }
@@ -0,0 +1,119 @@
+package lsc
+
+import "testing"
+
+func TestDiagFaults(t *testing.T) {
+	srv := newScooter(t)
+	srv.SAdd("battery:0:faults", "32")
+
+	res := mustRun(t, srv, "diag", "faults")
+	assertContains(t, res.stdout, "=== Active Faults (2) ===", "Vehicle Faults:\n  • 12")
+
+	data := jsonData(t, srv, "diag", "faults")
+	if data["total_faults"] != 2.0 {
+		t.Errorf("total_faults = %v, want 2", data["total_faults"])
+	}
+	if got := lookup(t, data, "battery_0.0"); got != "32" {
+		t.Errorf("battery_0 faults = %v", data["battery_0"])
+	}
+}
+
+func TestDiagEvents(t *testing.T) {
+	srv := newScooter(t)
+
+	res := mustRun(t, srv, "diag", "events")
+	assertContains(t, res.stdout,
+		"[ecu:12] Motor controller overcurrent",
+		"[battery:0:32] Battery temperature high",
+	)
+
+	envelope := decodeResult(t, mustRun(t, srv, "diag", "events", "--json"))
+	events, ok := envelope.Data.([]interface{})
+	if !ok || len(events) != 2 {
+		t.Fatalf("events = %v", envelope.Data)
+	}
+	first := events[0].(map[string]interface{})
+	if first["id"] != "1700000000000-0" || first["group"] != "ecu" || first["timestamp"] != 1700000000000.0 {
+		t.Errorf("first event = %v", first)
+	}
+}
+
+func TestDiagBattery(t *testing.T) {
+	srv := newScooter(t)
+
+	res := mustRun(t, srv, "diag", "battery")
+	assertContains(t, res.stdout,
+		"=== Battery 0 ===",
+		"Sensor 3:            23°C",
+		"Serial Number:       BAT0001",
+		"=== Battery 1 ===\n  Not Present",
+	)
+
+	data := jsonData(t, srv, "diag", "battery")
+	for path, want := range map[string]interface{}{
+		"batteries.0.charge.voltage_v":          53.2,
+		"batteries.0.temperature.sensor_3_c":    23.0,
+		"batteries.0.health.cycles":             42.0,
+		"batteries.0.identity.firmware_version": "A1.2.3",
+		"batteries.1.present":                   false,
+	} {
+		if got := lookup(t, data, path); got != want {
+			t.Errorf("%s = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestDiagVersion(t *testing.T) {
+	srv := newScooter(t)
+
+	res := mustRun(t, srv, "diag", "version")
+	assertContains(t, res.stdout,
+		"MDB:                 v1.4.0",
+		"ECU:                 0445400C",
+		"Battery 0:           A1.2.3 (S/N: BAT0001)",
+		"Battery 1:           Not Present",
+	)
+
+	data := jsonData(t, srv, "diag", "version")
+	for path, want := range map[string]interface{}{
+		"system.mdb":          "v1.4.0",
+		"system.dbc":          "v1.4.1",
+		"components.ecu":      "0445400C",
+		"batteries.0.version": "A1.2.3",
+		"ota.status":          "downloading",
+	} {
+		if got := lookup(t, data, path); got != want {
+			t.Errorf("%s = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestDashboardPower(t *testing.T) {
+	srv := newScooter(t)
+	writeUserConfig(t, "")
+
+	// 'lsc dashboard on' still switches the power, as before the live view
+	mustRun(t, srv, "dashboard", "on")
+	if got, _ := srv.List("scooter:hardware"); len(got) != 1 || got[0] != "dashboard:on" {
+		t.Errorf("scooter:hardware = %v, want [dashboard:on]", got)
+	}
+	srv.Del("scooter:hardware")
+
+	// and is recorded as diag.dashboard
+	records := decodeResult(t, mustRun(t, srv, "audit", "show", "--json")).Data.([]interface{})
+	if len(records) != 1 {
+		t.Fatalf("records = %v, want 1", records)
+	}
+	if r := records[0].(map[string]interface{}); r["command"] != "diag.dashboard" || r["payload"] != "dashboard:on" {
+		t.Errorf("record = %v", r)
+	}
+
+	// and is checked as diag.dashboard
+	writeUserConfig(t, `
+[access]
+deny = ["diag.dashboard"]
+`)
+	res := runLSC(t, srv, "dashboard", "off")
+	assertCode(t, res.err, "permission_denied")
+	assertContains(t, res.stderr, "lsc diag.dashboard is denied")
+}
@@ -0,0 +1,213 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"librescoot/lsc/internal/app"
+	"librescoot/lsc/internal/model"
+	"librescoot/lsc/internal/redis"
+
+	"github.com/spf13/cobra"
+)
+
+// vehicleStates are the states of the vehicle state machine
+var vehicleStates = []string{
+	"init", "stand-by", "parked", "ready-to-drive", "waiting-seatbox",
+	"shutting-down", "updating", "waiting-hibernation", "waiting-hibernation-advanced",
+	"waiting-hibernation-seatbox", "waiting-hibernation-confirm", "hibernating",
+}
+
+// scrapeTimeout bounds the Redis reads of one scrape
+const scrapeTimeout = 10 * time.Second
+
+// NewCommand creates the exporter command
+func NewCommand(a *app.App) *cobra.Command {
+	var (
+		exporterListen string
+		exporterPath   string
+	)
+	cmd := &cobra.Command{
+		Use:   "exporter",
+		Short: "Serve scooter telemetry as Prometheus metrics",
+		Long: `Serve battery, motor, vehicle, fault and modem telemetry in the Prometheus
+text format (or OpenMetrics, if the scraper asks for it) for continuous
+scraping. Every scrape reads the current values from Redis.
+
+Metrics:
+  lsc_up                              1 if Redis could be read
+  lsc_battery_present                 Battery inserted, per battery
+  lsc_battery_soc_percent             State of charge
+  lsc_battery_voltage_volts           Pack voltage
+  lsc_battery_current_amps            Pack current
+  lsc_battery_temperature_celsius     Temperature, per sensor
+  lsc_battery_health_percent          State of health
+  lsc_battery_charge_cycles_total     Charge cycles
+  lsc_motor_speed_kmh                 Speed
+  lsc_motor_rpm                       Motor RPM
+  lsc_motor_odometer_meters_total     Odometer
+  lsc_motor_voltage_volts             Motor voltage
+  lsc_motor_current_amps              Motor current
+  lsc_motor_temperature_celsius       Motor temperature
+  lsc_vehicle_state                   Vehicle state (one series per state)
+  lsc_faults_active                   Active faults, per source
+  lsc_modem_signal_quality_percent    Modem signal quality
+  lsc_internet_connected              1 if the modem is online
+
+Example Prometheus job:
+  - job_name: scooters
+    static_configs:
+      - targets: ['bench-1:9100', 'bench-2:9100']
+
+Examples:
+  lsc exporter
+  lsc --ssh deep-blue exporter --listen :9101`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			listener, err := net.Listen("tcp", exporterListen)
+			if err != nil {
+				return fmt.Errorf("failed to listen on %s: %w", exporterListen, err)
+			}
+
+			mux := http.NewServeMux()
+			mux.HandleFunc(exporterPath, metricsHandler(a.Redis))
+			server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+			sigChan := make(chan os.Signal, 1)
+			signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+			defer signal.Stop(sigChan)
+			go func() {
+				<-sigChan
+				ctx, cancel := context.WithTimeout(cmd.Context(), 5*time.Second)
+				defer cancel()
+				server.Shutdown(ctx)
+			}()
+
+			fmt.Fprintf(os.Stderr, "Serving metrics on http://%s%s\n", listener.Addr(), exporterPath)
+			if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&exporterListen, "listen", ":9100", "Address to listen on (host:port)")
+	cmd.Flags().StringVar(&exporterPath, "path", "/metrics", "HTTP path of the metrics")
+	return cmd
+}
+
+// metricsHandler handles the scrapes, reading from client
+func metricsHandler(client *redis.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), scrapeTimeout)
+		defer cancel()
+
+		metrics := collect(ctx, client)
+
+		openMetrics := strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")
+		if openMetrics {
+			w.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+		} else {
+			w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		}
+		metrics.write(w, openMetrics)
+	}
+}
+
+// collect reads the telemetry hashes and fault sets. A failed read of a hash
+// leaves out its metrics and sets lsc_up to 0.
+func collect(ctx context.Context, client *redis.Client) *metricSet {
+	m := newMetricSet()
+	// lsc_up goes first but is only known at the end
+	upFamily := m.family("lsc_up", typeGauge, "Whether the scooter's Redis could be read")
+	up := 1.0
+	hgetall := func(key string) map[string]string {
+		data, err := client.HGetAllWithContext(ctx, key)
+		if err != nil {
+			up = 0
+			return nil
+		}
+		return data
+	}
+	faults := func(key string) {
+		members, err := client.SMembersWithContext(ctx, key)
+		if err != nil {
+			up = 0
+			return
+		}
+		m.add("lsc_faults_active", typeGauge, "Number of active faults", float64(len(members)), label{"source", key})
+	}
+
+	for _, slot := range model.BatterySlots {
+		if data := hgetall(model.BatteryKey(slot)); data != nil {
+			collectBattery(m, model.ParseBattery(slot, data))
+		}
+	}
+	if data := hgetall(model.EngineECUKey); data != nil {
+		collectMotor(m, model.ParseEngineECU(data))
+	}
+	if data := hgetall(model.VehicleKey); data != nil {
+		m.addState("lsc_vehicle_state", "Vehicle state machine state", model.ParseVehicle(data).State, vehicleStates)
+	}
+
+	faults("vehicle:fault")
+	for _, slot := range model.BatterySlots {
+		faults(model.BatteryFaultsKey(slot))
+	}
+
+	if data := hgetall(model.InternetKey); data != nil {
+		internet := model.ParseInternet(data)
+		m.addField("lsc_modem_signal_quality_percent", typeGauge, "Modem signal quality", &internet.Fields, "signal-quality", float64(internet.SignalQuality))
+		if internet.Status != "" {
+			connected := 0.0
+			if internet.Connected() {
+				connected = 1
+			}
+			m.add("lsc_internet_connected", typeGauge, "Whether the modem is connected to the internet", connected)
+		}
+	}
+
+	upFamily.samples = append(upFamily.samples, sample{value: up})
+	return m
+}
+
+func collectBattery(m *metricSet, b *model.Battery) {
+	battery := label{"battery", strconv.Itoa(b.Slot)}
+
+	present := 0.0
+	if b.Present {
+		present = 1
+	}
+	m.add("lsc_battery_present", typeGauge, "Whether the battery is inserted", present, battery)
+	if !b.Present {
+		return
+	}
+
+	m.addField("lsc_battery_soc_percent", typeGauge, "Battery state of charge", &b.Fields, "charge", float64(b.Charge), battery)
+	m.addField("lsc_battery_voltage_volts", typeGauge, "Battery voltage", &b.Fields, "voltage", b.Voltage, battery)
+	m.addField("lsc_battery_current_amps", typeGauge, "Battery current", &b.Fields, "current", b.Current, battery)
+	for sensor, temperature := range b.Temperatures {
+		m.addField("lsc_battery_temperature_celsius", typeGauge, "Battery temperature", &b.Fields, fmt.Sprintf("temperature:%d", sensor), float64(temperature),
+			battery, label{"sensor", fmt.Sprint(sensor)})
+	}
+	m.addField("lsc_battery_health_percent", typeGauge, "Battery state of health", &b.Fields, "state-of-health", float64(b.StateOfHealth), battery)
+	m.addField("lsc_battery_charge_cycles", typeCounter, "Battery charge cycles", &b.Fields, "cycle-count", float64(b.CycleCount), battery)
+}
+
+func collectMotor(m *metricSet, e *model.EngineECU) {
+	m.addField("lsc_motor_speed_kmh", typeGauge, "Vehicle speed reported by the motor controller", &e.Fields, "speed", float64(e.Speed))
+	m.addField("lsc_motor_rpm", typeGauge, "Motor revolutions per minute", &e.Fields, "rpm", float64(e.RPM))
+	m.addField("lsc_motor_odometer_meters", typeCounter, "Odometer", &e.Fields, "odometer", math.Round(e.Odometer*1000))
+	m.addField("lsc_motor_voltage_volts", typeGauge, "Motor controller voltage", &e.Fields, "motor:voltage", e.Voltage)
+	m.addField("lsc_motor_current_amps", typeGauge, "Motor controller current", &e.Fields, "motor:current", e.Current)
+	m.addField("lsc_motor_temperature_celsius", typeGauge, "Motor controller temperature", &e.Fields, "temperature", float64(e.Temperature))
+}
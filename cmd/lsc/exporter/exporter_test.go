@@ -0,0 +1,124 @@
+package exporter
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"librescoot/lsc/internal/redis"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// scrape requests the metrics of srv, as OpenMetrics if openMetrics is set,
+// and returns the content type and exposition text
+func scrape(t *testing.T, srv *miniredis.Miniredis, openMetrics bool) (string, string) {
+	t.Helper()
+	client := redis.NewClient(srv.Addr())
+	t.Cleanup(func() { client.Close() })
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	if openMetrics {
+		req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	}
+	rec := httptest.NewRecorder()
+	metricsHandler(client)(rec, req)
+	return rec.Header().Get("Content-Type"), rec.Body.String()
+}
+
+// newScooter seeds a parked scooter with one battery and active faults
+func newScooter(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+	srv := miniredis.RunT(t)
+	for key, fields := range map[string]map[string]string{
+		"battery:0": {
+			"present": "true", "charge": "87", "voltage": "53200", "current": "-1200",
+			"temperature:0": "21", "cycle-count": "42",
+		},
+		"battery:1":  {"present": "false"},
+		"engine-ecu": {"speed": "0", "odometer": "1234500"},
+		"vehicle":    {"state": "parked"},
+		"internet":   {"status": "connected", "signal-quality": "71"},
+	} {
+		for field, value := range fields {
+			srv.HSet(key, field, value)
+		}
+	}
+	srv.SAdd("vehicle:fault", "12", "14")
+	srv.SAdd("battery:0:faults", "32")
+	return srv
+}
+
+func TestMetricsPrometheus(t *testing.T) {
+	contentType, text := scrape(t, newScooter(t), false)
+	if !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Errorf("content type = %s", contentType)
+	}
+
+	for _, want := range []string{
+		"# TYPE lsc_up gauge\nlsc_up 1\n",
+		`lsc_battery_present{battery="0"} 1`,
+		`lsc_battery_present{battery="1"} 0`,
+		`lsc_battery_soc_percent{battery="0"} 87`,
+		`lsc_battery_current_amps{battery="0"} -1.2`,
+		`lsc_battery_temperature_celsius{battery="0",sensor="0"} 21`,
+		// Counters carry _total in the TYPE line of the Prometheus format
+		"# TYPE lsc_battery_charge_cycles_total counter\nlsc_battery_charge_cycles_total{battery=\"0\"} 42\n",
+		"# TYPE lsc_motor_odometer_meters_total counter\nlsc_motor_odometer_meters_total 1.2345e+06\n",
+		// The Prometheus format has no state sets
+		"# TYPE lsc_vehicle_state gauge\n",
+		`lsc_vehicle_state{lsc_vehicle_state="parked"} 1`,
+		`lsc_vehicle_state{lsc_vehicle_state="stand-by"} 0`,
+		`lsc_faults_active{source="vehicle:fault"} 2`,
+		`lsc_faults_active{source="battery:0:faults"} 1`,
+		`lsc_faults_active{source="battery:1:faults"} 0`,
+		"lsc_modem_signal_quality_percent 71",
+		"lsc_internet_connected 1",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("metrics do not contain %q:\n%s", want, text)
+		}
+	}
+
+	// Fields the battery does not publish are left out, not reported as 0
+	if strings.Contains(text, `sensor="1"`) || strings.Contains(text, "lsc_battery_health_percent") {
+		t.Errorf("missing fields were exported:\n%s", text)
+	}
+	if strings.Contains(text, "# EOF") {
+		t.Error("Prometheus format ends with # EOF")
+	}
+}
+
+func TestMetricsOpenMetrics(t *testing.T) {
+	contentType, text := scrape(t, newScooter(t), true)
+	if !strings.HasPrefix(contentType, "application/openmetrics-text; version=1.0.0") {
+		t.Errorf("content type = %s", contentType)
+	}
+
+	for _, want := range []string{
+		// OpenMetrics names the counter family without _total
+		"# TYPE lsc_battery_charge_cycles counter\nlsc_battery_charge_cycles_total{battery=\"0\"} 42\n",
+		"# TYPE lsc_vehicle_state stateset\n",
+		`lsc_vehicle_state{lsc_vehicle_state="parked"} 1`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("metrics do not contain %q:\n%s", want, text)
+		}
+	}
+	if !strings.HasSuffix(text, "# EOF\n") {
+		t.Errorf("OpenMetrics does not end with # EOF:\n%s", text)
+	}
+}
+
+func TestMetricsRedisDown(t *testing.T) {
+	srv := newScooter(t)
+	client := redis.NewClient(srv.Addr())
+	t.Cleanup(func() { client.Close() })
+	srv.Close()
+
+	rec := httptest.NewRecorder()
+	metricsHandler(client)(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if text := rec.Body.String(); !strings.HasPrefix(text, "# HELP lsc_up") || !strings.Contains(text, "lsc_up 0\n") || strings.Contains(text, "lsc_battery") {
+		t.Errorf("metrics with Redis down:\n%s", text)
+	}
+}
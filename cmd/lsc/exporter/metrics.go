@@ -0,0 +1,156 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"librescoot/lsc/internal/model"
+)
+
+// Metric types of the exposition formats
+const (
+	typeGauge    = "gauge"
+	typeCounter  = "counter"
+	typeStateSet = "stateset"
+)
+
+// label is one name="value" pair of a sample
+type label struct {
+	name, value string
+}
+
+// sample is one value of a metric family
+type sample struct {
+	labels []label
+	value  float64
+}
+
+// family is a metric with its samples, written in the order they were added
+type family struct {
+	name    string
+	help    string
+	kind    string
+	samples []sample
+}
+
+// metricSet collects metric families for one scrape
+type metricSet struct {
+	families []*family
+	byName   map[string]*family
+}
+
+func newMetricSet() *metricSet {
+	return &metricSet{byName: make(map[string]*family)}
+}
+
+// family returns the named family, adding it on first use
+func (m *metricSet) family(name, kind, help string) *family {
+	if f, ok := m.byName[name]; ok {
+		return f
+	}
+	f := &family{name: name, help: help, kind: kind}
+	m.families = append(m.families, f)
+	m.byName[name] = f
+	return f
+}
+
+// add records a sample
+func (m *metricSet) add(name, kind, help string, value float64, labels ...label) {
+	f := m.family(name, kind, help)
+	f.samples = append(f.samples, sample{labels: labels, value: value})
+}
+
+// addField records a sample of a parsed hash field. Missing or malformed
+// fields are left out instead of being reported as zero.
+func (m *metricSet) addField(name, kind, help string, fields *model.Fields, field string, value float64, labels ...label) {
+	if !fields.Has(field) {
+		return
+	}
+	m.add(name, kind, help, value, labels...)
+}
+
+// addState records an enum as one sample per known state, set to 1 for the
+// current state. Unknown states are added so they are not lost.
+func (m *metricSet) addState(name, help, current string, states []string, labels ...label) {
+	f := m.family(name, typeStateSet, help)
+	if current != "" && !slices.Contains(states, current) {
+		states = append(states[:len(states):len(states)], current)
+	}
+	for _, state := range states {
+		value := 0.0
+		if state == current {
+			value = 1
+		}
+		stateLabels := append(labels[:len(labels):len(labels)], label{name, state})
+		f.samples = append(f.samples, sample{labels: stateLabels, value: value})
+	}
+}
+
+// write renders the metrics in the Prometheus text format, or in OpenMetrics
+// when openMetrics is set. The Prometheus format has no state sets, so they
+// are written as gauges there.
+func (m *metricSet) write(w io.Writer, openMetrics bool) error {
+	var b strings.Builder
+	for _, f := range m.families {
+		kind, typeName, sampleName := f.kind, f.name, f.name
+		if kind == typeCounter {
+			sampleName += "_total"
+			if !openMetrics {
+				typeName = sampleName
+			}
+		}
+		if kind == typeStateSet && !openMetrics {
+			kind = typeGauge
+		}
+
+		fmt.Fprintf(&b, "# HELP %s %s\n", typeName, f.help)
+		fmt.Fprintf(&b, "# TYPE %s %s\n", typeName, kind)
+		for _, s := range f.samples {
+			b.WriteString(sampleName)
+			writeLabels(&b, s.labels)
+			b.WriteByte(' ')
+			b.WriteString(formatValue(s.value))
+			b.WriteByte('\n')
+		}
+	}
+	if openMetrics {
+		b.WriteString("# EOF\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeLabels(b *strings.Builder, labels []label) {
+	if len(labels) == 0 {
+		return
+	}
+	b.WriteByte('{')
+	for i, l := range labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(b, "%s=\"%s\"", l.name, escapeLabel(l.value))
+	}
+	b.WriteByte('}')
+}
+
+// escapeLabel escapes a label value as both formats require
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
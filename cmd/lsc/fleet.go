@@ -0,0 +1,445 @@
+package lsc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"librescoot/lsc/internal/config"
+	"librescoot/lsc/internal/format"
+	"librescoot/lsc/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	fleetProfiles      []string
+	fleetAll           bool
+	fleetInventory     string
+	fleetParallel      int
+	fleetTargetTimeout time.Duration
+	fleetColumns       []string
+)
+
+// fleetTarget is one scooter and the connection flags used to reach it.
+// Secrets go in Env instead of Flags, out of sight of the process list.
+type fleetTarget struct {
+	Name  string
+	Flags []string
+	Env   []string
+}
+
+// inventorySecrets are the inventory keys passed to the child through its
+// environment rather than as flags
+var inventorySecrets = map[string]bool{"password": true}
+
+// fleetResult is the outcome of running the command against one target
+type fleetResult struct {
+	Target   string
+	Status   string // success or error
+	Code     string // failure class (see output.Code) when Status is error
+	Duration time.Duration
+	Result   interface{}
+	Error    string
+}
+
+var fleetCmd = &cobra.Command{
+	Use:   "fleet [flags] -- <command> [args...]",
+	Short: "Run a command against many scooters in parallel",
+	Long: `Run an lsc command concurrently against a list of profiles or an inventory
+file and aggregate the results into one table or JSON array.
+
+Each target runs as a separate 'lsc --json' invocation, so any command works
+unchanged. Use -- to separate fleet flags from the command and its flags.
+
+Targets are selected with --profiles, --all (every configured profile) or
+--inventory. An inventory file lists one scooter per line: a bare name refers
+to a profile, while key=value pairs (the same keys as 'lsc config set')
+describe the connection directly:
+
+  # name       connection
+  deep-blue
+  bench-1      ssh=root@10.0.0.12
+  bench-2      redis-addr=10.0.0.13:6379 password=secret
+
+Targets do not inherit the LSC_* environment, and key=value lines ignore the
+default profile.
+
+Examples:
+  lsc fleet --all -- status
+  lsc fleet --profiles deep-blue,bench-1 -- settings get alarm.enabled
+  lsc fleet --inventory scooters.txt --target-timeout 10s -- diag version
+  lsc fleet --all --columns battery.0.charge,vehicle.state -- status
+  lsc fleet --all --json -- diag faults`,
+	Args: cobra.MinimumNArgs(1),
+	Annotations: map[string]string{
+		annotationNoRedis: "true",
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if args[0] == "fleet" {
+			return output.InvalidArgument("fleet cannot run itself")
+		}
+
+		targets, err := fleetTargets()
+		if err != nil {
+			return output.InvalidArgument("%w", err)
+		}
+		if len(targets) == 0 {
+			return output.InvalidArgument("no targets: use --profiles, --all or --inventory")
+		}
+
+		results := runFleet(targets, args)
+
+		failed := 0
+		for _, r := range results {
+			if r.Status != "success" {
+				failed++
+			}
+		}
+
+		lscApp.Render(fleetData(results), func() {
+			printFleetTable(results, failed)
+		})
+
+		if failed > 0 {
+			return output.Reported(fmt.Errorf("%d of %d targets failed", failed, len(results)))
+		}
+		return nil
+	},
+}
+
+// fleetTargets collects the targets selected by --profiles, --all and --inventory
+func fleetTargets() ([]fleetTarget, error) {
+	var targets []fleetTarget
+	seen := make(map[string]bool)
+	add := func(t fleetTarget) {
+		if !seen[t.Name] {
+			seen[t.Name] = true
+			targets = append(targets, t)
+		}
+	}
+
+	names := fleetProfiles
+	if fleetAll {
+		cfg, err := config.Load()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, cfg.ProfileNames()...)
+	}
+	for _, name := range names {
+		add(fleetTarget{Name: name, Flags: []string{"--profile", name}})
+	}
+
+	if fleetInventory != "" {
+		inventory, err := readInventory(fleetInventory)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range inventory {
+			add(t)
+		}
+	}
+
+	return targets, nil
+}
+
+// readInventory parses an inventory file (see fleetCmd help for the format)
+func readInventory(path string) ([]fleetTarget, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var targets []fleetTarget
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		target := fleetTarget{Name: fields[0], Flags: []string{"--profile", fields[0]}}
+		if len(fields) > 1 {
+			// The connection is given in full, not on top of the default profile
+			target.Flags = []string{"--profile", config.NoProfile}
+		}
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok {
+				return nil, fmt.Errorf("%s:%d: expected key=value, got '%s'", path, lineNum, field)
+			}
+			if inventorySecrets[key] {
+				target.Env = append(target.Env, inventoryEnv(key)+"="+value)
+				continue
+			}
+			flag, err := inventoryFlag(key)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, lineNum, err)
+			}
+			target.Flags = append(target.Flags, "--"+flag+"="+value)
+		}
+		targets = append(targets, target)
+	}
+
+	return targets, scanner.Err()
+}
+
+// inventoryFlag maps an inventory/profile key to the global flag it sets
+func inventoryFlag(key string) (string, error) {
+	switch key {
+	case "profile", "command-timeout":
+		return key, nil
+	}
+	for _, src := range redisFlagSources {
+		if src.key == key {
+			return src.flag, nil
+		}
+	}
+	return "", fmt.Errorf("unknown inventory key '%s'", key)
+}
+
+// inventoryEnv returns the environment variable of a connection key
+func inventoryEnv(key string) string {
+	for _, src := range redisFlagSources {
+		if src.key == key {
+			return src.env
+		}
+	}
+	return ""
+}
+
+// fleetEnv is the environment of a child: this process's without the LSC_*
+// variables, which would select a profile or connection for every target,
+// plus the target's own
+func fleetEnv(own []string) []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, "LSC_") {
+			env = append(env, kv)
+		}
+	}
+	return append(env, own...)
+}
+
+// runFleet runs args against every target with at most --parallel in flight
+func runFleet(targets []fleetTarget, args []string) []fleetResult {
+	self, err := os.Executable()
+	if err != nil {
+		self = os.Args[0]
+	}
+
+	parallel := fleetParallel
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	results := make([]fleetResult, len(targets))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target fleetTarget) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = runFleetTarget(self, target, args)
+		}(i, target)
+	}
+	wg.Wait()
+
+	return results
+}
+
+func runFleetTarget(self string, target fleetTarget, args []string) fleetResult {
+	ctx, cancel := context.WithTimeout(context.Background(), fleetTargetTimeout)
+	defer cancel()
+
+	cmdArgs := append([]string{}, target.Flags...)
+	cmdArgs = append(cmdArgs, "--json")
+	if commandTimeout > 0 {
+		cmdArgs = append(cmdArgs, "--command-timeout", commandTimeout.String())
+	}
+	if readOnly {
+		cmdArgs = append(cmdArgs, "--read-only")
+	}
+	cmdArgs = append(cmdArgs, args...)
+
+	var stdout, stderr bytes.Buffer
+	child := exec.CommandContext(ctx, self, cmdArgs...)
+	child.Env = fleetEnv(target.Env)
+	child.Stdout = &stdout
+	child.Stderr = &stderr
+
+	start := lscApp.Now()
+	err := child.Run()
+	result := fleetResult{
+		Target:   target.Name,
+		Status:   "success",
+		Duration: lscApp.Now().Sub(start),
+	}
+
+	// Each child prints one result envelope
+	var envelope output.Result
+	if jsonErr := json.Unmarshal(stdout.Bytes(), &envelope); jsonErr == nil && envelope.Status != "" {
+		result.Result = envelope.Data
+		if envelope.Error != nil {
+			result.Status = "error"
+			result.Code = envelope.Error.Code
+			result.Error = envelope.Error.Message
+		}
+	} else if out := strings.TrimSpace(stdout.String()); out != "" {
+		result.Result = out
+	}
+
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		result.Status = "error"
+		result.Code = "timeout"
+		result.Error = fmt.Sprintf("no result within %s", fleetTargetTimeout)
+	case err != nil && result.Status == "success":
+		result.Status = "error"
+		result.Code = "error"
+		result.Error = strings.TrimPrefix(lastLine(stderr.String()), "Error: ")
+		if result.Error == "" {
+			result.Error = err.Error()
+		}
+	}
+
+	return result
+}
+
+// fleetData builds the per-scooter result list
+func fleetData(results []fleetResult) []map[string]interface{} {
+	entries := make([]map[string]interface{}, 0, len(results))
+	for _, r := range results {
+		entry := map[string]interface{}{
+			"scooter":     r.Target,
+			"status":      r.Status,
+			"duration_ms": r.Duration.Milliseconds(),
+			"result":      r.Result,
+		}
+		if r.Error != "" {
+			entry["error"] = map[string]interface{}{
+				"code":    r.Code,
+				"message": r.Error,
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func printFleetTable(results []fleetResult, failed int) {
+	headers := []string{"SCOOTER", "STATUS", "TIME"}
+	if len(fleetColumns) > 0 {
+		for _, column := range fleetColumns {
+			headers = append(headers, strings.ToUpper(column))
+		}
+	} else {
+		headers = append(headers, "RESULT")
+	}
+
+	rows := make([][]string, 0, len(results))
+	for _, r := range results {
+		status := r.Status
+		if r.Code != "" {
+			status = r.Code
+		}
+		row := []string{r.Target, status, fmt.Sprintf("%.1fs", r.Duration.Seconds())}
+
+		if len(fleetColumns) > 0 {
+			for _, column := range fleetColumns {
+				row = append(row, lookupPath(r.Result, column))
+			}
+		} else if r.Error != "" {
+			row = append(row, truncate(r.Error, 60))
+		} else {
+			row = append(row, truncate(summarizeResult(r.Result), 60))
+		}
+		rows = append(rows, row)
+	}
+
+	format.PrintTable(headers, rows)
+	fmt.Println()
+	if failed > 0 {
+		fmt.Println(format.Warning(fmt.Sprintf("%d of %d targets failed", failed, len(results))))
+	} else {
+		fmt.Println(format.Success(fmt.Sprintf("All %d targets succeeded", len(results))))
+	}
+}
+
+// summarizeResult renders the top-level scalar fields of a result as key=value pairs
+func summarizeResult(result interface{}) string {
+	obj, ok := result.(map[string]interface{})
+	if !ok {
+		if result == nil {
+			return ""
+		}
+		return strings.ReplaceAll(fmt.Sprint(result), "\n", " ")
+	}
+
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		switch obj[key].(type) {
+		case map[string]interface{}, []interface{}:
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%v", key, obj[key]))
+	}
+	if len(parts) == 0 {
+		return "ok"
+	}
+	return strings.Join(parts, " ")
+}
+
+// lookupPath resolves a dot-separated path (e.g. battery.0.charge) in a JSON value
+func lookupPath(value interface{}, path string) string {
+	value, ok := output.Lookup(value, path)
+	if !ok || value == nil {
+		return "-"
+	}
+	return fmt.Sprint(value)
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max-1] + "…"
+}
+
+func init() {
+	fleetCmd.Flags().StringSliceVar(&fleetProfiles, "profiles", nil, "Comma-separated list of profiles to target")
+	fleetCmd.Flags().BoolVar(&fleetAll, "all", false, "Target every configured profile")
+	fleetCmd.Flags().StringVar(&fleetInventory, "inventory", "", "Inventory file listing targets")
+	fleetCmd.Flags().IntVarP(&fleetParallel, "parallel", "p", 8, "Maximum number of targets to run concurrently")
+	fleetCmd.Flags().DurationVar(&fleetTargetTimeout, "target-timeout", 30*time.Second, "Timeout per target")
+	fleetCmd.Flags().StringSliceVar(&fleetColumns, "columns", nil, "Result fields to show as table columns (dot paths)")
+	rootCmd.AddCommand(fleetCmd)
+}
@@ -0,0 +1,58 @@
+package lsc
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFleetInventoryTargets(t *testing.T) {
+	a, b, other := newScooter(t), newScooter(t), newScooter(t)
+	b.HSet("vehicle", "state", "stand-by")
+	b.RequireAuth("secret")
+	other.HSet("vehicle", "state", "ready-to-drive")
+
+	// The parent's profile and connection must not reach the targets
+	writeUserConfig(t, fmt.Sprintf(`
+default-profile = "other"
+[profiles.other]
+redis-addr = "%s"
+socket = "/nonexistent/redis.sock"
+`, other.Addr()))
+	t.Setenv("LSC_PROFILE", "other")
+	t.Setenv("LSC_REDIS_ADDR", other.Addr())
+	t.Setenv("LSC_REDIS_DB", "5")
+	t.Setenv("LSCTEST_EXEC", "1")
+
+	path := filepath.Join(t.TempDir(), "inventory")
+	os.WriteFile(path, []byte(fmt.Sprintf("a  redis-addr=%s\nb  redis-addr=%s password=secret\n", a.Addr(), b.Addr())), 0o644)
+
+	targets, err := readInventory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flags := strings.Join(targets[1].Flags, " "); strings.Contains(flags, "secret") {
+		t.Errorf("flags = %s, the password is on the command line", flags)
+	}
+
+	res := mustRun(t, a, "fleet", "--inventory", path, "--json", "--", "status")
+	results := decodeResult(t, res).Data.([]interface{})
+	want := map[string]string{"a": "parked", "b": "stand-by"}
+	if len(results) != len(want) {
+		t.Fatalf("results = %v", results)
+	}
+	for _, r := range results {
+		r := r.(map[string]interface{})
+		name := r["scooter"].(string)
+		if r["status"] != "success" {
+			t.Errorf("%s: %v", name, r["error"])
+			continue
+		}
+		data := r["result"].(map[string]interface{})
+		if got := lookup(t, data, "vehicle.state"); got != want[name] {
+			t.Errorf("%s: vehicle.state = %v, want %s", name, got, want[name])
+		}
+	}
+}
@@ -1,27 +1,21 @@
 package gps
 
 import (
-	"librescoot/lsc/internal/redis"
+	"librescoot/lsc/internal/app"
 
 	"github.com/spf13/cobra"
 )
 
-var RedisClient *redis.Client
-var JSONOutput *bool
-
-// SetRedisClient allows the parent command to inject the Redis client
-func SetRedisClient(client *redis.Client) {
-	RedisClient = client
-}
-
-// SetJSONOutput allows the parent command to inject the JSON output flag
-func SetJSONOutput(jsonOutput *bool) {
-	JSONOutput = jsonOutput
-}
-
-// GpsCmd represents the gps command
-var GpsCmd = &cobra.Command{
-	Use:   "gps",
-	Short: "GPS status and tracking",
-	Long:  `View GPS fix status, position, and accuracy information.`,
+// NewCommand creates the gps command and its subcommands
+func NewCommand(a *app.App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gps",
+		Short: "GPS status and tracking",
+		Long:  `View GPS fix status, position, and accuracy information.`,
+	}
+	cmd.AddCommand(
+		newStatusCmd(a),
+		newWatchCmd(a),
+	)
+	return cmd
 }
@@ -1,171 +1,153 @@
 package gps
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
 	"strconv"
 	"time"
 
+	"librescoot/lsc/internal/app"
 	"librescoot/lsc/internal/format"
+	"librescoot/lsc/internal/model"
 
 	"github.com/spf13/cobra"
 )
 
-var statusCmd = &cobra.Command{
-	Use:   "status",
-	Short: "Show GPS status",
-	Long:  `Display current GPS fix status, position, and accuracy information.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		// Fetch GPS data
-		gpsData, err := RedisClient.HGetAll("gps")
-		if err != nil {
-			if JSONOutput != nil && *JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"error": err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Failed to fetch GPS data: %v\n"), err)
+func newStatusCmd(a *app.App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show GPS status",
+		Long:  `Display current GPS fix status, position, and accuracy information.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Fetch GPS data
+			gpsData, err := a.Redis.HGetAll(model.GPSKey)
+			if err != nil {
+				return fmt.Errorf("failed to fetch GPS data: %w", err)
 			}
-			return
-		}
-
-		if len(gpsData) == 0 {
-			if JSONOutput != nil && *JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"error": "No GPS data available",
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Println(format.Warning("No GPS data available"))
-			}
-			return
-		}
 
-		// If JSON output is requested
-		if JSONOutput != nil && *JSONOutput {
-			parseFloat := func(s string) float64 {
-				v, _ := strconv.ParseFloat(s, 64)
-				return v
+			if len(gpsData) == 0 {
+				return fmt.Errorf("no GPS data available")
 			}
+			gps := model.ParseGPS(gpsData)
 
-			output := map[string]interface{}{
-				"connected": gpsData["connected"] == "1",
-				"active":    gpsData["active"] == "1",
-				"state":     gpsData["state"],
-				"fix_type":  gpsData["fix"],
-			}
+			return a.Render(gpsStatusData(gps), func() {
+				printGPSStatus(gps)
+			})
+		},
+	}
+}
 
-			// Add position if available
-			if gpsData["state"] == "fix-established" || gpsData["state"] == "tracking" {
-				output["position"] = map[string]interface{}{
-					"latitude":  parseFloat(gpsData["latitude"]),
-					"longitude": parseFloat(gpsData["longitude"]),
-					"altitude":  parseFloat(gpsData["altitude"]),
-					"speed":     parseFloat(gpsData["speed"]),
-					"course":    parseFloat(gpsData["course"]),
-				}
-				output["accuracy"] = map[string]interface{}{
-					"eph":     parseFloat(gpsData["eph"]),
-					"quality": parseFloat(gpsData["quality"]),
-					"hdop":    parseFloat(gpsData["hdop"]),
-					"pdop":    parseFloat(gpsData["pdop"]),
-					"vdop":    parseFloat(gpsData["vdop"]),
-				}
-				output["timestamp"] = gpsData["timestamp"]
-				output["updated"] = gpsData["updated"]
-			}
+// gpsStatusData converts the GPS state to its JSON representation
+func gpsStatusData(gps *model.GPS) map[string]interface{} {
+	data := map[string]interface{}{
+		"connected": gps.Connected,
+		"active":    gps.Active,
+		"state":     gps.State,
+		"fix_type":  gps.Fix,
+	}
 
-			jsonBytes, _ := json.MarshalIndent(output, "", "  ")
-			fmt.Println(string(jsonBytes))
-			return
+	// Add position if available
+	if gps.HasFix() {
+		data["position"] = map[string]interface{}{
+			"latitude":  gps.Latitude,
+			"longitude": gps.Longitude,
+			"altitude":  gps.Altitude,
+			"speed":     gps.Speed,
+			"course":    gps.Course,
 		}
+		data["accuracy"] = map[string]interface{}{
+			"eph":     gps.EPH,
+			"quality": gps.Quality,
+			"hdop":    gps.HDOP,
+			"pdop":    gps.PDOP,
+			"vdop":    gps.VDOP,
+		}
+		data["timestamp"] = gps.Timestamp
+		data["updated"] = gps.Updated
+	}
 
-		// Display GPS status
-		format.PrintSection("GPS Status")
+	return data
+}
 
-		// Connection and fix status
-		connected := gpsData["connected"] == "1"
-		active := gpsData["active"] == "1"
-		state := gpsData["state"]
-		fixType := gpsData["fix"]
+// printGPSStatus prints fix status, position, accuracy and time
+func printGPSStatus(gps *model.GPS) {
+	// Display GPS status
+	format.PrintSection("GPS Status")
 
-		if connected {
-			format.PrintKV("Connected", format.Success("Yes"))
-		} else {
-			format.PrintKV("Connected", format.Error("No"))
-		}
+	// Connection and fix status
+	if gps.Connected {
+		format.PrintKV("Connected", format.Success("Yes"))
+	} else {
+		format.PrintKV("Connected", format.Error("No"))
+	}
 
-		if active {
-			format.PrintKV("Active", format.Success("Yes"))
-		} else {
-			format.PrintKV("Active", format.Warning("No"))
-		}
+	if gps.Active {
+		format.PrintKV("Active", format.Success("Yes"))
+	} else {
+		format.PrintKV("Active", format.Warning("No"))
+	}
 
-		format.PrintKV("State", format.ColorizeState(state))
-		format.PrintKV("Fix Type", formatFixType(fixType))
+	format.PrintKV("State", format.ColorizeState(gps.State))
+	format.PrintKV("Fix Type", formatFixType(gps.Fix))
 
-		// Position information
-		if state == "fix-established" || state == "tracking" {
-			format.PrintSubsection("Position")
-			format.PrintKV("Latitude", fmt.Sprintf("%s°", gpsData["latitude"]))
-			format.PrintKV("Longitude", fmt.Sprintf("%s°", gpsData["longitude"]))
-			format.PrintKV("Altitude", fmt.Sprintf("%s m", gpsData["altitude"]))
+	// Position information
+	if gps.HasFix() {
+		format.PrintSubsection("Position")
+		format.PrintKV("Latitude", fmt.Sprintf("%s°", formatFloat(gps.Latitude)))
+		format.PrintKV("Longitude", fmt.Sprintf("%s°", formatFloat(gps.Longitude)))
+		format.PrintKV("Altitude", fmt.Sprintf("%s m", formatFloat(gps.Altitude)))
 
-			if speed, ok := gpsData["speed"]; ok {
-				speedVal, _ := strconv.ParseFloat(speed, 64)
-				format.PrintKV("Speed", fmt.Sprintf("%.1f km/h", speedVal))
-			}
+		if gps.Has("speed") {
+			format.PrintKV("Speed", fmt.Sprintf("%.1f km/h", gps.Speed))
+		}
 
-			if course, ok := gpsData["course"]; ok {
-				courseVal, _ := strconv.ParseFloat(course, 64)
-				format.PrintKV("Course", fmt.Sprintf("%.1f° (%s)", courseVal, degreesToCardinal(courseVal)))
-			}
+		if gps.Has("course") {
+			format.PrintKV("Course", fmt.Sprintf("%.1f° (%s)", gps.Course, degreesToCardinal(gps.Course)))
+		}
 
-			// Accuracy information
-			format.PrintSubsection("Accuracy")
+		// Accuracy information
+		format.PrintSubsection("Accuracy")
 
-			if eph, ok := gpsData["eph"]; ok {
-				ephVal, _ := strconv.ParseFloat(eph, 64)
-				format.PrintKV("Horizontal Error", formatAccuracy(ephVal))
-			}
+		if gps.Has("eph") {
+			format.PrintKV("Horizontal Error", formatAccuracy(gps.EPH))
+		}
+		if gps.Has("quality") {
+			format.PrintKV("Quality", formatQuality(gps.Quality))
+		}
+		if gps.Has("hdop") {
+			format.PrintKV("HDOP", formatFloat(gps.HDOP))
+		}
+		if gps.Has("pdop") {
+			format.PrintKV("PDOP", formatFloat(gps.PDOP))
+		}
+		if gps.Has("vdop") {
+			format.PrintKV("VDOP", formatFloat(gps.VDOP))
+		}
 
-			if quality, ok := gpsData["quality"]; ok {
-				qualityVal, _ := strconv.ParseFloat(quality, 64)
-				format.PrintKV("Quality", formatQuality(qualityVal))
-			}
+		// Timestamp
+		format.PrintSubsection("Time")
+		if gps.Timestamp != "" {
+			format.PrintKV("GPS Time", formatTime(gps.Timestamp))
+		}
+		if gps.Updated != "" {
+			format.PrintKV("Last Update", formatTime(gps.Updated))
+		}
+	}
 
-			if _, ok := gpsData["hdop"]; ok {
-				format.PrintKV("HDOP", gpsData["hdop"])
-			}
-			if _, ok := gpsData["pdop"]; ok {
-				format.PrintKV("PDOP", gpsData["pdop"])
-			}
-			if _, ok := gpsData["vdop"]; ok {
-				format.PrintKV("VDOP", gpsData["vdop"])
-			}
+	fmt.Println()
+}
 
-			// Timestamp
-			format.PrintSubsection("Time")
-			if timestamp, ok := gpsData["timestamp"]; ok {
-				if t, err := time.Parse(time.RFC3339, timestamp); err == nil {
-					format.PrintKV("GPS Time", t.Format("2006-01-02 15:04:05 MST"))
-				} else {
-					format.PrintKV("GPS Time", timestamp)
-				}
-			}
-			if updated, ok := gpsData["updated"]; ok {
-				if t, err := time.Parse(time.RFC3339, updated); err == nil {
-					format.PrintKV("Last Update", t.Format("2006-01-02 15:04:05 MST"))
-				} else {
-					format.PrintKV("Last Update", updated)
-				}
-			}
-		}
+// formatFloat formats a value with as many decimals as it needs
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
 
-		fmt.Println()
-	},
+// formatTime formats an RFC 3339 time for display, or returns it as is if it
+// cannot be parsed
+func formatTime(value string) string {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.Format("2006-01-02 15:04:05 MST")
+	}
+	return value
 }
 
 func formatFixType(fixType string) string {
@@ -220,7 +202,3 @@ func degreesToCardinal(degrees float64) string {
 	}
 	return directions[index]
 }
-
-func init() {
-	GpsCmd.AddCommand(statusCmd)
-}
@@ -6,228 +6,178 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
-	"strconv"
 	"syscall"
 	"time"
 
+	"librescoot/lsc/internal/app"
 	"librescoot/lsc/internal/format"
+	"librescoot/lsc/internal/model"
 
 	"github.com/spf13/cobra"
 )
 
-var (
-	watchCompact bool
-)
-
-var watchCmd = &cobra.Command{
-	Use:   "watch",
-	Short: "Watch GPS updates in real-time",
-	Long:  `Poll GPS updates and display changes in real-time.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
-
-		// Handle Ctrl+C
-		sigChan := make(chan os.Signal, 1)
-		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-		go func() {
-			<-sigChan
-			if JSONOutput == nil || !*JSONOutput {
-				fmt.Println(format.Dim("\nStopping GPS watch..."))
+func newWatchCmd(a *app.App) *cobra.Command {
+	var watchCompact bool
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Watch GPS updates in real-time",
+		Long:  `Poll GPS updates and display changes in real-time.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+
+			// Handle Ctrl+C
+			sigChan := make(chan os.Signal, 1)
+			signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+			go func() {
+				<-sigChan
+				if !a.Structured() {
+					fmt.Println(format.Dim("\nStopping GPS watch..."))
+				}
+				cancel()
+			}()
+
+			if !a.Structured() {
+				fmt.Println(format.Success("Watching GPS updates... (Ctrl+C to stop)"))
+				fmt.Println()
 			}
-			cancel()
-		}()
-
-		if JSONOutput == nil || !*JSONOutput {
-			fmt.Println(format.Success("Watching GPS updates... (Ctrl+C to stop)"))
-			fmt.Println()
-		}
-
-		// Print initial status
-		printGPSUpdate(ctx)
-
-		// Poll for updates every second
-		ticker := time.NewTicker(1 * time.Second)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-ticker.C:
-				// Poll GPS hash and display
-				printGPSUpdate(ctx)
+
+			// Print initial status
+			printGPSUpdate(ctx, a, watchCompact)
+
+			// Poll for updates every second
+			ticker := time.NewTicker(1 * time.Second)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-ticker.C:
+					// Poll GPS hash and display
+					printGPSUpdate(ctx, a, watchCompact)
+				}
 			}
-		}
-	},
+		},
+	}
+	cmd.Flags().BoolVar(&watchCompact, "compact", false, "Use compact one-line format")
+	return cmd
 }
 
-func printGPSUpdate(ctx context.Context) {
-	gpsData, err := RedisClient.HGetAllWithContext(ctx, "gps")
+func printGPSUpdate(ctx context.Context, a *app.App, compact bool) {
+	gpsData, err := a.Redis.HGetAllWithContext(ctx, model.GPSKey)
 	if err != nil {
 		return
 	}
+	gps := model.ParseGPS(gpsData)
 
-	if JSONOutput != nil && *JSONOutput {
-		printJSONUpdate(gpsData)
-	} else if watchCompact {
-		printCompactUpdate(gpsData)
+	if a.Structured() {
+		printJSONUpdate(gps, a.Now())
+	} else if compact {
+		printCompactUpdate(gps)
 	} else {
-		printFullUpdate(gpsData)
+		printFullUpdate(gps, a.Now())
 	}
 }
 
-func printJSONUpdate(gpsData map[string]string) {
-	parseFloat := func(s string) float64 {
-		v, _ := strconv.ParseFloat(s, 64)
-		return v
+func printJSONUpdate(gps *model.GPS, now time.Time) {
+	update := map[string]interface{}{
+		"timestamp": now.Unix(),
+		"connected": gps.Connected,
+		"active":    gps.Active,
+		"state":     gps.State,
+		"fix_type":  gps.Fix,
+		"latitude":  gps.Latitude,
+		"longitude": gps.Longitude,
+		"altitude":  gps.Altitude,
+		"speed":     gps.Speed,
+		"course":    gps.Course,
+		"eph":       gps.EPH,
+		"quality":   gps.Quality,
+		"hdop":      gps.HDOP,
+		"pdop":      gps.PDOP,
+		"vdop":      gps.VDOP,
+		"gps_time":  gps.Timestamp,
+		"updated":   gps.Updated,
 	}
 
-	output := map[string]interface{}{
-		"timestamp":  time.Now().Unix(),
-		"connected":  gpsData["connected"] == "1",
-		"active":     gpsData["active"] == "1",
-		"state":      gpsData["state"],
-		"fix_type":   gpsData["fix"],
-		"latitude":   parseFloat(gpsData["latitude"]),
-		"longitude":  parseFloat(gpsData["longitude"]),
-		"altitude":   parseFloat(gpsData["altitude"]),
-		"speed":      parseFloat(gpsData["speed"]),
-		"course":     parseFloat(gpsData["course"]),
-		"eph":        parseFloat(gpsData["eph"]),
-		"quality":    parseFloat(gpsData["quality"]),
-		"hdop":       parseFloat(gpsData["hdop"]),
-		"pdop":       parseFloat(gpsData["pdop"]),
-		"vdop":       parseFloat(gpsData["vdop"]),
-		"gps_time":   gpsData["timestamp"],
-		"updated":    gpsData["updated"],
-	}
-
-	jsonBytes, _ := json.Marshal(output)
+	jsonBytes, _ := json.Marshal(update)
 	fmt.Println(string(jsonBytes))
 }
 
-func printCompactUpdate(gpsData map[string]string) {
+func printCompactUpdate(gps *model.GPS) {
 	// One-line format: timestamp | lat,lon | alt | speed | course | accuracy
 	timestamp := "N/A"
-	if ts, ok := gpsData["updated"]; ok {
-		if t, err := time.Parse(time.RFC3339, ts); err == nil {
-			timestamp = t.Format("15:04:05")
-		}
+	if t, err := time.Parse(time.RFC3339, gps.Updated); err == nil {
+		timestamp = t.Format("15:04:05")
 	}
 
-	lat := gpsData["latitude"]
-	lon := gpsData["longitude"]
-
 	altitude := "N/A"
-	if alt, ok := gpsData["altitude"]; ok {
-		if altVal, err := strconv.ParseFloat(alt, 64); err == nil {
-			altitude = fmt.Sprintf("%.0fm", altVal)
-		}
-	}
-
-	speed := "0.0"
-	if s, ok := gpsData["speed"]; ok {
-		if speedVal, err := strconv.ParseFloat(s, 64); err == nil {
-			speed = fmt.Sprintf("%.1f", speedVal)
-		}
+	if gps.Has("altitude") {
+		altitude = fmt.Sprintf("%.0fm", gps.Altitude)
 	}
 
 	course := "---"
-	if c, ok := gpsData["course"]; ok {
-		if courseVal, err := strconv.ParseFloat(c, 64); err == nil {
-			course = fmt.Sprintf("%.0f° %s", courseVal, degreesToCardinal(courseVal))
-		}
+	if gps.Has("course") {
+		course = fmt.Sprintf("%.0f° %s", gps.Course, degreesToCardinal(gps.Course))
 	}
 
 	accuracy := "N/A"
-	if eph, ok := gpsData["eph"]; ok {
-		if ephVal, err := strconv.ParseFloat(eph, 64); err == nil {
-			accuracy = formatAccuracy(ephVal)
-		}
+	if gps.Has("eph") {
+		accuracy = formatAccuracy(gps.EPH)
 	}
 
-	fmt.Printf("%s | %s,%s | %s | %s km/h | %s | %s\n",
+	fmt.Printf("%s | %s,%s | %s | %.1f km/h | %s | %s\n",
 		format.Dim(timestamp),
-		lat, lon,
+		formatFloat(gps.Latitude), formatFloat(gps.Longitude),
 		altitude,
-		speed,
+		gps.Speed,
 		course,
 		accuracy,
 	)
 }
 
-func printFullUpdate(gpsData map[string]string) {
-	timestamp := time.Now().Format("15:04:05")
-
-	state := gpsData["state"]
-	fixType := gpsData["fix"]
-	lat := gpsData["latitude"]
-	lon := gpsData["longitude"]
-
-	speed := "0.0"
-	if s, ok := gpsData["speed"]; ok {
-		if speedVal, err := strconv.ParseFloat(s, 64); err == nil {
-			speed = fmt.Sprintf("%.1f", speedVal)
-		}
-	}
+func printFullUpdate(gps *model.GPS, now time.Time) {
+	timestamp := now.Format("15:04:05")
 
 	course := "---"
-	if c, ok := gpsData["course"]; ok {
-		if courseVal, err := strconv.ParseFloat(c, 64); err == nil {
-			course = fmt.Sprintf("%.1f° (%s)", courseVal, degreesToCardinal(courseVal))
-		}
+	if gps.Has("course") {
+		course = fmt.Sprintf("%.1f° (%s)", gps.Course, degreesToCardinal(gps.Course))
 	}
 
 	altitude := "N/A"
-	if alt, ok := gpsData["altitude"]; ok {
-		if altVal, err := strconv.ParseFloat(alt, 64); err == nil {
-			altitude = fmt.Sprintf("%.1f m", altVal)
-		}
+	if gps.Has("altitude") {
+		altitude = fmt.Sprintf("%.1f m", gps.Altitude)
 	}
 
 	accuracy := "N/A"
-	if eph, ok := gpsData["eph"]; ok {
-		if ephVal, err := strconv.ParseFloat(eph, 64); err == nil {
-			accuracy = formatAccuracy(ephVal)
-		}
+	if gps.Has("eph") {
+		accuracy = formatAccuracy(gps.EPH)
 	}
 
-	quality := gpsData["quality"]
-	hdop := gpsData["hdop"]
-	pdop := gpsData["pdop"]
-	vdop := gpsData["vdop"]
-
 	gpsTime := "N/A"
-	if ts, ok := gpsData["timestamp"]; ok && ts != "" {
-		if t, err := time.Parse(time.RFC3339, ts); err == nil {
-			gpsTime = t.Format("15:04:05")
-		}
+	if t, err := time.Parse(time.RFC3339, gps.Timestamp); err == nil {
+		gpsTime = t.Format("15:04:05")
 	}
 
 	// Show state if no fix or in error state
 	statePrefix := ""
-	if fixType == "" || fixType == "none" || fixType == "unknown" || state == "error" || state == "no-fix" {
-		statePrefix = format.ColorizeState(state) + " "
+	if gps.Fix == "" || gps.Fix == "none" || gps.Fix == "unknown" || gps.State == "error" || gps.State == "no-fix" {
+		statePrefix = format.ColorizeState(gps.State) + " "
 	}
 
 	// Single line with all info
-	fmt.Printf("[%s] %s%s | %s,%s | ▲ %s | %s km/h | %s | Acc: %s | Q: %s | DOP: %s/%s/%s | T: %s\n",
+	fmt.Printf("[%s] %s%s | %s,%s | ▲ %s | %.1f km/h | %s | Acc: %s | Q: %s | DOP: %s/%s/%s | T: %s\n",
 		format.Dim(timestamp),
 		statePrefix,
-		formatFixType(fixType),
-		lat, lon,
+		formatFixType(gps.Fix),
+		formatFloat(gps.Latitude), formatFloat(gps.Longitude),
 		altitude,
-		speed,
+		gps.Speed,
 		course,
 		accuracy,
-		quality,
-		hdop, pdop, vdop,
+		formatFloat(gps.Quality),
+		formatFloat(gps.HDOP), formatFloat(gps.PDOP), formatFloat(gps.VDOP),
 		format.Dim(gpsTime),
 	)
 }
-
-func init() {
-	watchCmd.Flags().BoolVar(&watchCompact, "compact", false, "Use compact one-line format")
-	GpsCmd.AddCommand(watchCmd)
-}
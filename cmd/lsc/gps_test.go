@@ -0,0 +1,28 @@
+package lsc
+
+import "testing"
+
+func TestGPSStatus(t *testing.T) {
+	srv := newScooter(t)
+
+	res := mustRun(t, srv, "gps", "status")
+	assertContains(t, res.stdout,
+		"State:               fix-established",
+		"Fix Type:            3D Fix",
+		"Latitude:            52.520008°",
+		"Course:              90.0° (E)",
+	)
+
+	data := jsonData(t, srv, "gps", "status")
+	for path, want := range map[string]interface{}{
+		"connected":          true,
+		"fix_type":           "3d",
+		"position.longitude": 13.404954,
+		"position.altitude":  34.5,
+		"accuracy.hdop":      0.9,
+	} {
+		if got := lookup(t, data, path); got != want {
+			t.Errorf("%s = %v, want %v", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package lsc
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"librescoot/lsc/internal/format"
+	"librescoot/lsc/internal/output"
+	"librescoot/lsc/internal/redis"
+	"librescoot/lsc/internal/sim"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// TestMain keeps the user's configuration, LSC_* environment and terminal
+// colors out of the tests
+func TestMain(m *testing.M) {
+	// fleet runs this binary as lsc for each target
+	if os.Getenv("LSCTEST_EXEC") != "" {
+		format.DisableColors()
+		Execute()
+	}
+
+	dir, err := os.MkdirTemp("", "lsc-test-")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("HOME", dir)
+	os.Setenv("XDG_CONFIG_HOME", dir)
+	for _, env := range os.Environ() {
+		if name, _, _ := strings.Cut(env, "="); strings.HasPrefix(name, "LSC_") {
+			os.Unsetenv(name)
+		}
+	}
+	format.DisableColors()
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// scooterState is the Redis content of a parked scooter with one battery
+var scooterState = map[string]map[string]string{
+	"vehicle": {
+		"state":                 "parked",
+		"kickstand":             "down",
+		"brake:left":            "off",
+		"brake:right":           "on",
+		"blinker:switch":        "off",
+		"blinker:state":         "off",
+		"seatbox:lock":          "closed",
+		"handlebar:lock-sensor": "unlocked",
+	},
+	"engine-ecu": {
+		"speed":         "0",
+		"rpm":           "0",
+		"odometer":      "1234500",
+		"motor:voltage": "54000",
+		"motor:current": "1500",
+		"temperature":   "31",
+		"throttle":      "false",
+		"kers":          "true",
+		"fw-version":    "0445400C",
+	},
+	"battery:0": {
+		"present":           "true",
+		"state":             "active",
+		"charge":            "87",
+		"voltage":           "53200",
+		"current":           "-1200",
+		"temperature:0":     "21",
+		"temperature:1":     "22",
+		"temperature:2":     "22",
+		"temperature:3":     "23",
+		"temperature-state": "ideal",
+		"cycle-count":       "42",
+		"state-of-health":   "98",
+		"fw-version":        "A1.2.3",
+		"serial-number":     "BAT0001",
+	},
+	"battery:1": {
+		"present": "false",
+	},
+	"alarm":         {"status": "disarmed"},
+	"power-manager": {"state": "running", "wakeup-source": "rtc"},
+	"gps": {
+		"state":     "fix-established",
+		"latitude":  "52.520008",
+		"longitude": "13.404954",
+		"altitude":  "34.5",
+		"speed":     "0",
+		"course":    "90",
+		"fix":       "3d",
+		"quality":   "1",
+		"hdop":      "0.9",
+		"active":    "1",
+		"connected": "1",
+	},
+	"system": {
+		"mdb-version":    "v1.4.0",
+		"dbc-version":    "v1.4.1",
+		"nrf-fw-version": "2.1.0",
+		"environment":    "production",
+	},
+	"ota": {
+		"system":                "mdb",
+		"status":                "downloading",
+		"fresh-update":          "false",
+		"status:mdb":            "downloading",
+		"update-version:mdb":    "v1.5.0",
+		"download-progress:mdb": "42",
+	},
+	"settings": {
+		"alarm.enabled":                            "false",
+		"alarm.honk":                               "true",
+		"scooter.speed_limit":                      "25",
+		"dashboard.saved-locations.1.latitude":     "52.516275",
+		"dashboard.saved-locations.1.longitude":    "13.377704",
+		"dashboard.saved-locations.1.label":        "Brandenburg Gate",
+		"dashboard.saved-locations.1.created-at":   "2025-01-02T10:00:00Z",
+		"dashboard.saved-locations.1.last-used-at": "2025-03-04T18:30:00Z",
+	},
+}
+
+// newScooter starts an in-process Redis holding scooterState, an active
+// vehicle fault and two fault events
+func newScooter(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+	srv := miniredis.RunT(t)
+	for key, fields := range scooterState {
+		for field, value := range fields {
+			srv.HSet(key, field, value)
+		}
+	}
+	srv.SAdd("vehicle:fault", "12")
+	srv.XAdd("events:faults", "1700000000000-0", []string{"group", "ecu", "code", "12", "description", "Motor controller overcurrent"})
+	srv.XAdd("events:faults", "1700000060000-0", []string{"group", "battery:0", "code", "32", "description", "Battery temperature high"})
+	return srv
+}
+
+// startSim runs the simulated services against srv until the test ends
+func startSim(t *testing.T, srv *miniredis.Miniredis) {
+	t.Helper()
+	client := redis.NewClient(srv.Addr())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sim.New(client, sim.Options{Delay: 20 * time.Millisecond}).Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		client.Close()
+	})
+
+	// The simulator resets the state to stand-by once it is running
+	deadline := time.Now().Add(5 * time.Second)
+	for srv.HGet("system", "environment") != sim.Environment {
+		if time.Now().After(deadline) {
+			t.Fatal("simulator did not start")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// result is the outcome of one lsc invocation
+type result struct {
+	stdout string
+	stderr string
+	err    error
+}
+
+// runLSC runs lsc with args against srv like the binary does, starting from
+// default flags
+func runLSC(t *testing.T, srv *miniredis.Miniredis, args ...string) result {
+	t.Helper()
+	return runAt(t, srv.Addr(), args...)
+}
+
+// runAt runs lsc with args against the Redis at addr
+func runAt(t *testing.T, addr string, args ...string) result {
+	t.Helper()
+	resetFlags(rootCmd)
+	setContext(rootCmd, context.Background())
+	commandStarted = false
+	args = append([]string{"--redis-addr", addr}, args...)
+
+	var res result
+	res.stdout, res.stderr = captureOutput(t, func() {
+		rootCmd.SetArgs(args)
+		cmd, err := rootCmd.ExecuteC()
+		if err != nil {
+			closeConnections()
+			err = reportError(cmd, args, err)
+		}
+		res.err = err
+	})
+	return res
+}
+
+// mustRun is runLSC for invocations that have to succeed
+func mustRun(t *testing.T, srv *miniredis.Miniredis, args ...string) result {
+	t.Helper()
+	res := runLSC(t, srv, args...)
+	if res.err != nil {
+		t.Fatalf("lsc %s: %v\nstdout:\n%s\nstderr:\n%s", strings.Join(args, " "), res.err, res.stdout, res.stderr)
+	}
+	return res
+}
+
+// captureOutput runs fn with stdout and stderr redirected to files
+func captureOutput(t *testing.T, fn func()) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	stdout, err := os.Create(dir + "/stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdout.Close()
+	stderr, err := os.Create(dir + "/stderr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stderr.Close()
+
+	prevStdout, prevStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = stdout, stderr
+	defer func() {
+		os.Stdout, os.Stderr = prevStdout, prevStderr
+	}()
+	fn()
+
+	read := func(f *os.File) string {
+		f.Seek(0, io.SeekStart)
+		data, err := io.ReadAll(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	return read(stdout), read(stderr)
+}
+
+// decodeResult parses the --json envelope printed by a command
+func decodeResult(t *testing.T, res result) output.Result {
+	t.Helper()
+	var envelope output.Result
+	if err := json.Unmarshal([]byte(res.stdout), &envelope); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, res.stdout)
+	}
+	return envelope
+}
+
+// jsonData runs a command with --json and returns its data object
+func jsonData(t *testing.T, srv *miniredis.Miniredis, args ...string) map[string]interface{} {
+	t.Helper()
+	envelope := decodeResult(t, mustRun(t, srv, append(args, "--json")...))
+	if envelope.Status != "success" {
+		t.Fatalf("lsc %s: status %q", strings.Join(args, " "), envelope.Status)
+	}
+	data, ok := envelope.Data.(map[string]interface{})
+	if !ok {
+		t.Fatalf("lsc %s: data is %T, not an object", strings.Join(args, " "), envelope.Data)
+	}
+	return data
+}
+
+// lookup returns the value at a dotted path of a JSON object
+func lookup(t *testing.T, data map[string]interface{}, path string) interface{} {
+	t.Helper()
+	value, ok := output.Lookup(data, path)
+	if !ok {
+		t.Fatalf("no %s in %v", path, data)
+	}
+	return value
+}
+
+// assertContains fails unless s contains every one of wants
+func assertContains(t *testing.T, s string, wants ...string) {
+	t.Helper()
+	for _, want := range wants {
+		if !strings.Contains(s, want) {
+			t.Errorf("output does not contain %q:\n%s", want, s)
+		}
+	}
+}
+
+// assertCode fails unless err has the failure class named code, e.g. "timeout"
+func assertCode(t *testing.T, err error, code string) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("expected a %s error, got success", code)
+	}
+	if got := output.Code(err); got != code {
+		t.Fatalf("error %q is %s, want %s", err, got, code)
+	}
+}
+
+// subscribe listens on a channel of srv and returns a function that waits for
+// the next message and returns its payload
+func subscribe(t *testing.T, srv *miniredis.Miniredis, channel string) func() string {
+	t.Helper()
+	client := redis.NewClient(srv.Addr())
+	pubsub := client.Subscribe(context.Background(), channel)
+	t.Cleanup(func() {
+		pubsub.Close()
+		client.Close()
+	})
+	if _, err := pubsub.Receive(context.Background()); err != nil {
+		t.Fatalf("subscribing to %s: %v", channel, err)
+	}
+	messages := pubsub.Channel()
+
+	return func() string {
+		t.Helper()
+		select {
+		case msg := <-messages:
+			return msg.Payload
+		case <-time.After(2 * time.Second):
+			t.Fatalf("nothing published on %s", channel)
+			return ""
+		}
+	}
+}
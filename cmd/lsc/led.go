@@ -1,13 +1,13 @@
 package lsc
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
 	"strconv"
 	"strings"
 
+	"librescoot/lsc/internal/confirm"
 	"librescoot/lsc/internal/format"
+	"librescoot/lsc/internal/output"
 
 	"github.com/spf13/cobra"
 )
@@ -31,20 +31,20 @@ var cueAliases = map[string]int{
 
 // LED channel name to index mapping
 var channelAliases = map[string]int{
-	"headlight":         0,
-	"front-ring":        1,
-	"brake":             2,
-	"brake-light":       2,
+	"headlight":           0,
+	"front-ring":          1,
+	"brake":               2,
+	"brake-light":         2,
 	"blinker-front-left":  3,
 	"blinker-left-front":  3,
 	"blinker-front-right": 4,
 	"blinker-right-front": 4,
-	"number-plates":     5,
-	"plates":            5,
-	"blinker-rear-left": 6,
-	"blinker-left-rear": 6,
-	"blinker-rear-right": 7,
-	"blinker-right-rear": 7,
+	"number-plates":       5,
+	"plates":              5,
+	"blinker-rear-left":   6,
+	"blinker-left-rear":   6,
+	"blinker-rear-right":  7,
+	"blinker-right-rear":  7,
 }
 
 // LED fade name to index mapping
@@ -73,7 +73,7 @@ func parseCueIndex(s string) (int, error) {
 	if index, ok := cueAliases[s]; ok {
 		return index, nil
 	}
-	return 0, fmt.Errorf("invalid cue '%s'", s)
+	return 0, output.InvalidArgument("invalid cue '%s'", s)
 }
 
 // parseChannelIndex parses channel index from string (numeric or alias)
@@ -87,7 +87,7 @@ func parseChannelIndex(s string) (int, error) {
 	if index, ok := channelAliases[s]; ok {
 		return index, nil
 	}
-	return 0, fmt.Errorf("invalid channel '%s'", s)
+	return 0, output.InvalidArgument("invalid channel '%s'", s)
 }
 
 // parseFadeIndex parses fade index from string (numeric or alias)
@@ -101,7 +101,7 @@ func parseFadeIndex(s string) (int, error) {
 	if index, ok := fadeAliases[s]; ok {
 		return index, nil
 	}
-	return 0, fmt.Errorf("invalid fade '%s'", s)
+	return 0, output.InvalidArgument("invalid fade '%s'", s)
 }
 
 var ledCmd = &cobra.Command{
@@ -148,48 +148,23 @@ Examples:
   lsc led cue 10              # Activate left blinker
   lsc led cue blink-left      # Same using alias
   lsc led cue blink_both      # Hazard lights (underscores work too)`,
-	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
 		indexStr := args[0]
 		index, err := parseCueIndex(indexStr)
 		if err != nil {
-			if JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "led-cue",
-					"status":  "error",
-					"error":   err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("%v\n"), err)
-			}
-			return
+			return err
 		}
 
-		if err := redisClient.LPush("scooter:led:cue", strconv.Itoa(index)); err != nil {
-			if JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "led-cue",
-					"status":  "error",
-					"error":   err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Failed to send LED cue command: %v\n"), err)
-			}
-			return
+		if err := lscApp.Send(cmd.Context(), confirm.Command{List: "scooter:led:cue", Payload: strconv.Itoa(index)}); err != nil {
+			return fmt.Errorf("failed to send LED cue command: %w", err)
 		}
 
-		if JSONOutput {
-			output, _ := json.Marshal(map[string]interface{}{
-				"command": "led-cue",
-				"status":  "success",
-				"index":   index,
-			})
-			fmt.Println(string(output))
-		} else {
+		return lscApp.Render(map[string]interface{}{
+			"index": index,
+		}, func() {
 			fmt.Printf("%s LED cue %d triggered\n", format.Success("✓"), index)
-		}
+		})
 	},
 }
 
@@ -229,67 +204,32 @@ Examples:
   lsc led fade 2 2                          # Fade on brake light
   lsc led fade brake brake-linear-on        # Same using aliases
   lsc led fade front-ring smooth-off        # Smooth off front ring`,
-	Args:  cobra.ExactArgs(2),
-	Run: func(cmd *cobra.Command, args []string) {
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
 		channelStr := args[0]
 		indexStr := args[1]
 
 		channel, err := parseChannelIndex(channelStr)
 		if err != nil {
-			if JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "led-fade",
-					"status":  "error",
-					"error":   err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("%v\n"), err)
-			}
-			return
+			return err
 		}
 
 		index, err := parseFadeIndex(indexStr)
 		if err != nil {
-			if JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "led-fade",
-					"status":  "error",
-					"error":   err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("%v\n"), err)
-			}
-			return
+			return err
 		}
 
 		command := fmt.Sprintf("%d:%d", channel, index)
-		if err := redisClient.LPush("scooter:led:fade", command); err != nil {
-			if JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "led-fade",
-					"status":  "error",
-					"error":   err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Failed to send LED fade command: %v\n"), err)
-			}
-			return
+		if err := lscApp.Send(cmd.Context(), confirm.Command{List: "scooter:led:fade", Payload: command}); err != nil {
+			return fmt.Errorf("failed to send LED fade command: %w", err)
 		}
 
-		if JSONOutput {
-			output, _ := json.Marshal(map[string]interface{}{
-				"command": "led-fade",
-				"status":  "success",
-				"channel": channel,
-				"index":   index,
-			})
-			fmt.Println(string(output))
-		} else {
+		return lscApp.Render(map[string]interface{}{
+			"channel": channel,
+			"index":   index,
+		}, func() {
 			fmt.Printf("%s LED fade animation %d triggered on channel %d\n", format.Success("✓"), index, channel)
-		}
+		})
 	},
 }
 
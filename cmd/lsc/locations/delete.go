@@ -1,87 +1,47 @@
 package locations
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
-	"strconv"
 
+	"librescoot/lsc/internal/app"
 	"librescoot/lsc/internal/format"
 
 	"github.com/spf13/cobra"
 )
 
-var deleteCmd = &cobra.Command{
-	Use:     "delete <id>",
-	Aliases: []string{"rm", "remove"},
-	Short:   "Delete a saved location",
-	Long:    `Delete a saved location by ID.`,
-	Args:    cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		// Parse ID
-		id, err := strconv.Atoi(args[0])
-		if err != nil {
-			if JSONOutput != nil && *JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "locations-delete",
-					"status":  "error",
-					"error":   fmt.Sprintf("invalid id: %s", args[0]),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Invalid ID '%s': must be an integer\n"), args[0])
+func newDeleteCmd(a *app.App) *cobra.Command {
+	return &cobra.Command{
+		Use:     "delete <id>",
+		Aliases: []string{"rm", "remove"},
+		Short:   "Delete a saved location",
+		Long:    `Delete a saved location by ID.`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
 			}
-			return
-		}
 
-		// Check if location exists
-		location, err := loadLocation(id)
-		if err != nil {
-			if JSONOutput != nil && *JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "locations-delete",
-					"status":  "error",
-					"error":   fmt.Sprintf("location not found: %d", id),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Location with ID %d not found\n"), id)
+			// Check if location exists
+			location, err := findLocation(a.Redis, id)
+			if err != nil {
+				return err
 			}
-			return
-		}
 
-		// Delete from Redis
-		if err := deleteLocation(id); err != nil {
-			if JSONOutput != nil && *JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "locations-delete",
-					"status":  "error",
-					"error":   err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Failed to delete location: %v\n"), err)
+			// Delete from Redis
+			if err := deleteLocation(a.Redis, id); err != nil {
+				return fmt.Errorf("failed to delete location: %w", err)
 			}
-			return
-		}
 
-		if JSONOutput != nil && *JSONOutput {
-			output, _ := json.Marshal(map[string]interface{}{
-				"command": "locations-delete",
-				"status":  "success",
-				"id":      id,
+			return a.Render(map[string]interface{}{
+				"id": id,
+			}, func() {
+				fmt.Printf("%s Deleted location %s (%s)\n",
+					format.Success("✓"),
+					format.Info(fmt.Sprintf("%d", id)),
+					location.Label,
+				)
 			})
-			fmt.Println(string(output))
-		} else {
-			fmt.Printf("%s Deleted location %s (%s)\n",
-				format.Success("✓"),
-				format.Info(fmt.Sprintf("%d", id)),
-				location.Label,
-			)
-		}
-	},
-}
-
-func init() {
-	LocationsCmd.AddCommand(deleteCmd)
+		},
+	}
 }
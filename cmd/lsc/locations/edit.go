@@ -1,21 +1,21 @@
 package locations
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
 	"strconv"
-	"time"
 
+	"librescoot/lsc/internal/app"
 	"librescoot/lsc/internal/format"
+	"librescoot/lsc/internal/output"
 
 	"github.com/spf13/cobra"
 )
 
-var editCmd = &cobra.Command{
-	Use:   "edit <id> <field> <value> [<field> <value> ...]",
-	Short: "Edit a saved location",
-	Long: `Edit one or more fields of a saved location.
+func newEditCmd(a *app.App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "edit <id> <field> <value> [<field> <value> ...]",
+		Short: "Edit a saved location",
+		Long: `Edit one or more fields of a saved location.
 
 Valid fields: label, lat, lon
 
@@ -23,166 +23,72 @@ Examples:
   lsc loc edit 0 label "New Home"
   lsc loc edit 0 lat 52.5 lon 13.4
   lsc loc edit 0 label "Office" lat 52.5235 lon 13.4115`,
-	Args: cobra.MinimumNArgs(3),
-	Run: func(cmd *cobra.Command, args []string) {
-		// Parse ID
-		id, err := strconv.Atoi(args[0])
-		if err != nil {
-			if JSONOutput != nil && *JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "locations-edit",
-					"status":  "error",
-					"error":   fmt.Sprintf("invalid id: %s", args[0]),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Invalid ID '%s': must be an integer\n"), args[0])
+		Args: cobra.MinimumNArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
 			}
-			return
-		}
 
-		// Load existing location
-		location, err := loadLocation(id)
-		if err != nil {
-			if JSONOutput != nil && *JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "locations-edit",
-					"status":  "error",
-					"error":   fmt.Sprintf("location not found: %d", id),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Location with ID %d not found\n"), id)
+			// Load existing location
+			location, err := findLocation(a.Redis, id)
+			if err != nil {
+				return err
 			}
-			return
-		}
 
-		// Parse field-value pairs
-		updates, err := parseFieldValuePairs(args[1:])
-		if err != nil {
-			if JSONOutput != nil && *JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "locations-edit",
-					"status":  "error",
-					"error":   err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("%v\n"), err)
+			// Parse field-value pairs
+			updates, err := parseFieldValuePairs(args[1:])
+			if err != nil {
+				return err
 			}
-			return
-		}
 
-		// Apply updates
-		modified := false
-		for field, value := range updates {
-			switch field {
-			case "label":
-				location.Label = value
-				modified = true
-			case "latitude":
-				lat, err := strconv.ParseFloat(value, 64)
-				if err != nil {
-					if JSONOutput != nil && *JSONOutput {
-						output, _ := json.Marshal(map[string]interface{}{
-							"command": "locations-edit",
-							"status":  "error",
-							"error":   fmt.Sprintf("invalid latitude: %s", value),
-						})
-						fmt.Println(string(output))
-					} else {
-						fmt.Fprintf(os.Stderr, format.Error("Invalid latitude '%s': must be a number\n"), value)
+			// Apply updates
+			modified := false
+			for field, value := range updates {
+				switch field {
+				case "label":
+					location.Label = value
+					modified = true
+				case "latitude":
+					lat, err := strconv.ParseFloat(value, 64)
+					if err != nil {
+						return output.InvalidArgument("invalid latitude '%s': must be a number", value)
 					}
-					return
-				}
-				location.Latitude = lat
-				modified = true
-			case "longitude":
-				lon, err := strconv.ParseFloat(value, 64)
-				if err != nil {
-					if JSONOutput != nil && *JSONOutput {
-						output, _ := json.Marshal(map[string]interface{}{
-							"command": "locations-edit",
-							"status":  "error",
-							"error":   fmt.Sprintf("invalid longitude: %s", value),
-						})
-						fmt.Println(string(output))
-					} else {
-						fmt.Fprintf(os.Stderr, format.Error("Invalid longitude '%s': must be a number\n"), value)
+					location.Latitude = lat
+					modified = true
+				case "longitude":
+					lon, err := strconv.ParseFloat(value, 64)
+					if err != nil {
+						return output.InvalidArgument("invalid longitude '%s': must be a number", value)
 					}
-					return
+					location.Longitude = lon
+					modified = true
 				}
-				location.Longitude = lon
-				modified = true
 			}
-		}
 
-		if !modified {
-			if JSONOutput != nil && *JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "locations-edit",
-					"status":  "error",
-					"error":   "no valid fields to update",
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("No valid fields to update\n"))
+			if !modified {
+				return output.InvalidArgument("no valid fields to update")
 			}
-			return
-		}
 
-		// Validate coordinates if changed
-		if err := validateCoordinates(location.Latitude, location.Longitude); err != nil {
-			if JSONOutput != nil && *JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "locations-edit",
-					"status":  "error",
-					"error":   err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("%v\n"), err)
+			// Validate coordinates if changed
+			if err := validateCoordinates(location.Latitude, location.Longitude); err != nil {
+				return err
 			}
-			return
-		}
 
-		// Update last-used-at timestamp
-		location.LastUsedAt = time.Now()
+			// Update last-used-at timestamp
+			location.LastUsedAt = a.Now()
 
-		// Save to Redis
-		if err := saveLocation(*location); err != nil {
-			if JSONOutput != nil && *JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "locations-edit",
-					"status":  "error",
-					"error":   err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Failed to update location: %v\n"), err)
+			// Save to Redis
+			if err := saveLocation(a.Redis, *location); err != nil {
+				return fmt.Errorf("failed to update location: %w", err)
 			}
-			return
-		}
 
-		if JSONOutput != nil && *JSONOutput {
-			output, _ := json.Marshal(map[string]interface{}{
-				"command":   "locations-edit",
-				"status":    "success",
-				"id":        id,
-				"latitude":  location.Latitude,
-				"longitude": location.Longitude,
-				"label":     location.Label,
+			return a.Render(locationData(*location), func() {
+				fmt.Printf("%s Location %s updated\n",
+					format.Success("✓"),
+					format.Info(fmt.Sprintf("%d", id)),
+				)
 			})
-			fmt.Println(string(output))
-		} else {
-			fmt.Printf("%s Location %s updated\n",
-				format.Success("✓"),
-				format.Info(fmt.Sprintf("%d", id)),
-			)
-		}
-	},
-}
-
-func init() {
-	LocationsCmd.AddCommand(editCmd)
+		},
+	}
 }
@@ -9,16 +9,13 @@ import (
 	"strings"
 	"time"
 
+	"librescoot/lsc/internal/app"
+	"librescoot/lsc/internal/output"
 	"librescoot/lsc/internal/redis"
 
 	"github.com/spf13/cobra"
 )
 
-var (
-	RedisClient *redis.Client
-	JSONOutput  *bool
-)
-
 const (
 	locationsKeyPrefix = "dashboard.saved-locations"
 )
@@ -33,31 +30,62 @@ type SavedLocation struct {
 	LastUsedAt time.Time
 }
 
-var LocationsCmd = &cobra.Command{
-	Use:     "locations",
-	Aliases: []string{"loc"},
-	Short:   "Manage saved locations",
-	Long:    `Manage saved locations for navigation.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		// Default to list when called without subcommand
-		listCmd.Run(cmd, args)
-	},
+// NewCommand creates the locations command and its subcommands. Without a
+// subcommand it lists the saved locations.
+func NewCommand(a *app.App) *cobra.Command {
+	listCmd := newListCmd(a)
+	cmd := &cobra.Command{
+		Use:     "locations",
+		Aliases: []string{"loc"},
+		Short:   "Manage saved locations",
+		Long:    `Manage saved locations for navigation.`,
+		RunE:    listCmd.RunE,
+	}
+	cmd.AddCommand(
+		newAddCmd(a),
+		newDeleteCmd(a),
+		newEditCmd(a),
+		listCmd,
+		newShowCmd(a),
+		newTouchCmd(a),
+	)
+	return cmd
+}
+
+// parseID parses a location ID argument
+func parseID(arg string) (int, error) {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, output.InvalidArgument("invalid ID '%s': must be an integer", arg)
+	}
+	return id, nil
 }
 
-// SetRedisClient sets the Redis client for all locations commands
-func SetRedisClient(client *redis.Client) {
-	RedisClient = client
+// findLocation loads a location by ID, reporting a missing location as an error
+func findLocation(client *redis.Client, id int) (*SavedLocation, error) {
+	location, err := loadLocation(client, id)
+	if err != nil {
+		return nil, fmt.Errorf("location with ID %d not found", id)
+	}
+	return location, nil
 }
 
-// SetJSONOutput sets the JSON output flag reference for all locations commands
-func SetJSONOutput(jsonOutput *bool) {
-	JSONOutput = jsonOutput
+// locationData converts a location to its JSON representation
+func locationData(loc SavedLocation) map[string]interface{} {
+	return map[string]interface{}{
+		"id":           loc.ID,
+		"latitude":     loc.Latitude,
+		"longitude":    loc.Longitude,
+		"label":        loc.Label,
+		"created_at":   loc.CreatedAt.Format(time.RFC3339),
+		"last_used_at": loc.LastUsedAt.Format(time.RFC3339),
+	}
 }
 
 // loadAllLocations discovers and loads all saved locations from Redis
-func loadAllLocations() ([]SavedLocation, error) {
+func loadAllLocations(client *redis.Client) ([]SavedLocation, error) {
 	// Get all fields from settings hash
-	settings, err := RedisClient.HGetAll("settings")
+	settings, err := client.HGetAll("settings")
 	if err != nil {
 		return nil, err
 	}
@@ -77,7 +105,7 @@ func loadAllLocations() ([]SavedLocation, error) {
 	// Load each location
 	locations := []SavedLocation{}
 	for id := range idMap {
-		loc, err := loadLocation(id)
+		loc, err := loadLocation(client, id)
 		if err == nil && loc != nil {
 			locations = append(locations, *loc)
 		}
@@ -92,13 +120,13 @@ func loadAllLocations() ([]SavedLocation, error) {
 }
 
 // loadLocation loads a single location by ID
-func loadLocation(id int) (*SavedLocation, error) {
+func loadLocation(client *redis.Client, id int) (*SavedLocation, error) {
 	fields := []string{"latitude", "longitude", "label", "created-at", "last-used-at"}
 	data := make(map[string]string)
 
 	for _, field := range fields {
 		key := fmt.Sprintf("%s.%d.%s", locationsKeyPrefix, id, field)
-		value, err := RedisClient.HGet("settings", key)
+		value, err := client.HGet("settings", key)
 		if err != nil {
 			// Field doesn't exist, skip this location
 			return nil, err
@@ -137,7 +165,7 @@ func loadLocation(id int) (*SavedLocation, error) {
 }
 
 // saveLocation saves or updates a location
-func saveLocation(loc SavedLocation) error {
+func saveLocation(client *redis.Client, loc SavedLocation) error {
 	fields := map[string]string{
 		"latitude":     fmt.Sprintf("%.6f", loc.Latitude),
 		"longitude":    fmt.Sprintf("%.6f", loc.Longitude),
@@ -148,37 +176,34 @@ func saveLocation(loc SavedLocation) error {
 
 	for field, value := range fields {
 		key := fmt.Sprintf("%s.%d.%s", locationsKeyPrefix, loc.ID, field)
-		if err := RedisClient.HSet("settings", key, value); err != nil {
+		if err := client.HSet("settings", key, value); err != nil {
 			return err
 		}
 	}
 
 	// Publish notification
-	client := RedisClient.GetClient()
-	ctx := context.Background()
-	return client.Publish(ctx, "settings", fmt.Sprintf("%s.%d", locationsKeyPrefix, loc.ID)).Err()
+	return client.Publish(context.Background(), "settings", fmt.Sprintf("%s.%d", locationsKeyPrefix, loc.ID))
 }
 
 // deleteLocation deletes a location by ID
-func deleteLocation(id int) error {
+func deleteLocation(client *redis.Client, id int) error {
 	fields := []string{"latitude", "longitude", "label", "created-at", "last-used-at"}
-	client := RedisClient.GetClient()
 	ctx := context.Background()
 
 	for _, field := range fields {
 		key := fmt.Sprintf("%s.%d.%s", locationsKeyPrefix, id, field)
-		if err := client.HDel(ctx, "settings", key).Err(); err != nil {
+		if err := client.HDelWithContext(ctx, "settings", key); err != nil {
 			return err
 		}
 	}
 
 	// Publish notification
-	return client.Publish(ctx, "settings", fmt.Sprintf("%s.%d", locationsKeyPrefix, id)).Err()
+	return client.Publish(ctx, "settings", fmt.Sprintf("%s.%d", locationsKeyPrefix, id))
 }
 
 // findNextAvailableID finds the next available ID slot
-func findNextAvailableID() (int, error) {
-	locations, err := loadAllLocations()
+func findNextAvailableID(client *redis.Client) (int, error) {
+	locations, err := loadAllLocations(client)
 	if err != nil {
 		return 0, err
 	}
@@ -198,12 +223,12 @@ func findNextAvailableID() (int, error) {
 }
 
 // formatRelativeTime formats a time as relative to now
-func formatRelativeTime(t time.Time) string {
+func formatRelativeTime(t, now time.Time) string {
 	if t.IsZero() {
 		return "never"
 	}
 
-	duration := time.Since(t)
+	duration := now.Sub(t)
 	if duration < time.Minute {
 		return "just now"
 	} else if duration < time.Hour {
@@ -232,10 +257,10 @@ func formatRelativeTime(t time.Time) string {
 // validateCoordinates validates latitude and longitude
 func validateCoordinates(lat, lon float64) error {
 	if lat < -90 || lat > 90 {
-		return fmt.Errorf("latitude must be between -90 and 90")
+		return output.InvalidArgument("latitude must be between -90 and 90")
 	}
 	if lon < -180 || lon > 180 {
-		return fmt.Errorf("longitude must be between -180 and 180")
+		return output.InvalidArgument("longitude must be between -180 and 180")
 	}
 	return nil
 }
@@ -243,7 +268,7 @@ func validateCoordinates(lat, lon float64) error {
 // parseFieldValuePairs parses field-value pairs from arguments
 func parseFieldValuePairs(args []string) (map[string]string, error) {
 	if len(args)%2 != 0 {
-		return nil, fmt.Errorf("fields and values must be provided in pairs")
+		return nil, output.InvalidArgument("fields and values must be provided in pairs")
 	}
 
 	updates := make(map[string]string)
@@ -260,12 +285,9 @@ func parseFieldValuePairs(args []string) (map[string]string, error) {
 		case "lon", "lng", "longitude":
 			updates["longitude"] = value
 		default:
-			return nil, fmt.Errorf("invalid field: %s (valid: label, lat, lon)", field)
+			return nil, output.InvalidArgument("invalid field: %s (valid: label, lat, lon)", field)
 		}
 	}
 
 	return updates, nil
 }
-
-func init() {
-}
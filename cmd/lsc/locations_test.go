@@ -0,0 +1,36 @@
+package lsc
+
+import "testing"
+
+func TestLocationsList(t *testing.T) {
+	srv := newScooter(t)
+
+	res := mustRun(t, srv, "locations", "list")
+	assertContains(t, res.stdout,
+		"[1] Brandenburg Gate (52.516275, 13.377704)",
+		"Created: 2025-01-02",
+	)
+
+	envelope := decodeResult(t, mustRun(t, srv, "locations", "list", "--json"))
+	locations, ok := envelope.Data.([]interface{})
+	if !ok || len(locations) != 1 {
+		t.Fatalf("locations = %v", envelope.Data)
+	}
+	location := locations[0].(map[string]interface{})
+	if location["id"] != 1.0 || location["label"] != "Brandenburg Gate" || location["latitude"] != 52.516275 {
+		t.Errorf("location = %v", location)
+	}
+}
+
+func TestLocationsListEmpty(t *testing.T) {
+	srv := newScooter(t)
+	srv.Del("settings")
+
+	res := mustRun(t, srv, "locations", "list")
+	assertContains(t, res.stdout, "No saved locations")
+
+	envelope := decodeResult(t, mustRun(t, srv, "locations", "list", "--json"))
+	if locations, ok := envelope.Data.([]interface{}); !ok || len(locations) != 0 {
+		t.Errorf("locations = %v, want an empty list", envelope.Data)
+	}
+}
@@ -7,45 +7,44 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
 
+	"librescoot/lsc/internal/app"
 	"librescoot/lsc/internal/format"
+	"librescoot/lsc/internal/output"
 	"librescoot/lsc/internal/redis"
+	"librescoot/lsc/internal/runner"
 
 	"github.com/spf13/cobra"
 )
 
-var (
-	RedisClient *redis.Client
-	JSONOutput  *bool
-
-	// Flags
-	logsSince    string
-	logsUntil    string
-	logsOutput   string
-	logsPriority string
-)
+// extractOptions are the flags of the logs command
+type extractOptions struct {
+	since    string
+	until    string
+	output   string
+	priority string
+}
 
 // Service name mappings
 var serviceMap = map[string]string{
-	"vehicle":   "librescoot-vehicle.service",
-	"battery":   "librescoot-battery.service",
-	"ecu":       "librescoot-ecu.service",
-	"motor":     "librescoot-ecu.service", // alias
-	"modem":     "librescoot-modem.service",
-	"pm":        "librescoot-pm.service",
-	"power":     "librescoot-pm.service", // alias
-	"update":    "librescoot-update.service",
-	"settings":  "librescoot-settings.service",
-	"keycard":   "librescoot-keycard.service",
-	"bluetooth": "librescoot-bluetooth.service",
-	"ble":       "librescoot-bluetooth.service", // alias
-	"ums":       "librescoot-ums.service",
+	"vehicle":    "librescoot-vehicle.service",
+	"battery":    "librescoot-battery.service",
+	"ecu":        "librescoot-ecu.service",
+	"motor":      "librescoot-ecu.service", // alias
+	"modem":      "librescoot-modem.service",
+	"pm":         "librescoot-pm.service",
+	"power":      "librescoot-pm.service", // alias
+	"update":     "librescoot-update.service",
+	"settings":   "librescoot-settings.service",
+	"keycard":    "librescoot-keycard.service",
+	"bluetooth":  "librescoot-bluetooth.service",
+	"ble":        "librescoot-bluetooth.service", // alias
+	"ums":        "librescoot-ums.service",
 	"radio-gaga": "radio-gaga.service",
-	"uplink":    "radio-gaga.service", // alias
+	"uplink":     "radio-gaga.service", // alias
 }
 
 // Redis keys to snapshot
@@ -57,10 +56,13 @@ var redisKeys = []string{
 	"power-mux", "version:mdb", "version:dbc",
 }
 
-var LogsCmd = &cobra.Command{
-	Use:   "logs [services...]",
-	Short: "Extract service logs and system state",
-	Long: `Extract systemd service logs and Redis snapshots for debugging and analysis.
+// NewCommand creates the logs command
+func NewCommand(a *app.App) *cobra.Command {
+	var opts extractOptions
+	cmd := &cobra.Command{
+		Use:   "logs [services...]",
+		Short: "Extract service logs and system state",
+		Long: `Extract systemd service logs and Redis snapshots for debugging and analysis.
 
 Available services:
   vehicle, battery, ecu/motor, modem, pm/power, update, settings,
@@ -78,24 +80,22 @@ Examples:
   lsc logs all --since 1h --output /data/debug-session
   lsc logs battery ecu --since "2025-10-25 10:00" --until "2025-10-25 12:00"
   lsc logs all --since 1d --priority err`,
-	Run:  runLogsExtract,
-}
-
-// SetRedisClient sets the Redis client for logs commands
-func SetRedisClient(client *redis.Client) {
-	RedisClient = client
-}
-
-// SetJSONOutput sets the JSON output flag reference for logs commands
-func SetJSONOutput(jsonOutput *bool) {
-	JSONOutput = jsonOutput
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runLogsExtract(a, opts, args)
+		},
+	}
+	cmd.Flags().StringVar(&opts.since, "since", "24h", "Start time for logs (journalctl format)")
+	cmd.Flags().StringVar(&opts.until, "until", "", "End time for logs (default: now)")
+	cmd.Flags().StringVar(&opts.output, "output", "", "Output directory (default: auto-generate)")
+	cmd.Flags().StringVar(&opts.priority, "priority", "", "Log level filter (err, warning, info, debug)")
+	return cmd
 }
 
-func runLogsExtract(cmd *cobra.Command, args []string) {
+func runLogsExtract(a *app.App, opts extractOptions, args []string) error {
 	// Determine output directory
-	outputDir := logsOutput
+	outputDir := opts.output
 	if outputDir == "" {
-		outputDir = fmt.Sprintf("/data/logs-%s", time.Now().Format("2006-01-02-15-04"))
+		outputDir = fmt.Sprintf("/data/logs-%s", a.Now().Format("2006-01-02-15-04"))
 	}
 
 	// Default to "all" if no services specified
@@ -130,47 +130,44 @@ func runLogsExtract(cmd *cobra.Command, args []string) {
 	}
 
 	if len(services) == 0 {
-		fmt.Fprintf(os.Stderr, format.Error("No valid services specified\n"))
-		return
+		return output.InvalidArgument("no valid services specified")
 	}
 
 	// Create output directory structure
 	if err := os.MkdirAll(filepath.Join(outputDir, "logs"), 0755); err != nil {
-		fmt.Fprintf(os.Stderr, format.Error("Failed to create output directory: %v\n"), err)
-		return
+		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 	if err := os.MkdirAll(filepath.Join(outputDir, "snapshots"), 0755); err != nil {
-		fmt.Fprintf(os.Stderr, format.Error("Failed to create snapshots directory: %v\n"), err)
-		return
+		return fmt.Errorf("failed to create snapshots directory: %w", err)
 	}
 
 	metadata := map[string]interface{}{
-		"timestamp": time.Now().Format(time.RFC3339),
+		"timestamp": a.Now().Format(time.RFC3339),
 		"services":  services,
-		"since":     logsSince,
-		"until":     logsUntil,
-		"priority":  logsPriority,
+		"since":     opts.since,
+		"until":     opts.until,
+		"priority":  opts.priority,
 	}
 
-	if !*JSONOutput {
+	if !a.Structured() {
 		fmt.Printf("%s Extracting logs to %s\n", format.Info("→"), outputDir)
 	}
 
 	// Extract service logs
 	for _, svc := range services {
-		if err := extractServiceLogs(svc, outputDir); err != nil {
+		if err := extractServiceLogs(a.Runner, svc, outputDir, opts); err != nil {
 			fmt.Fprintf(os.Stderr, format.Warning("Failed to extract %s: %v\n"), svc, err)
-		} else if !*JSONOutput {
+		} else if !a.Structured() {
 			fmt.Printf("  %s %s\n", format.Success("✓"), svc)
 		}
 	}
 
 	// Capture Redis snapshots
-	if !*JSONOutput {
+	if !a.Structured() {
 		fmt.Printf("%s Capturing Redis snapshots\n", format.Info("→"))
 	}
-	capturedCount := captureRedisSnapshots(outputDir)
-	if !*JSONOutput {
+	capturedCount := captureRedisSnapshots(a.Redis, outputDir)
+	if !a.Structured() {
 		fmt.Printf("  %s %d keys captured\n", format.Success("✓"), capturedCount)
 	}
 	metadata["redis_snapshots"] = capturedCount
@@ -182,55 +179,50 @@ func runLogsExtract(cmd *cobra.Command, args []string) {
 	}
 
 	// Create tarball
-	if !*JSONOutput {
+	if !a.Structured() {
 		fmt.Printf("%s Creating compressed archive\n", format.Info("→"))
 	}
 	tarballPath := outputDir + ".tar.gz"
 	if err := createTarball(outputDir, tarballPath); err != nil {
 		fmt.Fprintf(os.Stderr, format.Warning("Failed to create tarball: %v\n"), err)
-	} else if !*JSONOutput {
+	} else if !a.Structured() {
 		fmt.Printf("  %s %s\n", format.Success("✓"), filepath.Base(tarballPath))
 	}
 
-	if *JSONOutput {
-		output, _ := json.Marshal(map[string]interface{}{
-			"command":        "logs-extract",
-			"status":         "success",
-			"output_dir":     outputDir,
-			"tarball":        tarballPath,
-			"services_count": len(services),
-			"redis_snapshots": capturedCount,
-		})
-		fmt.Println(string(output))
-	} else {
+	return a.Render(map[string]interface{}{
+		"output_dir":      outputDir,
+		"tarball":         tarballPath,
+		"services_count":  len(services),
+		"redis_snapshots": capturedCount,
+	}, func() {
 		fmt.Printf("\n%s Logs extracted successfully\n", format.Success("✓"))
 		fmt.Printf("  Directory: %s\n", outputDir)
 		fmt.Printf("  Archive:   %s\n", tarballPath)
-	}
+	})
 }
 
-func extractServiceLogs(service, outputDir string) error {
+func extractServiceLogs(r runner.Runner, service, outputDir string, opts extractOptions) error {
 	args := []string{"-u", service, "--no-pager"}
 
-	if logsSince != "" {
-		args = append(args, "--since", convertDurationToJournalctl(logsSince))
+	if opts.since != "" {
+		args = append(args, "--since", convertDurationToJournalctl(opts.since))
 	}
-	if logsUntil != "" {
-		args = append(args, "--until", convertDurationToJournalctl(logsUntil))
+	if opts.until != "" {
+		args = append(args, "--until", convertDurationToJournalctl(opts.until))
 	}
-	if logsPriority != "" {
-		args = append(args, "--priority", logsPriority)
+	if opts.priority != "" {
+		args = append(args, "--priority", opts.priority)
 	}
 
-	cmd := exec.Command("journalctl", args...)
-	output, err := cmd.Output()
+	cmd := runner.Command(r, "journalctl", args...)
+	out, err := cmd.Output()
 	if err != nil {
 		return err
 	}
 
 	// Save to file
 	logFile := filepath.Join(outputDir, "logs", service+".log")
-	return os.WriteFile(logFile, output, 0644)
+	return os.WriteFile(logFile, out, 0644)
 }
 
 // convertDurationToJournalctl converts duration strings like "1h", "24h", "1d"
@@ -287,11 +279,11 @@ func convertDurationToJournalctl(duration string) string {
 	return duration
 }
 
-func captureRedisSnapshots(outputDir string) int {
+func captureRedisSnapshots(client *redis.Client, outputDir string) int {
 	count := 0
 
 	for _, key := range redisKeys {
-		data, err := RedisClient.HGetAll(key)
+		data, err := client.HGetAll(key)
 		if err != nil || len(data) == 0 {
 			continue
 		}
@@ -371,10 +363,3 @@ func createTarball(sourceDir, tarballPath string) error {
 		return nil
 	})
 }
-
-func init() {
-	LogsCmd.Flags().StringVar(&logsSince, "since", "24h", "Start time for logs (journalctl format)")
-	LogsCmd.Flags().StringVar(&logsUntil, "until", "", "End time for logs (default: now)")
-	LogsCmd.Flags().StringVar(&logsOutput, "output", "", "Output directory (default: auto-generate)")
-	LogsCmd.Flags().StringVar(&logsPriority, "priority", "", "Log level filter (err, warning, info, debug)")
-}
@@ -11,22 +11,20 @@ import (
 	"syscall"
 	"time"
 
+	"librescoot/lsc/internal/app"
 	"librescoot/lsc/internal/format"
-	"librescoot/lsc/internal/redis"
+	"librescoot/lsc/internal/output"
 
 	"github.com/spf13/cobra"
 )
 
-var (
-	RedisClient *redis.Client
-	JSONOutput  *bool
-
-	// Flags
-	monitorDuration string
-	monitorInterval string
-	monitorOutput   string
-	monitorFormat   string
-)
+// monitorOptions are the flags of the monitor command
+type monitorOptions struct {
+	duration string
+	interval string
+	output   string
+	format   string
+}
 
 // Subsystem names
 var subsystems = []string{
@@ -35,18 +33,21 @@ var subsystems = []string{
 
 // Session metadata
 type SessionMetadata struct {
-	StartTime   time.Time `json:"start_time"`
-	EndTime     time.Time `json:"end_time"`
-	Duration    string    `json:"duration"`
-	Interval    string    `json:"interval"`
-	Subsystems  []string  `json:"subsystems"`
+	StartTime   time.Time      `json:"start_time"`
+	EndTime     time.Time      `json:"end_time"`
+	Duration    string         `json:"duration"`
+	Interval    string         `json:"interval"`
+	Subsystems  []string       `json:"subsystems"`
 	RecordCount map[string]int `json:"record_count"`
 }
 
-var MonitorCmd = &cobra.Command{
-	Use:       "monitor <subsystems...>",
-	Short:     "Record real-time metrics over time",
-	Long: `Record scooter metrics to timestamped files for analysis.
+// NewCommand creates the monitor command
+func NewCommand(a *app.App) *cobra.Command {
+	var opts monitorOptions
+	cmd := &cobra.Command{
+		Use:   "monitor <subsystems...>",
+		Short: "Record real-time metrics over time",
+		Long: `Record scooter metrics to timestamped files for analysis.
 
 Available subsystems:
   gps      - GPS coordinates and speed
@@ -69,59 +70,53 @@ Examples:
   lsc monitor battery vehicle --duration 10m --interval 5s
   lsc monitor all --duration 30m --output /data/debug-session
   lsc monitor gps battery --format csv --duration 5m`,
-	Args:      cobra.MinimumNArgs(1),
-	ValidArgs: subsystems,
-	Run:       runMonitor,
-}
-
-// SetRedisClient sets the Redis client for monitor commands
-func SetRedisClient(client *redis.Client) {
-	RedisClient = client
-}
-
-// SetJSONOutput sets the JSON output flag reference for monitor commands
-func SetJSONOutput(jsonOutput *bool) {
-	JSONOutput = jsonOutput
+		Args:      cobra.MinimumNArgs(1),
+		ValidArgs: subsystems,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runMonitor(a, opts, args)
+		},
+	}
+	cmd.Flags().StringVar(&opts.duration, "duration", "1h", "Recording duration (1m, 5m, 1h, 24h)")
+	cmd.Flags().StringVar(&opts.interval, "interval", "1s", "Polling interval (100ms, 1s, 5s)")
+	cmd.Flags().StringVar(&opts.output, "output", "", "Output directory (default: auto-generate)")
+	cmd.Flags().StringVar(&opts.format, "format", "jsonl", "Output format (jsonl, csv)")
+	return cmd
 }
 
-func runMonitor(cmd *cobra.Command, args []string) {
+func runMonitor(a *app.App, opts monitorOptions, args []string) error {
 	// Parse duration
-	duration, err := parseDuration(monitorDuration)
+	duration, err := parseDuration(opts.duration)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, format.Error("Invalid duration '%s': %v\n"), monitorDuration, err)
-		return
+		return output.InvalidArgument("invalid duration '%s': %w", opts.duration, err)
 	}
 
 	// Parse interval
-	interval, err := parseDuration(monitorInterval)
+	interval, err := parseDuration(opts.interval)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, format.Error("Invalid interval '%s': %v\n"), monitorInterval, err)
-		return
+		return output.InvalidArgument("invalid interval '%s': %w", opts.interval, err)
 	}
 
 	// Determine output directory
-	outputDir := monitorOutput
+	outputDir := opts.output
 	if outputDir == "" {
-		outputDir = fmt.Sprintf("/data/monitor-%s", time.Now().Format("2006-01-02-15-04"))
+		outputDir = fmt.Sprintf("/data/monitor-%s", a.Now().Format("2006-01-02-15-04"))
 	}
 
 	// Determine which subsystems to monitor
 	selectedSubsystems := expandSubsystems(args)
 	if len(selectedSubsystems) == 0 {
-		fmt.Fprintf(os.Stderr, format.Error("No valid subsystems specified\n"))
-		return
+		return output.InvalidArgument("no valid subsystems specified")
 	}
 
 	// Create output directory
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		fmt.Fprintf(os.Stderr, format.Error("Failed to create output directory: %v\n"), err)
-		return
+		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	if !*JSONOutput {
+	if !a.Structured() {
 		fmt.Printf("%s Recording metrics to %s\n", format.Info("→"), outputDir)
-		fmt.Printf("  Duration: %s\n", monitorDuration)
-		fmt.Printf("  Interval: %s\n", monitorInterval)
+		fmt.Printf("  Duration: %s\n", opts.duration)
+		fmt.Printf("  Interval: %s\n", opts.interval)
 		fmt.Printf("  Subsystems: %v\n", selectedSubsystems)
 		fmt.Println()
 	}
@@ -135,19 +130,26 @@ func runMonitor(cmd *cobra.Command, args []string) {
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 	go func() {
 		<-sigChan
-		if !*JSONOutput {
+		if !a.Structured() {
 			fmt.Println("\nShutting down gracefully...")
 		}
 		cancel()
 	}()
 
+	rec := &recorder{
+		client:    a.Redis,
+		clock:     a.Clock,
+		outputDir: outputDir,
+		format:    opts.format,
+	}
+
 	// Track record counts
 	recordCounts := make(map[string]*int)
 	var mu sync.Mutex
 
 	// Start recorders
 	var wg sync.WaitGroup
-	startTime := time.Now()
+	startTime := a.Now()
 
 	for _, subsystem := range selectedSubsystems {
 		count := 0
@@ -156,24 +158,24 @@ func runMonitor(cmd *cobra.Command, args []string) {
 		wg.Add(1)
 		switch subsystem {
 		case "gps":
-			go recordGPS(ctx, &wg, outputDir, interval, &count, &mu)
+			go rec.recordGPS(ctx, &wg, interval, &count, &mu)
 		case "battery":
-			go recordBattery(ctx, &wg, outputDir, interval, &count, &mu)
+			go rec.recordBattery(ctx, &wg, interval, &count, &mu)
 		case "vehicle":
-			go recordVehicle(ctx, &wg, outputDir, interval, &count, &mu)
+			go rec.recordVehicle(ctx, &wg, interval, &count, &mu)
 		case "motor":
-			go recordMotor(ctx, &wg, outputDir, interval, &count, &mu)
+			go rec.recordMotor(ctx, &wg, interval, &count, &mu)
 		case "power":
-			go recordPower(ctx, &wg, outputDir, interval, &count, &mu)
+			go rec.recordPower(ctx, &wg, interval, &count, &mu)
 		case "modem":
-			go recordModem(ctx, &wg, outputDir, interval, &count, &mu)
+			go rec.recordModem(ctx, &wg, interval, &count, &mu)
 		case "events":
-			go recordEvents(ctx, &wg, outputDir, &count, &mu)
+			go rec.recordEvents(ctx, &wg, &count, &mu)
 		}
 	}
 
 	// Progress updates
-	if !*JSONOutput {
+	if !a.Structured() {
 		go func() {
 			ticker := time.NewTicker(5 * time.Second)
 			defer ticker.Stop()
@@ -184,7 +186,7 @@ func runMonitor(cmd *cobra.Command, args []string) {
 					return
 				case <-ticker.C:
 					mu.Lock()
-					elapsed := time.Since(startTime)
+					elapsed := a.Now().Sub(startTime)
 					total := 0
 					for _, count := range recordCounts {
 						total += *count
@@ -198,9 +200,9 @@ func runMonitor(cmd *cobra.Command, args []string) {
 
 	// Wait for all recorders to finish
 	wg.Wait()
-	endTime := time.Now()
+	endTime := a.Now()
 
-	if !*JSONOutput {
+	if !a.Structured() {
 		fmt.Println() // Clear progress line
 	}
 
@@ -208,8 +210,8 @@ func runMonitor(cmd *cobra.Command, args []string) {
 	metadata := SessionMetadata{
 		StartTime:   startTime,
 		EndTime:     endTime,
-		Duration:    monitorDuration,
-		Interval:    monitorInterval,
+		Duration:    opts.duration,
+		Interval:    opts.interval,
 		Subsystems:  selectedSubsystems,
 		RecordCount: make(map[string]int),
 	}
@@ -224,7 +226,7 @@ func runMonitor(cmd *cobra.Command, args []string) {
 	}
 
 	// Create tarball
-	if !*JSONOutput {
+	if !a.Structured() {
 		fmt.Printf("%s Creating compressed archive\n", format.Info("→"))
 	}
 	tarballPath := outputDir + ".tar.gz"
@@ -233,24 +235,19 @@ func runMonitor(cmd *cobra.Command, args []string) {
 	}
 
 	// Print summary
-	if *JSONOutput {
-		output, _ := json.Marshal(map[string]interface{}{
-			"command":        "monitor",
-			"status":         "success",
-			"output_dir":     outputDir,
-			"tarball":        tarballPath,
-			"duration":       endTime.Sub(startTime).Seconds(),
-			"record_counts":  metadata.RecordCount,
-			"total_records":  sumRecords(metadata.RecordCount),
-		})
-		fmt.Println(string(output))
-	} else {
+	return a.Render(map[string]interface{}{
+		"output_dir":    outputDir,
+		"tarball":       tarballPath,
+		"duration":      endTime.Sub(startTime).Seconds(),
+		"record_counts": metadata.RecordCount,
+		"total_records": sumRecords(metadata.RecordCount),
+	}, func() {
 		fmt.Printf("\n%s Monitoring complete\n", format.Success("✓"))
 		fmt.Printf("  Directory: %s\n", outputDir)
 		fmt.Printf("  Archive:   %s\n", tarballPath)
 		fmt.Printf("  Duration:  %s\n", formatDuration(endTime.Sub(startTime)))
 		fmt.Printf("  Records:   %d\n", sumRecords(metadata.RecordCount))
-	}
+	})
 }
 
 func expandSubsystems(args []string) []string {
@@ -306,10 +303,3 @@ func parseDuration(s string) (time.Duration, error) {
 	// Support formats like: 1m, 5m, 1h, 24h, 100ms
 	return time.ParseDuration(s)
 }
-
-func init() {
-	MonitorCmd.Flags().StringVar(&monitorDuration, "duration", "1h", "Recording duration (1m, 5m, 1h, 24h)")
-	MonitorCmd.Flags().StringVar(&monitorInterval, "interval", "1s", "Polling interval (100ms, 1s, 5s)")
-	MonitorCmd.Flags().StringVar(&monitorOutput, "output", "", "Output directory (default: auto-generate)")
-	MonitorCmd.Flags().StringVar(&monitorFormat, "format", "jsonl", "Output format (jsonl, csv)")
-}
@@ -8,14 +8,23 @@ import (
 	"sync"
 	"time"
 
+	"librescoot/lsc/internal/model"
 	"librescoot/lsc/internal/redis"
 )
 
+// recorder writes the metrics of the subsystems to files in outputDir
+type recorder struct {
+	client    *redis.Client
+	clock     func() time.Time
+	outputDir string
+	format    string
+}
+
 // recordGPS records GPS coordinates and speed
-func recordGPS(ctx context.Context, wg *sync.WaitGroup, outputDir string, interval time.Duration, count *int, mu *sync.Mutex) {
+func (r *recorder) recordGPS(ctx context.Context, wg *sync.WaitGroup, interval time.Duration, count *int, mu *sync.Mutex) {
 	defer wg.Done()
 
-	writer, err := NewMetricWriter(filepath.Join(outputDir, "gps."+monitorFormat), monitorFormat)
+	writer, err := NewMetricWriter(filepath.Join(r.outputDir, "gps."+r.format), r.format)
 	if err != nil {
 		return
 	}
@@ -29,13 +38,13 @@ func recordGPS(ctx context.Context, wg *sync.WaitGroup, outputDir string, interv
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			data, err := RedisClient.HGetAll("gps:filtered")
+			data, err := r.client.HGetAll("gps:filtered")
 			if err != nil || len(data) == 0 {
 				continue
 			}
 
 			record := map[string]interface{}{
-				"timestamp": time.Now().UnixMilli(),
+				"timestamp": r.clock().UnixMilli(),
 			}
 
 			// Add GPS fields
@@ -60,7 +69,7 @@ func recordGPS(ctx context.Context, wg *sync.WaitGroup, outputDir string, interv
 }
 
 // recordBattery records battery metrics for all connected batteries
-func recordBattery(ctx context.Context, wg *sync.WaitGroup, outputDir string, interval time.Duration, count *int, mu *sync.Mutex) {
+func (r *recorder) recordBattery(ctx context.Context, wg *sync.WaitGroup, interval time.Duration, count *int, mu *sync.Mutex) {
 	defer wg.Done()
 
 	// Create writers for each battery (0 and 1)
@@ -80,38 +89,35 @@ func recordBattery(ctx context.Context, wg *sync.WaitGroup, outputDir string, in
 			return
 		case <-ticker.C:
 			// Check both battery:0 and battery:1
-			for id := 0; id <= 1; id++ {
-				key := "battery:" + strconv.Itoa(id)
-				data, err := RedisClient.HGetAll(key)
+			for _, id := range model.BatterySlots {
+				data, err := r.client.HGetAll(model.BatteryKey(id))
 				if err != nil || len(data) == 0 {
 					continue
 				}
 
 				// Create writer on first successful read
 				if writers[id] == nil {
-					filename := "battery-" + strconv.Itoa(id) + "." + monitorFormat
-					w, err := NewMetricWriter(filepath.Join(outputDir, filename), monitorFormat)
+					filename := "battery-" + strconv.Itoa(id) + "." + r.format
+					w, err := NewMetricWriter(filepath.Join(r.outputDir, filename), r.format)
 					if err != nil {
 						continue
 					}
 					writers[id] = w
 				}
 
+				battery := model.ParseBattery(id, data)
 				record := map[string]interface{}{
-					"timestamp":   time.Now().UnixMilli(),
-					"battery_id":  id,
-				}
-
-				// Add battery fields with type conversion
-				for key, val := range data {
-					switch key {
-					case "soc", "voltage", "current", "temperature":
-						if f, err := strconv.ParseFloat(val, 64); err == nil {
-							record[key] = f
-						}
-					default:
-						record[key] = val
-					}
+					"timestamp":         r.clock().UnixMilli(),
+					"battery_id":        id,
+					"present":           battery.Present,
+					"state":             battery.State,
+					"charge_percent":    battery.Charge,
+					"voltage_v":         battery.Voltage,
+					"current_a":         battery.Current,
+					"temperature_c":     battery.Temperatures[0],
+					"temperature_state": battery.TemperatureState,
+					"cycles":            battery.CycleCount,
+					"health_percent":    battery.StateOfHealth,
 				}
 
 				if err := writers[id].WriteJSON(record); err == nil {
@@ -125,10 +131,10 @@ func recordBattery(ctx context.Context, wg *sync.WaitGroup, outputDir string, in
 }
 
 // recordVehicle records vehicle state changes
-func recordVehicle(ctx context.Context, wg *sync.WaitGroup, outputDir string, interval time.Duration, count *int, mu *sync.Mutex) {
+func (r *recorder) recordVehicle(ctx context.Context, wg *sync.WaitGroup, interval time.Duration, count *int, mu *sync.Mutex) {
 	defer wg.Done()
 
-	writer, err := NewMetricWriter(filepath.Join(outputDir, "vehicle."+monitorFormat), monitorFormat)
+	writer, err := NewMetricWriter(filepath.Join(r.outputDir, "vehicle."+r.format), r.format)
 	if err != nil {
 		return
 	}
@@ -142,13 +148,13 @@ func recordVehicle(ctx context.Context, wg *sync.WaitGroup, outputDir string, in
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			data, err := RedisClient.HGetAll("vehicle")
+			data, err := r.client.HGetAll(model.VehicleKey)
 			if err != nil || len(data) == 0 {
 				continue
 			}
 
 			record := map[string]interface{}{
-				"timestamp": time.Now().UnixMilli(),
+				"timestamp": r.clock().UnixMilli(),
 			}
 
 			// Add all vehicle fields
@@ -166,10 +172,10 @@ func recordVehicle(ctx context.Context, wg *sync.WaitGroup, outputDir string, in
 }
 
 // recordMotor records motor/ECU metrics
-func recordMotor(ctx context.Context, wg *sync.WaitGroup, outputDir string, interval time.Duration, count *int, mu *sync.Mutex) {
+func (r *recorder) recordMotor(ctx context.Context, wg *sync.WaitGroup, interval time.Duration, count *int, mu *sync.Mutex) {
 	defer wg.Done()
 
-	writer, err := NewMetricWriter(filepath.Join(outputDir, "motor."+monitorFormat), monitorFormat)
+	writer, err := NewMetricWriter(filepath.Join(r.outputDir, "motor."+r.format), r.format)
 	if err != nil {
 		return
 	}
@@ -183,31 +189,23 @@ func recordMotor(ctx context.Context, wg *sync.WaitGroup, outputDir string, inte
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			data, err := RedisClient.HGetAll("engine-ecu")
+			data, err := r.client.HGetAll(model.EngineECUKey)
 			if err != nil || len(data) == 0 {
 				continue
 			}
 
+			ecu := model.ParseEngineECU(data)
 			record := map[string]interface{}{
-				"timestamp": time.Now().UnixMilli(),
-			}
-
-			// Add motor fields with type conversion
-			for key, val := range data {
-				switch key {
-				case "rpm", "speed", "odometer", "temperature":
-					if f, err := strconv.ParseFloat(val, 64); err == nil {
-						record[key] = f
-					}
-				case "motor:voltage", "motor:current":
-					if f, err := strconv.ParseFloat(val, 64); err == nil {
-						// Clean up key name
-						cleanKey := strings.ReplaceAll(key, ":", "_")
-						record[cleanKey] = f
-					}
-				default:
-					record[key] = val
-				}
+				"timestamp":     r.clock().UnixMilli(),
+				"state":         ecu.State,
+				"speed_kph":     ecu.Speed,
+				"rpm":           ecu.RPM,
+				"odometer_km":   ecu.Odometer,
+				"voltage_v":     ecu.Voltage,
+				"current_a":     ecu.Current,
+				"temperature_c": ecu.Temperature,
+				"throttle":      ecu.Throttle,
+				"kers":          ecu.KERS,
 			}
 
 			if err := writer.WriteJSON(record); err == nil {
@@ -220,10 +218,10 @@ func recordMotor(ctx context.Context, wg *sync.WaitGroup, outputDir string, inte
 }
 
 // recordPower records power manager metrics
-func recordPower(ctx context.Context, wg *sync.WaitGroup, outputDir string, interval time.Duration, count *int, mu *sync.Mutex) {
+func (r *recorder) recordPower(ctx context.Context, wg *sync.WaitGroup, interval time.Duration, count *int, mu *sync.Mutex) {
 	defer wg.Done()
 
-	writer, err := NewMetricWriter(filepath.Join(outputDir, "power."+monitorFormat), monitorFormat)
+	writer, err := NewMetricWriter(filepath.Join(r.outputDir, "power."+r.format), r.format)
 	if err != nil {
 		return
 	}
@@ -237,13 +235,13 @@ func recordPower(ctx context.Context, wg *sync.WaitGroup, outputDir string, inte
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			data, err := RedisClient.HGetAll("power-manager")
+			data, err := r.client.HGetAll(model.PowerManagerKey)
 			if err != nil || len(data) == 0 {
 				continue
 			}
 
 			record := map[string]interface{}{
-				"timestamp": time.Now().UnixMilli(),
+				"timestamp": r.clock().UnixMilli(),
 			}
 
 			// Add power manager fields
@@ -267,10 +265,10 @@ func recordPower(ctx context.Context, wg *sync.WaitGroup, outputDir string, inte
 }
 
 // recordModem records modem and internet connectivity metrics
-func recordModem(ctx context.Context, wg *sync.WaitGroup, outputDir string, interval time.Duration, count *int, mu *sync.Mutex) {
+func (r *recorder) recordModem(ctx context.Context, wg *sync.WaitGroup, interval time.Duration, count *int, mu *sync.Mutex) {
 	defer wg.Done()
 
-	writer, err := NewMetricWriter(filepath.Join(outputDir, "modem."+monitorFormat), monitorFormat)
+	writer, err := NewMetricWriter(filepath.Join(r.outputDir, "modem."+r.format), r.format)
 	if err != nil {
 		return
 	}
@@ -285,13 +283,13 @@ func recordModem(ctx context.Context, wg *sync.WaitGroup, outputDir string, inte
 			return
 		case <-ticker.C:
 			// Get modem data
-			modemData, err := RedisClient.HGetAll("modem")
+			modemData, err := r.client.HGetAll(model.ModemKey)
 			if err != nil {
 				modemData = make(map[string]string)
 			}
 
 			// Get internet data
-			internetData, err := RedisClient.HGetAll("internet")
+			internetData, err := r.client.HGetAll(model.InternetKey)
 			if err != nil {
 				internetData = make(map[string]string)
 			}
@@ -302,7 +300,7 @@ func recordModem(ctx context.Context, wg *sync.WaitGroup, outputDir string, inte
 			}
 
 			record := map[string]interface{}{
-				"timestamp": time.Now().UnixMilli(),
+				"timestamp": r.clock().UnixMilli(),
 			}
 
 			// Add modem fields with prefix
@@ -325,10 +323,10 @@ func recordModem(ctx context.Context, wg *sync.WaitGroup, outputDir string, inte
 }
 
 // recordEvents records fault events from the stream
-func recordEvents(ctx context.Context, wg *sync.WaitGroup, outputDir string, count *int, mu *sync.Mutex) {
+func (r *recorder) recordEvents(ctx context.Context, wg *sync.WaitGroup, count *int, mu *sync.Mutex) {
 	defer wg.Done()
 
-	writer, err := NewMetricWriter(filepath.Join(outputDir, "events."+monitorFormat), monitorFormat)
+	writer, err := NewMetricWriter(filepath.Join(r.outputDir, "events."+r.format), r.format)
 	if err != nil {
 		return
 	}
@@ -344,7 +342,7 @@ func recordEvents(ctx context.Context, wg *sync.WaitGroup, outputDir string, cou
 		}
 
 		// Read with blocking
-		streams, err := RedisClient.XRead(ctx, &redis.XReadArgs{
+		streams, err := r.client.XRead(ctx, &redis.XReadArgs{
 			Streams: []string{"events:faults", lastID},
 			Count:   10,
 			Block:   1 * time.Second,
@@ -1,55 +1,36 @@
 package ota
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
 
+	"librescoot/lsc/internal/app"
+	"librescoot/lsc/internal/confirm"
 	"librescoot/lsc/internal/format"
 
 	"github.com/spf13/cobra"
 )
 
-var checkCmd = &cobra.Command{
-	Use:   "check",
-	Short: "Trigger immediate update check",
-	Long: `Trigger an immediate update check by sending a check-now command to the update service.
+func newCheckCmd(a *app.App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "check",
+		Short: "Trigger immediate update check",
+		Long: `Trigger an immediate update check by sending a check-now command to the update service.
 
 This bypasses the configured check interval and causes both MDB and DBC update services
 to check for available updates immediately.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		// Send check-now command to scooter:update
-		err := RedisClient.LPush("scooter:update", "check-now")
-		if err != nil {
-			if JSONOutput != nil && *JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "ota-check",
-					"status":  "error",
-					"error":   err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Failed to trigger update check: %v\n"), err)
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Send check-now command to scooter:update
+			if err := a.Send(cmd.Context(), confirm.Command{List: "scooter:update", Payload: "check-now"}); err != nil {
+				return fmt.Errorf("failed to trigger update check: %w", err)
 			}
-			os.Exit(1)
-			return
-		}
 
-		if JSONOutput != nil && *JSONOutput {
-			output, _ := json.Marshal(map[string]interface{}{
-				"command": "ota-check",
-				"status":  "success",
+			return a.Render(map[string]interface{}{
 				"message": "Update check triggered",
+			}, func() {
+				fmt.Println(format.Success("Update check triggered"))
+				fmt.Println(format.Info("The update service will check for available updates immediately"))
+				fmt.Println(format.Dim("Use 'lsc ota status' to monitor update progress"))
 			})
-			fmt.Println(string(output))
-		} else {
-			fmt.Println(format.Success("Update check triggered"))
-			fmt.Println(format.Info("The update service will check for available updates immediately"))
-			fmt.Println(format.Dim("Use 'lsc ota status' to monitor update progress"))
-		}
-	},
-}
-
-func init() {
-	OTACmd.AddCommand(checkCmd)
+		},
+	}
 }
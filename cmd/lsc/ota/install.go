@@ -1,124 +1,106 @@
 package ota
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 
+	"librescoot/lsc/internal/app"
+	"librescoot/lsc/internal/audit"
 	"librescoot/lsc/internal/format"
+	"librescoot/lsc/internal/output"
+	"librescoot/lsc/internal/runner"
 
 	"github.com/spf13/cobra"
 )
 
-var installCmd = &cobra.Command{
-	Use:   "install <file-or-url>",
-	Short: "Install OTA update",
-	Long: `Install an OTA update from a local .mender file or download from URL.
+// remoteUpdatePath is where an update is copied to on the scooter
+const remoteUpdatePath = "/data/lsc-update.mender"
+
+// remoteInstall copies the update from stdin to $0 on the scooter, installs
+// it and removes the copy again
+const remoteInstall = `cat > "$0" && mender-update install "$0"; status=$?; rm -f "$0"; exit $status`
+
+func newInstallCmd(a *app.App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "install <file-or-url>",
+		Short: "Install OTA update",
+		Long: `Install an OTA update from a local .mender file or download from URL.
 
 This command will:
   - Download the file if a URL is provided
-  - Install the update using mender-update
+  - Copy it to the scooter when connected with --ssh
+  - Install the update using mender-update on the scooter
   - Report installation progress`,
-	Args: cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		source := args[0]
-		var filePath string
-		var err error
-
-		// Check if source is a URL
-		if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
-			if JSONOutput != nil && *JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "ota-install",
-					"status":  "downloading",
-					"url":     source,
-				})
-				fmt.Println(string(output))
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := a.Allow("install an update"); err != nil {
+				return err
+			}
+
+			source := args[0]
+			var filePath string
+			var err error
+
+			// Check if source is a URL
+			if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+				if !a.Structured() {
+					fmt.Printf("Downloading update from %s...\n", source)
+				}
+
+				filePath, err = downloadFile(source)
+				if err != nil {
+					return fmt.Errorf("failed to download update: %w", err)
+				}
+				defer os.Remove(filePath) // Clean up downloaded file
 			} else {
-				fmt.Printf("Downloading update from %s...\n", source)
+				filePath = source
 			}
 
-			filePath, err = downloadFile(source)
-			if err != nil {
-				if JSONOutput != nil && *JSONOutput {
-					output, _ := json.Marshal(map[string]interface{}{
-						"command": "ota-install",
-						"status":  "error",
-						"error":   fmt.Sprintf("download failed: %v", err),
-					})
-					fmt.Println(string(output))
-				} else {
-					fmt.Fprintf(os.Stderr, format.Error("Failed to download update: %v\n"), err)
+			// Verify file exists
+			if _, err := os.Stat(filePath); os.IsNotExist(err) {
+				return output.InvalidArgument("file not found: %s", filePath)
+			}
+
+			// Install using mender-update
+			if !a.Structured() {
+				fmt.Printf("Installing update from %s...\n", filepath.Base(filePath))
+			}
+
+			menderCmd := runner.Command(a.Runner, "mender-update", "install", filePath)
+			if runner.Remote(a.Runner) {
+				file, err := os.Open(filePath)
+				if err != nil {
+					return fmt.Errorf("failed to read update: %w", err)
 				}
-				return
+				defer file.Close()
+				menderCmd = runner.Command(a.Runner, "sh", "-c", remoteInstall, remoteUpdatePath)
+				menderCmd.Stdin = file
 			}
-			defer os.Remove(filePath) // Clean up downloaded file
-		} else {
-			filePath = source
-		}
-
-		// Verify file exists
-		if _, err := os.Stat(filePath); os.IsNotExist(err) {
-			if JSONOutput != nil && *JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "ota-install",
-					"status":  "error",
-					"error":   fmt.Sprintf("file not found: %s", filePath),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("File not found: %s\n"), filePath)
+			menderCmd.Stdout = os.Stdout
+			menderCmd.Stderr = os.Stderr
+			if a.Structured() {
+				// Keep stdout for the result envelope
+				menderCmd.Stdout = os.Stderr
 			}
-			return
-		}
-
-		// Install using mender-update
-		if JSONOutput != nil && *JSONOutput {
-			output, _ := json.Marshal(map[string]interface{}{
-				"command": "ota-install",
-				"status":  "installing",
-				"file":    filePath,
-			})
-			fmt.Println(string(output))
-		} else {
-			fmt.Printf("Installing update from %s...\n", filepath.Base(filePath))
-		}
-
-		menderCmd := exec.Command("mender-update", "install", filePath)
-		menderCmd.Stdout = os.Stdout
-		menderCmd.Stderr = os.Stderr
-
-		if err := menderCmd.Run(); err != nil {
-			if JSONOutput != nil && *JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "ota-install",
-					"status":  "error",
-					"error":   fmt.Sprintf("installation failed: %v", err),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Installation failed: %v\n"), err)
+
+			err = menderCmd.Run()
+			a.Record(audit.Record{Operation: "mender-update", Key: source, Payload: "install"}, err)
+			if err != nil {
+				return fmt.Errorf("installation failed: %w", err)
 			}
-			return
-		}
-
-		if JSONOutput != nil && *JSONOutput {
-			output, _ := json.Marshal(map[string]interface{}{
-				"command": "ota-install",
-				"status":  "success",
-				"file":    filePath,
+
+			return a.Render(map[string]interface{}{
+				"source": source,
+			}, func() {
+				fmt.Println(format.Success("Update installed successfully"))
+				fmt.Println(format.Warning("Note: A reboot may be required to complete the update"))
 			})
-			fmt.Println(string(output))
-		} else {
-			fmt.Println(format.Success("Update installed successfully"))
-			fmt.Println(format.Warning("Note: A reboot may be required to complete the update"))
-		}
-	},
+		},
+	}
 }
 
 func downloadFile(url string) (string, error) {
@@ -151,7 +133,3 @@ func downloadFile(url string) (string, error) {
 
 	return tmpFile.Name(), nil
 }
-
-func init() {
-	OTACmd.AddCommand(installCmd)
-}
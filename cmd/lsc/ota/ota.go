@@ -1,31 +1,22 @@
 package ota
 
 import (
-	"librescoot/lsc/internal/redis"
+	"librescoot/lsc/internal/app"
 
 	"github.com/spf13/cobra"
 )
 
-var (
-	RedisClient *redis.Client
-	JSONOutput  *bool
-)
-
-var OTACmd = &cobra.Command{
-	Use:   "ota",
-	Short: "OTA update management",
-	Long:  `Manage over-the-air (OTA) updates using Mender.`,
-}
-
-// SetRedisClient sets the Redis client for all ota commands
-func SetRedisClient(client *redis.Client) {
-	RedisClient = client
-}
-
-// SetJSONOutput sets the JSON output flag reference for all ota commands
-func SetJSONOutput(jsonOutput *bool) {
-	JSONOutput = jsonOutput
-}
-
-func init() {
+// NewCommand creates the ota command and its subcommands
+func NewCommand(a *app.App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ota",
+		Short: "OTA update management",
+		Long:  `Manage over-the-air (OTA) updates using Mender.`,
+	}
+	cmd.AddCommand(
+		newCheckCmd(a),
+		newInstallCmd(a),
+		newStatusCmd(a),
+	)
+	return cmd
 }
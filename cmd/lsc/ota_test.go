@@ -0,0 +1,61 @@
+package lsc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOTAStatus(t *testing.T) {
+	srv := newScooter(t)
+
+	res := mustRun(t, srv, "ota", "status")
+	assertContains(t, res.stdout,
+		"mdb:\n  status:            downloading",
+		"update-version:    v1.5.0",
+		"download-progress: 42",
+		"dbc:\n  status:            (no update service)",
+	)
+
+	data := jsonData(t, srv, "ota", "status")
+	for path, want := range map[string]interface{}{
+		"components.mdb.status":            "downloading",
+		"components.mdb.download-progress": "42",
+		"components.mdb.error":             nil,
+		"components.dbc.status":            nil,
+	} {
+		if got := lookup(t, data, path); got != want {
+			t.Errorf("%s = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestOTAInstall(t *testing.T) {
+	srv := newScooter(t)
+	writeUserConfig(t, "")
+
+	// A mender-update stand-in that records its arguments
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	script := "#!/bin/sh\necho \"$@\" >> " + calls + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "mender-update"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	update := filepath.Join(dir, "v1.5.0.mender")
+	os.WriteFile(update, []byte("artifact"), 0o644)
+
+	res := mustRun(t, srv, "ota", "install", update)
+	assertContains(t, res.stdout, "Update installed successfully")
+	if got, _ := os.ReadFile(calls); string(got) != "install "+update+"\n" {
+		t.Errorf("mender-update was called with %q", got)
+	}
+
+	records := decodeResult(t, mustRun(t, srv, "audit", "show", "--json")).Data.([]interface{})
+	if r := records[len(records)-1].(map[string]interface{}); r["operation"] != "mender-update" || r["command"] != "ota.install" || r["key"] != update {
+		t.Errorf("record = %v", r)
+	}
+
+	res = runLSC(t, srv, "--read-only", "ota", "install", update)
+	assertCode(t, res.err, "permission_denied")
+}
@@ -1,63 +1,54 @@
 package power
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
 
+	"librescoot/lsc/internal/app"
+	"librescoot/lsc/internal/confirm"
 	"librescoot/lsc/internal/format"
+	"librescoot/lsc/internal/policy"
 
 	"github.com/spf13/cobra"
 )
 
-var (
-	hibernateManual bool
-	hibernateTimer  bool
-)
-
-var hibernateCmd = &cobra.Command{
-	Use:   "hibernate",
-	Short: "Set power state to hibernate",
-	Long:  `Request the power manager to transition to hibernate (power off) state.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		command := "hibernate"
-
-		if hibernateManual {
-			command = "hibernate-manual"
-		} else if hibernateTimer {
-			command = "hibernate-timer"
-		}
-
-		if err := RedisClient.LPush("scooter:power", command); err != nil {
-			if JSONOutput != nil && *JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": command,
-					"status":  "error",
-					"error":   err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Failed to send hibernate command: %v\n"), err)
+func newHibernateCmd(a *app.App) *cobra.Command {
+	var (
+		hibernateManual bool
+		hibernateTimer  bool
+		force           bool
+	)
+	cmd := &cobra.Command{
+		Use:   "hibernate",
+		Short: "Set power state to hibernate",
+		Long:  `Request the power manager to transition to hibernate (power off) state.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			command := "hibernate"
+
+			if hibernateManual {
+				command = "hibernate-manual"
+			} else if hibernateTimer {
+				command = "hibernate-timer"
 			}
-			return
-		}
 
-		if JSONOutput != nil && *JSONOutput {
-			output, _ := json.Marshal(map[string]interface{}{
-				"command": command,
-				"status":  "success",
-			})
-			fmt.Println(string(output))
-		} else {
-			fmt.Printf("%s Power state set to: %s\n", format.Success("✓"), command)
-			fmt.Println(format.Warning("Warning: System will power off"))
-		}
-	},
-}
+			ctx := cmd.Context()
+			if err := a.Guard(ctx, "hibernate", force, policy.All...); err != nil {
+				return err
+			}
 
-func init() {
-	hibernateCmd.Flags().BoolVar(&hibernateManual, "manual", false, "Use hibernate-manual mode")
-	hibernateCmd.Flags().BoolVar(&hibernateTimer, "timer", false, "Use hibernate-timer mode")
+			if err := a.Send(ctx, confirm.Command{List: "scooter:power", Payload: command}); err != nil {
+				return fmt.Errorf("failed to send hibernate command: %w", err)
+			}
 
-	PowerCmd.AddCommand(hibernateCmd)
+			return a.Render(map[string]interface{}{
+				"requested": command,
+			}, func() {
+				fmt.Printf("%s Power state set to: %s\n", format.Success("✓"), command)
+				fmt.Println(format.Warning("Warning: System will power off"))
+			})
+		},
+	}
+	cmd.Flags().BoolVar(&hibernateManual, "manual", false, "Use hibernate-manual mode")
+	cmd.Flags().BoolVar(&hibernateTimer, "timer", false, "Use hibernate-timer mode")
+	cmd.Flags().BoolVar(&force, "force", false, "Hibernate even while the scooter is ridden or charging")
+	return cmd
 }
@@ -1,27 +1,24 @@
 package power
 
 import (
-	"librescoot/lsc/internal/redis"
+	"librescoot/lsc/internal/app"
 
 	"github.com/spf13/cobra"
 )
 
-var RedisClient *redis.Client
-var JSONOutput *bool
-
-// SetRedisClient allows the parent command to inject the Redis client
-func SetRedisClient(client *redis.Client) {
-	RedisClient = client
-}
-
-// SetJSONOutput allows the parent command to inject the JSON output flag
-func SetJSONOutput(jsonOutput *bool) {
-	JSONOutput = jsonOutput
-}
-
-// PowerCmd represents the power command
-var PowerCmd = &cobra.Command{
-	Use:   "power",
-	Short: "Power management and status",
-	Long:  `View power manager status and control power states (run, suspend, hibernate).`,
+// NewCommand creates the power command and its subcommands
+func NewCommand(a *app.App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "power",
+		Short: "Power management and status",
+		Long:  `View power manager status and control power states (run, suspend, hibernate).`,
+	}
+	cmd.AddCommand(
+		newHibernateCmd(a),
+		newRebootCmd(a),
+		newRunCmd(a),
+		newStatusCmd(a),
+		newSuspendCmd(a),
+	)
+	return cmd
 }
@@ -1,47 +1,40 @@
 package power
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
 
+	"librescoot/lsc/internal/app"
+	"librescoot/lsc/internal/confirm"
 	"librescoot/lsc/internal/format"
+	"librescoot/lsc/internal/policy"
 
 	"github.com/spf13/cobra"
 )
 
-var rebootCmd = &cobra.Command{
-	Use:   "reboot",
-	Short: "Reboot the system",
-	Long:  `Request the power manager to reboot the system.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		if err := RedisClient.LPush("scooter:power", "reboot"); err != nil {
-			if JSONOutput != nil && *JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "reboot",
-					"status":  "error",
-					"error":   err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Failed to send reboot command: %v\n"), err)
+func newRebootCmd(a *app.App) *cobra.Command {
+	var force bool
+	cmd := &cobra.Command{
+		Use:   "reboot",
+		Short: "Reboot the system",
+		Long:  `Request the power manager to reboot the system.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			if err := a.Guard(ctx, "reboot", force, policy.All...); err != nil {
+				return err
 			}
-			return
-		}
 
-		if JSONOutput != nil && *JSONOutput {
-			output, _ := json.Marshal(map[string]interface{}{
-				"command": "reboot",
-				"status":  "success",
-			})
-			fmt.Println(string(output))
-		} else {
-			fmt.Println(format.Success("Reboot command sent"))
-			fmt.Println(format.Warning("Warning: System will reboot"))
-		}
-	},
-}
+			if err := a.Send(ctx, confirm.Command{List: "scooter:power", Payload: "reboot"}); err != nil {
+				return fmt.Errorf("failed to send reboot command: %w", err)
+			}
 
-func init() {
-	PowerCmd.AddCommand(rebootCmd)
+			return a.Render(map[string]interface{}{
+				"requested": "reboot",
+			}, func() {
+				fmt.Println(format.Success("Reboot command sent"))
+				fmt.Println(format.Warning("Warning: System will reboot"))
+			})
+		},
+	}
+	cmd.Flags().BoolVar(&force, "force", false, "Reboot even while the scooter is ridden or charging")
+	return cmd
 }
@@ -1,46 +1,30 @@
 package power
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
 
+	"librescoot/lsc/internal/app"
+	"librescoot/lsc/internal/confirm"
 	"librescoot/lsc/internal/format"
 
 	"github.com/spf13/cobra"
 )
 
-var runCmd = &cobra.Command{
-	Use:   "run",
-	Short: "Set power state to run",
-	Long:  `Request the power manager to transition to run (normal operation) state.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		if err := RedisClient.LPush("scooter:power", "run"); err != nil {
-			if JSONOutput != nil && *JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "run",
-					"status":  "error",
-					"error":   err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Failed to send run command: %v\n"), err)
+func newRunCmd(a *app.App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "run",
+		Short: "Set power state to run",
+		Long:  `Request the power manager to transition to run (normal operation) state.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := a.Send(cmd.Context(), confirm.Command{List: "scooter:power", Payload: "run"}); err != nil {
+				return fmt.Errorf("failed to send run command: %w", err)
 			}
-			return
-		}
 
-		if JSONOutput != nil && *JSONOutput {
-			output, _ := json.Marshal(map[string]interface{}{
-				"command": "run",
-				"status":  "success",
+			return a.Render(map[string]interface{}{
+				"requested": "run",
+			}, func() {
+				fmt.Println(format.Success("Power state set to: run"))
 			})
-			fmt.Println(string(output))
-		} else {
-			fmt.Println(format.Success("Power state set to: run"))
-		}
-	},
-}
-
-func init() {
-	PowerCmd.AddCommand(runCmd)
+		},
+	}
 }
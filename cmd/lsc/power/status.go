@@ -1,182 +1,146 @@
 package power
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
-	"strconv"
 
+	"librescoot/lsc/internal/app"
 	"librescoot/lsc/internal/format"
+	"librescoot/lsc/internal/model"
 
 	"github.com/spf13/cobra"
 )
 
-var statusCmd = &cobra.Command{
-	Use:   "status",
-	Short: "Show power management status",
-	Long:  `Display current power manager state, battery levels, and inhibitor status.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		// Fetch power manager data
-		pmData, err := RedisClient.HGetAll("power-manager")
-		if err != nil {
-			if JSONOutput != nil && *JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"error": err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Failed to fetch power-manager data: %v\n"), err)
+func newStatusCmd(a *app.App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show power management status",
+		Long:  `Display current power manager state, battery levels, and inhibitor status.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Fetch power manager data
+			pmData, err := a.Redis.HGetAll(model.PowerManagerKey)
+			if err != nil {
+				return fmt.Errorf("failed to fetch power-manager data: %w", err)
 			}
-			return
-		}
-
-		// Fetch power mux data
-		pmuxData, _ := RedisClient.HGetAll("power-mux")
+			pm := model.ParsePowerManager(pmData)
 
-		// Fetch aux battery data
-		auxBattery, _ := RedisClient.HGetAll("aux-battery")
+			// Fetch power mux data
+			pmuxData, _ := a.Redis.HGetAll(model.PowerMuxKey)
+			pmux := model.ParsePowerMux(pmuxData)
 
-		// Fetch cb battery data
-		cbBattery, _ := RedisClient.HGetAll("cb-battery")
+			// Fetch aux battery data; an empty hash means there is no aux battery
+			auxData, _ := a.Redis.HGetAll(model.AuxBatteryKey)
+			var aux *model.AuxBattery
+			if len(auxData) > 0 {
+				aux = model.ParseAuxBattery(auxData)
+			}
 
-		// Fetch inhibitors
-		inhibitors, _ := RedisClient.SMembers("power-manager:busy-services")
+			// Fetch cb battery data
+			cbData, _ := a.Redis.HGetAll(model.CBBatteryKey)
+			cb := model.ParseCBBattery(cbData)
 
-		// JSON output
-		if JSONOutput != nil && *JSONOutput {
-			parseInt := func(s string) int {
-				v, _ := strconv.Atoi(s)
-				return v
-			}
-			parseFloat := func(s string) float64 {
-				v, _ := strconv.ParseFloat(s, 64)
-				return v
-			}
+			// Fetch inhibitors
+			inhibitors, _ := a.Redis.SMembers("power-manager:busy-services")
 
-			output := map[string]interface{}{
+			data := map[string]interface{}{
 				"power_manager": map[string]interface{}{
-					"state":        pmData["state"],
-					"power_source": pmuxData["selected-input"],
+					"state":        pm.State,
+					"power_source": pmux.SelectedInput,
 					"inhibitors":   inhibitors,
 				},
 			}
 
-			if len(auxBattery) > 0 {
-				output["aux_battery"] = map[string]interface{}{
-					"voltage_v":      parseFloat(auxBattery["voltage"]) / 1000.0,
-					"charge_percent": parseInt(auxBattery["charge"]),
-					"charge_status":  auxBattery["charge-status"],
+			if aux != nil {
+				data["aux_battery"] = map[string]interface{}{
+					"voltage_v":      aux.Voltage,
+					"charge_percent": aux.Charge,
+					"charge_status":  aux.ChargeStatus,
 				}
 			}
 
-			if len(cbBattery) > 0 && cbBattery["present"] == "true" {
-				output["cb_battery"] = map[string]interface{}{
+			if cb.Present {
+				data["cb_battery"] = map[string]interface{}{
 					"present":        true,
-					"charge_percent": parseInt(cbBattery["charge"]),
-					"charge_status":  cbBattery["charge-status"],
-					"health_percent": parseInt(cbBattery["state-of-health"]),
-					"cycles":         parseInt(cbBattery["cycle-count"]),
-					"temperature_c":  parseInt(cbBattery["temperature"]),
+					"charge_percent": cb.Charge,
+					"charge_status":  cb.ChargeStatus,
+					"health_percent": cb.StateOfHealth,
+					"cycles":         cb.CycleCount,
+					"temperature_c":  cb.Temperature,
 				}
 			} else {
-				output["cb_battery"] = map[string]interface{}{
+				data["cb_battery"] = map[string]interface{}{
 					"present": false,
 				}
 			}
 
-			jsonBytes, _ := json.MarshalIndent(output, "", "  ")
-			fmt.Println(string(jsonBytes))
-			return
-		}
+			return a.Render(data, func() {
+				printPowerStatus(pm, pmux, aux, cb, inhibitors)
+			})
+		},
+	}
+}
 
-		// Display power manager status
-		format.PrintSection("Power Manager")
+// printPowerStatus prints the power manager and auxiliary battery sections
+func printPowerStatus(pm *model.PowerManager, pmux *model.PowerMux, aux *model.AuxBattery, cb *model.CBBattery, inhibitors []string) {
+	// Display power manager status
+	format.PrintSection("Power Manager")
 
-		state := pmData["state"]
-		if state != "" {
-			format.PrintKV("State", format.ColorizeState(state))
-		} else {
-			format.PrintKV("State", format.Warning("Unknown"))
-		}
+	if pm.State != "" {
+		format.PrintKV("State", format.ColorizeState(pm.State))
+	} else {
+		format.PrintKV("State", format.Warning("Unknown"))
+	}
 
-		// Power source
-		if pmuxData["selected-input"] != "" {
-			selectedInput := pmuxData["selected-input"]
-			format.PrintKV("Power Source", formatPowerSource(selectedInput))
-		}
+	// Power source
+	if pmux.SelectedInput != "" {
+		format.PrintKV("Power Source", formatPowerSource(pmux.SelectedInput))
+	}
 
-		// Inhibitors
-		if len(inhibitors) > 0 {
-			format.PrintSubsection("Active Inhibitors")
-			for _, inh := range inhibitors {
-				fmt.Printf("  %s %s\n", format.Warning("•"), inh)
-			}
-		} else {
-			format.PrintKV("Inhibitors", format.Success("None"))
+	// Inhibitors
+	if len(inhibitors) > 0 {
+		format.PrintSubsection("Active Inhibitors")
+		for _, inh := range inhibitors {
+			fmt.Printf("  %s %s\n", format.Warning("•"), inh)
 		}
+	} else {
+		format.PrintKV("Inhibitors", format.Success("None"))
+	}
 
-		// Auxiliary batteries
-		if len(auxBattery) > 0 {
-			format.PrintSection("Auxiliary Battery")
-
-			voltage := auxBattery["voltage"]
-			if voltage != "" {
-				voltageVal, _ := strconv.Atoi(voltage)
-				format.PrintKV("Voltage", format.FormatVoltageColored(voltage))
-
-				// Typical 12V battery ranges
-				if voltageVal > 12500 {
-					// Good voltage for 12V system
-				} else if voltageVal > 11000 {
-					// Low voltage warning
-				}
-			}
-
-			charge := auxBattery["charge"]
-			if charge != "" {
-				chargeVal, _ := strconv.Atoi(charge)
-				format.PrintKV("Charge", format.ColorizePercentage(chargeVal))
-			}
+	// Auxiliary batteries; fields the service has not written are left out
+	if aux != nil {
+		format.PrintSection("Auxiliary Battery")
 
-			chargeStatus := auxBattery["charge-status"]
-			if chargeStatus != "" {
-				format.PrintKV("Status", format.ColorizeState(chargeStatus))
-			}
+		if aux.Has("voltage") {
+			format.PrintKV("Voltage", format.FormatVoltsColored(aux.Voltage))
 		}
+		if aux.Has("charge") {
+			format.PrintKV("Charge", format.ColorizePercentage(aux.Charge))
+		}
+		if aux.ChargeStatus != "" {
+			format.PrintKV("Status", format.ColorizeState(aux.ChargeStatus))
+		}
+	}
 
-		if len(cbBattery) > 0 && cbBattery["present"] == "true" {
-			format.PrintSection("Control Board Battery")
-
-			charge := cbBattery["charge"]
-			if charge != "" {
-				chargeVal, _ := strconv.Atoi(charge)
-				format.PrintKV("Charge", format.ColorizePercentage(chargeVal))
-			}
-
-			chargeStatus := cbBattery["charge-status"]
-			if chargeStatus != "" {
-				format.PrintKV("Status", format.ColorizeState(chargeStatus))
-			}
-
-			soh := cbBattery["state-of-health"]
-			if soh != "" {
-				sohVal, _ := strconv.Atoi(soh)
-				format.PrintKV("Health", format.ColorizePercentage(sohVal))
-			}
-
-			cycleCount := cbBattery["cycle-count"]
-			if cycleCount != "" {
-				format.PrintKV("Cycles", cycleCount)
-			}
+	if cb.Present {
+		format.PrintSection("Control Board Battery")
 
-			temp := cbBattery["temperature"]
-			if temp != "" {
-				format.PrintKV("Temperature", format.FormatTemperatureColored(temp))
-			}
+		if cb.Has("charge") {
+			format.PrintKV("Charge", format.ColorizePercentage(cb.Charge))
+		}
+		if cb.ChargeStatus != "" {
+			format.PrintKV("Status", format.ColorizeState(cb.ChargeStatus))
+		}
+		if cb.Has("state-of-health") {
+			format.PrintKV("Health", format.ColorizePercentage(cb.StateOfHealth))
 		}
+		if cb.Has("cycle-count") {
+			format.PrintKV("Cycles", fmt.Sprint(cb.CycleCount))
+		}
+		if cb.Has("temperature") {
+			format.PrintKV("Temperature", format.ColorizeTemperature(cb.Temperature))
+		}
+	}
 
-		fmt.Println()
-	},
+	fmt.Println()
 }
 
 func formatPowerSource(source string) string {
@@ -191,7 +155,3 @@ func formatPowerSource(source string) string {
 		return source
 	}
 }
-
-func init() {
-	PowerCmd.AddCommand(statusCmd)
-}
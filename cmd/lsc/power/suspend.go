@@ -1,47 +1,31 @@
 package power
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
 
+	"librescoot/lsc/internal/app"
+	"librescoot/lsc/internal/confirm"
 	"librescoot/lsc/internal/format"
 
 	"github.com/spf13/cobra"
 )
 
-var suspendCmd = &cobra.Command{
-	Use:   "suspend",
-	Short: "Set power state to suspend",
-	Long:  `Request the power manager to transition to suspend (low power) state.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		if err := RedisClient.LPush("scooter:power", "suspend"); err != nil {
-			if JSONOutput != nil && *JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "suspend",
-					"status":  "error",
-					"error":   err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Failed to send suspend command: %v\n"), err)
+func newSuspendCmd(a *app.App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "suspend",
+		Short: "Set power state to suspend",
+		Long:  `Request the power manager to transition to suspend (low power) state.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := a.Send(cmd.Context(), confirm.Command{List: "scooter:power", Payload: "suspend"}); err != nil {
+				return fmt.Errorf("failed to send suspend command: %w", err)
 			}
-			return
-		}
 
-		if JSONOutput != nil && *JSONOutput {
-			output, _ := json.Marshal(map[string]interface{}{
-				"command": "suspend",
-				"status":  "success",
+			return a.Render(map[string]interface{}{
+				"requested": "suspend",
+			}, func() {
+				fmt.Println(format.Success("Power state set to: suspend"))
+				fmt.Println(format.Dim("Note: System will enter low power mode"))
 			})
-			fmt.Println(string(output))
-		} else {
-			fmt.Println(format.Success("Power state set to: suspend"))
-			fmt.Println(format.Dim("Note: System will enter low power mode"))
-		}
-	},
-}
-
-func init() {
-	PowerCmd.AddCommand(suspendCmd)
+		},
+	}
 }
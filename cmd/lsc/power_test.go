@@ -0,0 +1,45 @@
+package lsc
+
+import "testing"
+
+func TestPowerStatus(t *testing.T) {
+	srv := newScooter(t)
+	srv.SAdd("power-manager:busy-services", "update-service")
+
+	res := mustRun(t, srv, "power", "status")
+	assertContains(t, res.stdout, "=== Power Manager ===", "State:               running", "update-service")
+
+	data := jsonData(t, srv, "power", "status")
+	if got := lookup(t, data, "power_manager.state"); got != "running" {
+		t.Errorf("state = %v, want running", got)
+	}
+	if got := lookup(t, data, "power_manager.inhibitors.0"); got != "update-service" {
+		t.Errorf("inhibitors = %v", lookup(t, data, "power_manager.inhibitors"))
+	}
+}
+
+func TestPowerRebootWhileCharging(t *testing.T) {
+	srv := newScooter(t)
+	srv.HSet("battery:0", "current", "3500")
+
+	res := runLSC(t, srv, "power", "reboot")
+	assertCode(t, res.err, "precondition_failed")
+	assertContains(t, res.stderr, "refusing to reboot: battery 0 is charging at 3.5 A")
+	if srv.Exists("scooter:power") {
+		t.Error("reboot was sent although it was refused")
+	}
+
+	res = runLSC(t, srv, "power", "reboot", "--json")
+	envelope := decodeResult(t, res)
+	if envelope.Error == nil || envelope.Error.Code != "precondition_failed" {
+		t.Fatalf("envelope = %+v", envelope)
+	}
+	violation := lookup(t, map[string]interface{}{"details": envelope.Error.Details}, "details.violations.0")
+	if v := violation.(map[string]interface{}); v["condition"] != "charging" {
+		t.Errorf("violation = %v", v)
+	}
+
+	res = mustRun(t, srv, "power", "reboot", "--force")
+	assertContains(t, res.stderr, "Warning: battery 0 is charging at 3.5 A")
+	assertContains(t, res.stdout, "Reboot command sent")
+}
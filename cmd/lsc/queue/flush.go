@@ -0,0 +1,43 @@
+package queue
+
+import (
+	"fmt"
+
+	"librescoot/lsc/cmd/lsc/service"
+	"librescoot/lsc/internal/app"
+	"librescoot/lsc/internal/format"
+
+	"github.com/spf13/cobra"
+)
+
+func newFlushCmd(a *app.App) *cobra.Command {
+	return &cobra.Command{
+		Use:       "flush <list>",
+		Short:     "Discard pending commands",
+		Long:      `Discard the commands waiting in a command list, so they do not run when its service starts.`,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: service.Queues(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			list := args[0]
+			if err := checkQueue(list); err != nil {
+				return err
+			}
+
+			ctx := cmd.Context()
+			commands, err := a.Redis.LRangeWithContext(ctx, list, 0, -1)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", list, err)
+			}
+			if err := a.Redis.DelWithContext(ctx, list); err != nil {
+				return fmt.Errorf("failed to flush %s: %w", list, err)
+			}
+
+			return a.Render(map[string]interface{}{
+				"list":    list,
+				"flushed": len(commands),
+			}, func() {
+				fmt.Printf("%s Discarded %d command(s) from %s\n", format.Success("✓"), len(commands), list)
+			})
+		},
+	}
+}
@@ -0,0 +1,89 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"librescoot/lsc/cmd/lsc/service"
+	"librescoot/lsc/internal/app"
+	"librescoot/lsc/internal/format"
+
+	"github.com/spf13/cobra"
+)
+
+func newListCmd(a *app.App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list [list]",
+		Short: "Show pending commands",
+		Long: `Without an argument, show how many commands wait in each known command list and
+which service reads it. With a list name, show its pending commands in the order they
+will run.`,
+		Args:      cobra.MaximumNArgs(1),
+		ValidArgs: service.Queues(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			if len(args) == 1 {
+				return showQueue(ctx, a, args[0])
+			}
+
+			queues := make([]map[string]interface{}, 0)
+			rows := make([][]string, 0)
+			for _, list := range service.Queues() {
+				pending, err := a.Redis.LLenWithContext(ctx, list)
+				if err != nil {
+					return fmt.Errorf("failed to read %s: %w", list, err)
+				}
+				unit := service.QueueUnit(list)
+				queues = append(queues, map[string]interface{}{
+					"list":    list,
+					"pending": pending,
+					"service": unit,
+				})
+				count := format.Success("0")
+				if pending > 0 {
+					count = format.Warning(strconv.FormatInt(pending, 10))
+				}
+				rows = append(rows, []string{list, count, unit})
+			}
+
+			return a.Render(queues, func() {
+				format.PrintTable([]string{"Queue", "Pending", "Service"}, rows)
+			})
+		},
+	}
+}
+
+// showQueue prints the commands waiting in one list
+func showQueue(ctx context.Context, a *app.App, list string) error {
+	if err := checkQueue(list); err != nil {
+		return err
+	}
+	commands, err := a.Redis.LRangeWithContext(ctx, list, 0, -1)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", list, err)
+	}
+	// Commands are pushed onto the head and taken from the tail
+	slices.Reverse(commands)
+
+	unit := service.QueueUnit(list)
+	return a.Render(map[string]interface{}{
+		"list":     list,
+		"service":  unit,
+		"commands": commands,
+	}, func() {
+		format.PrintSection(list)
+		if unit != "" {
+			format.PrintKV("Service", unit)
+		}
+		if len(commands) == 0 {
+			format.PrintKV("Pending", format.Success("None"))
+			return
+		}
+		format.PrintKV("Pending", format.Warning(strconv.Itoa(len(commands))))
+		for i, command := range commands {
+			fmt.Printf("  %d. %s\n", i+1, command)
+		}
+	})
+}
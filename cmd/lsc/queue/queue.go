@@ -0,0 +1,35 @@
+package queue
+
+import (
+	"strings"
+
+	"librescoot/lsc/internal/app"
+	"librescoot/lsc/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+// NewCommand creates the queue command and its subcommands
+func NewCommand(a *app.App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "queue",
+		Short: "Inspect and clear command queues",
+		Long: `Inspect and clear the scooter:* command lists the services read their commands from.
+
+Commands pile up in a list when the service reading it is not running, and would all
+run at once when it starts. lsc refuses to add to such a list until it is cleared.`,
+	}
+	cmd.AddCommand(
+		newFlushCmd(a),
+		newListCmd(a),
+	)
+	return cmd
+}
+
+// checkQueue rejects names outside the scooter:* command lists
+func checkQueue(list string) error {
+	if !strings.HasPrefix(list, "scooter:") {
+		return output.InvalidArgument("invalid queue '%s'; command queues are named scooter:*", list)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package lsc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQueueNotConsumed(t *testing.T) {
+	srv := newScooter(t)
+
+	// Nobody reads the list: the command is sent with a warning
+	res := mustRun(t, srv, "power", "reboot")
+	assertContains(t, res.stderr, "librescoot-pm.service has not taken the command from scooter:power")
+
+	// Further commands are refused instead of piling up
+	res = runLSC(t, srv, "power", "run")
+	assertCode(t, res.err, "rejected")
+	assertContains(t, res.stderr, "scooter:power already holds 1 unconsumed command(s)", "librescoot-pm.service")
+	if list, _ := srv.List("scooter:power"); !reflect.DeepEqual(list, []string{"reboot"}) {
+		t.Errorf("scooter:power = %v", list)
+	}
+
+	// A confirmed command names the service when it times out
+	res = runLSC(t, srv, "--command-timeout", "200ms", "vehicle", "lock")
+	assertCode(t, res.err, "timeout")
+	assertContains(t, res.stderr, "librescoot-vehicle.service has not taken it from scooter:state")
+}
+
+func TestQueueListAndFlush(t *testing.T) {
+	srv := newScooter(t)
+	srv.Lpush("scooter:power", "suspend")
+	srv.Lpush("scooter:power", "run")
+
+	res := mustRun(t, srv, "queue", "list")
+	assertContains(t, res.stdout, "scooter:power", "librescoot-pm.service", "scooter:state")
+
+	data := jsonData(t, srv, "queue", "list", "scooter:power")
+	if !reflect.DeepEqual(data["commands"], []interface{}{"suspend", "run"}) || data["service"] != "librescoot-pm.service" {
+		t.Errorf("queue list data = %v", data)
+	}
+
+	data = jsonData(t, srv, "queue", "flush", "scooter:power")
+	if data["flushed"] != float64(2) {
+		t.Errorf("flush data = %v", data)
+	}
+	if srv.Exists("scooter:power") {
+		t.Error("scooter:power still exists after flush")
+	}
+
+	// With the queue cleared, commands are accepted again
+	startSim(t, srv)
+	mustRun(t, srv, "power", "run")
+
+	res = runLSC(t, srv, "queue", "flush", "vehicle")
+	assertCode(t, res.err, "invalid_argument")
+}
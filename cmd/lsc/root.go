@@ -5,42 +5,168 @@ import (
 	"io"
 	"log"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
+	"librescoot/lsc/cmd/lsc/bridge"
+	"librescoot/lsc/cmd/lsc/dashboard"
 	"librescoot/lsc/cmd/lsc/diag"
+	"librescoot/lsc/cmd/lsc/exporter"
 	"librescoot/lsc/cmd/lsc/gps"
 	"librescoot/lsc/cmd/lsc/locations"
 	"librescoot/lsc/cmd/lsc/logs"
 	"librescoot/lsc/cmd/lsc/monitor"
 	"librescoot/lsc/cmd/lsc/ota"
 	"librescoot/lsc/cmd/lsc/power"
+	"librescoot/lsc/cmd/lsc/queue"
 	"librescoot/lsc/cmd/lsc/service"
+	"librescoot/lsc/internal/app"
+	"librescoot/lsc/internal/audit"
+	"librescoot/lsc/internal/config"
+	"librescoot/lsc/internal/output"
 	"librescoot/lsc/internal/redis"
+	"librescoot/lsc/internal/runner"
+	"librescoot/lsc/internal/sshtunnel"
 
 	"github.com/spf13/cobra"
 )
 
 var (
-	redisClient *redis.Client
+	// lscApp carries the connection and renderer into the subcommand packages
+	lscApp = app.New()
+
 	redisAddr   string
-	JSONOutput  bool // Global flag for JSON output mode
+	JSONOutput  bool // the --json flag
+	profileName string
+	sshTarget   string
+
+	// sshTunnel is the open SSH connection when --ssh is used
+	sshTunnel *sshtunnel.Tunnel
+
+	// Redis connection flags (see redisFlagSources for their environment variables)
+	redisSocket      string
+	redisUser        string
+	redisPassword    string
+	redisDB          int
+	redisTLS         bool
+	redisTLSCA       string
+	redisTLSCert     string
+	redisTLSKey      string
+	redisDialTimeout time.Duration
+	redisReadTimeout time.Duration
+
+	// activeProfile is the resolved configuration profile for this invocation
+	activeProfile = &config.Profile{}
+
+	// commandTimeout overrides the per-command confirmation timeout when non-zero
+	commandTimeout time.Duration
+
+	// commandStarted is set once argument and flag validation passed
+	commandStarted bool
+
+	// outputSpec and outputFields are the --output and --fields values
+	outputSpec   string
+	outputFields []string
+
+	// resolvedOutput is the output format of the running command after
+	// applying --json, the profile and the session
+	resolvedOutput string
+
+	// readOnly is the --read-only flag; accessRules are the configured
+	// access lists and accessPath the dotted path of the running command
+	readOnly    bool
+	accessRules config.Access
+	accessPath  string
+
+	// auditConfig and auditProfile are what applyProfile found for the
+	// audit log
+	auditConfig  config.Audit
+	auditProfile string
+)
+
+// redisFlagSources maps connection flags to the environment variable and profile key
+// consulted when the flag is not given on the command line, in that order.
+var redisFlagSources = []struct {
+	flag, env, key string
+}{
+	{"ssh", "LSC_SSH", "ssh"},
+	{"redis-addr", "LSC_REDIS_ADDR", "redis-addr"},
+	{"redis-socket", "LSC_REDIS_SOCKET", "socket"},
+	{"redis-user", "LSC_REDIS_USER", "username"},
+	{"redis-password", "LSC_REDIS_PASSWORD", "password"},
+	{"redis-db", "LSC_REDIS_DB", "db"},
+	{"redis-tls", "LSC_REDIS_TLS", "tls"},
+	{"redis-tls-ca", "LSC_REDIS_TLS_CA", "tls-ca"},
+	{"redis-tls-cert", "LSC_REDIS_TLS_CERT", "tls-cert"},
+	{"redis-tls-key", "LSC_REDIS_TLS_KEY", "tls-key"},
+	{"dial-timeout", "LSC_REDIS_DIAL_TIMEOUT", "dial-timeout"},
+	{"read-timeout", "LSC_REDIS_READ_TIMEOUT", "read-timeout"},
+}
+
+// scooterRedisAddr is where Redis listens on the scooter's MDB, used as the
+// default address when connecting through an SSH tunnel
+const scooterRedisAddr = "192.168.7.1:6379"
+
+const (
+	// annotationNoRedis marks commands (and their children) that run without a Redis connection
+	annotationNoRedis = "lsc:no-redis"
+	// annotationPath names the command checked against the access lists for
+	// shortcuts of commands elsewhere in the tree, e.g. "vehicle.lock"
+	annotationPath = "lsc:path"
+	// annotationRedisFlag marks commands that only connect to Redis when
+	// the named flag is given
+	annotationRedisFlag = "lsc:redis-flag"
 )
 
+// systemctlReadVerbs are the systemctl verbs that only report state
+var systemctlReadVerbs = map[string]bool{
+	"status": true, "show": true, "cat": true, "is-active": true, "is-enabled": true,
+	"is-failed": true, "list-units": true, "list-unit-files": true,
+}
+
 func init() {
 	// Suppress all default log output (Redis client uses this)
 	log.SetOutput(io.Discard)
 
 	rootCmd.PersistentFlags().StringVar(&redisAddr, "redis-addr", "localhost:6379", "Redis server address (host:port)")
-	rootCmd.PersistentFlags().BoolVar(&JSONOutput, "json", false, "Output in JSON format")
+	rootCmd.PersistentFlags().StringVar(&sshTarget, "ssh", "", "Connect through an SSH tunnel to this host (ssh config alias or user@host[:port])")
+	rootCmd.PersistentFlags().StringVar(&redisSocket, "redis-socket", "", "Connect via unix socket instead of TCP")
+	rootCmd.PersistentFlags().StringVar(&redisUser, "redis-user", "", "Redis ACL username")
+	rootCmd.PersistentFlags().StringVar(&redisPassword, "redis-password", "", "Redis password (prefer LSC_REDIS_PASSWORD)")
+	rootCmd.PersistentFlags().IntVar(&redisDB, "redis-db", 0, "Redis database index")
+	rootCmd.PersistentFlags().BoolVar(&redisTLS, "redis-tls", false, "Connect to Redis over TLS")
+	rootCmd.PersistentFlags().StringVar(&redisTLSCA, "redis-tls-ca", "", "CA bundle for verifying the Redis server (implies --redis-tls)")
+	rootCmd.PersistentFlags().StringVar(&redisTLSCert, "redis-tls-cert", "", "Client certificate for TLS authentication (implies --redis-tls)")
+	rootCmd.PersistentFlags().StringVar(&redisTLSKey, "redis-tls-key", "", "Client key for TLS authentication")
+	rootCmd.PersistentFlags().DurationVar(&redisDialTimeout, "dial-timeout", 0, "Timeout for establishing Redis connections (default 5s)")
+	rootCmd.PersistentFlags().DurationVar(&redisReadTimeout, "read-timeout", 0, "Timeout for Redis socket reads (default 3s)")
+	rootCmd.PersistentFlags().BoolVar(&JSONOutput, "json", false, "Output in JSON format (same as --output json)")
+	rootCmd.PersistentFlags().StringVarP(&outputSpec, "output", "o", "", "Output format: pretty, json, yaml, csv, table or template=<go-template>")
+	rootCmd.PersistentFlags().StringSliceVar(&outputFields, "fields", nil, "Only output these fields (comma-separated dot paths, e.g. vehicle.state)")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "Configuration profile to use, - for none (env: LSC_PROFILE)")
+	rootCmd.PersistentFlags().DurationVar(&commandTimeout, "command-timeout", 0, "Override the confirmation timeout of control commands")
+	rootCmd.PersistentFlags().BoolVar(&readOnly, "read-only", false, "Refuse every command that would change the scooter")
+
+	// Name the service reading a command list in queue errors
+	lscApp.QueueService = service.QueueUnit
+	lscApp.Authorize = checkAccess
+	lscApp.AuthorizeAs = checkAccessAs
+	lscApp.Audit = &audit.Log{Clock: lscApp.Now}
 
 	// Add subcommands
-	rootCmd.AddCommand(diag.DiagCmd)
-	rootCmd.AddCommand(gps.GpsCmd)
-	rootCmd.AddCommand(locations.LocationsCmd)
-	rootCmd.AddCommand(logs.LogsCmd)
-	rootCmd.AddCommand(monitor.MonitorCmd)
-	rootCmd.AddCommand(ota.OTACmd)
-	rootCmd.AddCommand(power.PowerCmd)
-	rootCmd.AddCommand(service.ServiceCmd)
+	rootCmd.AddCommand(bridge.NewCommand(lscApp))
+	rootCmd.AddCommand(dashboard.NewCommand(lscApp))
+	rootCmd.AddCommand(diag.NewCommand(lscApp))
+	rootCmd.AddCommand(exporter.NewCommand(lscApp))
+	rootCmd.AddCommand(gps.NewCommand(lscApp))
+	rootCmd.AddCommand(locations.NewCommand(lscApp))
+	rootCmd.AddCommand(logs.NewCommand(lscApp))
+	rootCmd.AddCommand(monitor.NewCommand(lscApp))
+	rootCmd.AddCommand(ota.NewCommand(lscApp))
+	rootCmd.AddCommand(power.NewCommand(lscApp))
+	rootCmd.AddCommand(queue.NewCommand(lscApp))
+	rootCmd.AddCommand(service.NewCommand(lscApp))
 }
 
 // rootCmd represents the base command when called without any subcommands
@@ -63,56 +189,378 @@ It provides convenient access to:
   • Settings management
   • Fault monitoring and event streaming
 
-All commands support JSON output mode (--json) for automation and scripting.`,
+All commands support structured output for automation and scripting:
+--output json|yaml|csv|table|template=<go-template> (--json is short for
+--output json), optionally narrowed down with --fields.
+
+Exit codes:
+  0  success
+  1  general error
+  2  invalid argument, flag or command
+  3  connection error (Redis or SSH not reachable)
+  4  timeout waiting for the scooter to confirm a state change
+  5  request rejected by the scooter
+  6  command unsafe in the scooter's current state (see --force)
+  7  command denied by --read-only or the configured access lists`,
+	SilenceErrors: true,
+	SilenceUsage:  true,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		profileErr := applyProfile(cmd)
+		if err := beginCommand(cmd); err != nil {
+			return err
+		}
+		if profileErr != nil {
+			return output.InvalidArgument("invalid configuration: %w", profileErr)
+		}
+		accessPath = commandPath(cmd)
+		configureAudit()
+
+		// The shell keeps its connection open across commands
+		if skipsRedis(cmd) || sessionActive {
+			return nil
+		}
+
+		connectTimeout, err := config.Duration(activeProfile.ConnectTimeout)
+		if err != nil {
+			return output.InvalidArgument("invalid connect-timeout: %w", err)
+		}
+
+		opts := redis.Options{
+			Addr:           redisAddr,
+			Socket:         redisSocket,
+			Username:       redisUser,
+			Password:       redisPassword,
+			DB:             redisDB,
+			TLS:            redisTLS,
+			TLSCAFile:      redisTLSCA,
+			TLSCertFile:    redisTLSCert,
+			TLSKeyFile:     redisTLSKey,
+			ConnectTimeout: connectTimeout,
+			DialTimeout:    redisDialTimeout,
+			ReadTimeout:    redisReadTimeout,
+		}
+
+		commandRunner := runner.Runner(runner.Local{})
+		if sshTarget != "" {
+			sshTunnel, err = sshtunnel.Dial(sshTarget, connectTimeout)
+			if err != nil {
+				return output.Connection("failed to open SSH tunnel: %w", err)
+			}
+			if !cmd.Flags().Changed("redis-addr") {
+				opts.Addr = scooterRedisAddr
+			}
+			opts.Dialer = sshTunnel.DialContext
+			commandRunner = runner.SSH{Client: sshTunnel.Client()}
+		}
+		commandRunner = runner.Guarded{Runner: commandRunner, Check: checkSystemctl, Done: auditSystemctl}
+
 		// Temporarily suppress stderr to hide redis library warnings
 		oldStderr := os.Stderr
 		devNull, _ := os.Open(os.DevNull)
 		os.Stderr = devNull
 
-		redisClient = redis.NewClient(redisAddr)
-		err := redisClient.Connect()
+		client, err := redis.NewClientWithOptions(opts)
+		if err != nil {
+			os.Stderr = oldStderr
+			devNull.Close()
+			return output.InvalidArgument("%w", err)
+		}
+		err = client.Connect()
 
 		// Restore stderr
 		os.Stderr = oldStderr
 		devNull.Close()
 
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error connecting to Redis: %v\n", err)
-			return err
+			client.Close()
+			return output.Connection("%w", err)
 		}
+		client.SetWriteGuard(func(command string) error {
+			return checkAccess("send " + command)
+		})
+		client.ObserveWrites(auditRedis)
+		lscApp.Audit.Redis = client
 
-		// Make Redis client available to subcommands
-		diag.SetRedisClient(redisClient)
-		gps.SetRedisClient(redisClient)
-		locations.SetRedisClient(redisClient)
-		logs.SetRedisClient(redisClient)
-		monitor.SetRedisClient(redisClient)
-		ota.SetRedisClient(redisClient)
-		power.SetRedisClient(redisClient)
-		service.SetRedisClient(redisClient)
-
-		// Make JSONOutput flag available to subcommands
-		diag.SetJSONOutput(&JSONOutput)
-		gps.SetJSONOutput(&JSONOutput)
-		locations.SetJSONOutput(&JSONOutput)
-		logs.SetJSONOutput(&JSONOutput)
-		monitor.SetJSONOutput(&JSONOutput)
-		ota.SetJSONOutput(&JSONOutput)
-		power.SetJSONOutput(&JSONOutput)
-		service.SetJSONOutput(&JSONOutput)
+		// Make the connection available to the commands
+		lscApp.Redis = client
+		lscApp.Runner = commandRunner
 
 		return nil
 	},
 	PersistentPostRun: func(cmd *cobra.Command, args []string) {
-		if redisClient != nil {
-			redisClient.Close()
+		if !sessionActive {
+			closeConnections()
 		}
 	},
 }
 
+// beginCommand marks the start of command execution and configures the result
+// renderer from --output, --json and --fields
+func beginCommand(cmd *cobra.Command) error {
+	commandStarted = true
+	lscApp.Output.Begin(commandName(cmd))
+
+	spec := outputSpec
+	if sessionActive && !cmd.Flags().Changed("json") && !cmd.Flags().Changed("output") {
+		// The format chosen in the shell replaces the profile default
+		spec = sessionOutput
+	}
+	if JSONOutput {
+		if spec != "" && spec != "json" {
+			return output.InvalidArgument("--json conflicts with --output %s", spec)
+		}
+		spec = "json"
+	}
+	if err := lscApp.Output.SetFormat(spec); err != nil {
+		return err
+	}
+	lscApp.Output.SetFields(outputFields)
+	resolvedOutput = spec
+	return nil
+}
+
+// commandName returns the command path without the program name, e.g. "vehicle-lock"
+func commandName(cmd *cobra.Command) string {
+	path := strings.Fields(cmd.CommandPath())
+	if len(path) <= 1 {
+		return cmd.Name()
+	}
+	return strings.Join(path[1:], "-")
+}
+
+// commandPath returns the dotted path of cmd for the access lists, e.g.
+// "diag.handlebar"
+func commandPath(cmd *cobra.Command) string {
+	if p := cmd.Annotations[annotationPath]; p != "" {
+		return p
+	}
+	path := strings.Fields(cmd.CommandPath())
+	if len(path) <= 1 {
+		return cmd.Name()
+	}
+	return strings.Join(path[1:], ".")
+}
+
+// checkAccess refuses action when read-only mode or the access lists deny
+// the running command
+func checkAccess(action string) error {
+	return checkAccessAs(accessPath, action)
+}
+
+// checkAccessAs refuses action when read-only mode or the access lists deny
+// the command at path
+func checkAccessAs(path, action string) error {
+	if readOnly || accessRules.ReadOnly {
+		return output.Forbidden("read-only mode: lsc %s would %s", path, action)
+	}
+	if reason := accessRules.Denied(path); reason != "" {
+		return output.Forbidden("lsc %s is %s; it would %s", path, reason, action)
+	}
+	return nil
+}
+
+// systemctlChange returns the verb and units of a systemctl invocation that
+// changes services; verb is "" for anything else
+func systemctlChange(c *runner.Cmd) (verb string, units []string) {
+	if c.Name != "systemctl" {
+		return "", nil
+	}
+	for _, arg := range c.Args {
+		switch {
+		case strings.HasPrefix(arg, "-"):
+		case verb == "":
+			if systemctlReadVerbs[arg] {
+				return "", nil
+			}
+			verb = arg
+		default:
+			units = append(units, arg)
+		}
+	}
+	return verb, units
+}
+
+// checkSystemctl applies checkAccess to systemctl invocations that change
+// services
+func checkSystemctl(c *runner.Cmd) error {
+	if verb, _ := systemctlChange(c); verb == "" {
+		return nil
+	}
+	return checkAccess("run systemctl " + strings.Join(c.Args, " "))
+}
+
+// configureAudit points the audit log at the configured file and stream and
+// fills in the fields shared by the records of the running command
+func configureAudit() {
+	lscApp.Audit.Path = auditConfig.Path
+	if lscApp.Audit.Path == "" {
+		lscApp.Audit.Path, _ = config.AuditPath()
+	}
+	lscApp.Audit.Stream = auditConfig.Stream
+
+	user, sshClient := audit.Session()
+	target := redisAddr
+	if redisSocket != "" {
+		target = redisSocket
+	}
+	if sshTarget != "" {
+		target = sshTarget
+	}
+	lscApp.Audit.Base = audit.Record{
+		User:      user,
+		SSHClient: sshClient,
+		Profile:   auditProfile,
+		Target:    target,
+		Command:   accessPath,
+	}
+}
+
+// auditRedis records the Redis writes the audit log covers
+func auditRedis(args []string, err error) {
+	if r, ok := audit.FromRedis(args); ok {
+		lscApp.Record(r, err)
+	}
+}
+
+// auditSystemctl records systemctl invocations that change services
+func auditSystemctl(c *runner.Cmd, err error) {
+	verb, units := systemctlChange(c)
+	if verb == "" {
+		return
+	}
+	lscApp.Record(audit.Record{Operation: "systemctl", Key: strings.Join(units, " "), Payload: verb}, err)
+}
+
+// closeConnections closes the Redis client and SSH tunnel, if open
+func closeConnections() {
+	if lscApp.Redis != nil {
+		lscApp.Redis.Close()
+		lscApp.Redis = nil
+	}
+	if sshTunnel != nil {
+		sshTunnel.Close()
+		sshTunnel = nil
+	}
+}
+
+// applyProfile loads the configuration files and fills in every global flag
+// that was not given explicitly on the command line from the environment or
+// the selected profile.
+func applyProfile(cmd *cobra.Command) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	name := profileName
+	if name == "" {
+		name = os.Getenv("LSC_PROFILE")
+	}
+
+	profile, err := cfg.Profile(name)
+	if err != nil {
+		return err
+	}
+	activeProfile = profile
+	accessRules = cfg.Access
+	auditConfig = cfg.Audit
+	auditProfile = name
+	switch auditProfile {
+	case "":
+		auditProfile = cfg.DefaultProfile
+	case config.NoProfile:
+		auditProfile = ""
+	}
+
+	flags := cmd.Flags()
+	for _, src := range redisFlagSources {
+		if flags.Changed(src.flag) {
+			continue
+		}
+		value := os.Getenv(src.env)
+		origin := src.env
+		if value == "" {
+			value, _ = profile.Get(src.key)
+			origin = "profile key " + src.key
+		}
+		if value == "" {
+			continue
+		}
+		if err := flags.Set(src.flag, value); err != nil {
+			return fmt.Errorf("invalid %s: %w", origin, err)
+		}
+	}
+	if !flags.Changed("json") && !flags.Changed("output") && profile.Output != "" {
+		outputSpec = profile.Output
+	}
+	if !flags.Changed("command-timeout") {
+		timeout, err := config.Duration(profile.CommandTimeout)
+		if err != nil {
+			return fmt.Errorf("invalid command-timeout: %w", err)
+		}
+		commandTimeout = timeout
+	}
+
+	return nil
+}
+
+// skipsRedis reports whether cmd or one of its parents is annotated to run without Redis
+func skipsRedis(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.Annotations[annotationNoRedis] == "true" {
+			return true
+		}
+	}
+	if flag := cmd.Annotations[annotationRedisFlag]; flag != "" {
+		return !cmd.Flags().Changed(flag)
+	}
+	return false
+}
+
+// confirmTimeout returns the --command-timeout override if set, otherwise the command's default
+func confirmTimeout(def time.Duration) time.Duration {
+	if commandTimeout > 0 {
+		return commandTimeout
+	}
+	return def
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// The process exits with the code of the failure class (see output.ExitCode).
 func Execute() {
-	cobra.CheckErr(rootCmd.Execute())
+	cmd, err := rootCmd.ExecuteC()
+	if err != nil {
+		closeConnections()
+		err = reportError(cmd, os.Args[1:], err)
+	}
+	os.Exit(output.ExitCode(err))
+}
+
+// reportError prints err through the result renderer and returns it with its
+// final class. Errors raised before the command started (unknown commands, bad
+// flags or arguments) are invalid arguments.
+func reportError(cmd *cobra.Command, args []string, err error) error {
+	if !commandStarted {
+		// Flags are not parsed at all when the command is unknown
+		spec := ""
+		if sessionActive {
+			spec = sessionOutput
+		}
+		if slices.Contains(args, "--json") {
+			spec = "json"
+		}
+		lscApp.Output.SetFormat(spec)
+		lscApp.Output.Begin(commandName(cmd))
+		if output.ClassOf(err) == output.ClassGeneral {
+			err = output.InvalidArgument("%w", err)
+		}
+	}
+
+	lscApp.Output.PrintError(err)
+
+	if output.ClassOf(err) == output.ClassInvalidArgument && !lscApp.Output.Structured() {
+		fmt.Fprintf(os.Stderr, "Run '%s --help' for usage.\n", cmd.CommandPath())
+	}
+	return err
 }
@@ -0,0 +1,499 @@
+package lsc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"librescoot/lsc/internal/confirm"
+	"librescoot/lsc/internal/format"
+	"librescoot/lsc/internal/output"
+	"librescoot/lsc/internal/redis"
+
+	"github.com/spf13/cobra"
+)
+
+var runJUnit string
+
+// defaultWaitTimeout applies to wait steps without a timeout
+const defaultWaitTimeout = 10 * time.Second
+
+// scriptStep is one executable line of an lsc script
+type scriptStep struct {
+	Line            int
+	Text            string
+	Kind            string   // command, wait, expect or sleep
+	Args            []string // lsc command line of command steps
+	Hash            string
+	Field           string
+	Op              string        // == or != for expect, always == for wait
+	Value           string        // expected field value
+	Duration        time.Duration // sleep duration or wait timeout
+	ContinueOnError bool
+}
+
+// stepResult is the outcome of one script step
+type stepResult struct {
+	Step     scriptStep
+	Status   string // passed, failed or skipped
+	Code     string // failure class (see output.Code) when Status is failed
+	Error    string
+	Duration time.Duration
+	Result   interface{} // command data in structured output, field value of expect
+}
+
+// scriptRunner executes script steps on one command session
+type scriptRunner struct {
+	session *commandSession
+	// structured is set when the run command itself prints structured output;
+	// command output is then collected into the report instead of printed
+	structured bool
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run <script>",
+	Short: "Run a script of lsc commands with waits and assertions",
+	Long: `Run the lsc commands in a script file one after another over a single
+connection and report which steps passed. Use - to read the script from stdin.
+
+Each line holds an lsc command without the 'lsc' prefix, or a directive:
+
+  wait <hash>.<field> == <value> [timeout <duration>]
+                        Wait until a hash field has a value (default timeout 10s)
+  expect <hash>.<field> ==|!= <value>
+                        Fail unless a hash field has (or does not have) a value
+  sleep <duration>      Pause, e.g. sleep 500ms
+  on-error continue|abort
+                        Keep going after a failed step, or skip the remaining
+                        steps (the default)
+
+Values may be quoted; empty lines and lines starting with # are ignored.
+Settings are addressed as settings.<key>, e.g. settings.alarm.enabled.
+
+Example script:
+
+  # Bench sequence
+  unlock
+  wait vehicle.state == ready-to-drive timeout 5s
+  on-error continue
+  diag blinkers left
+  sleep 2s
+  diag blinkers off
+  diag horn on
+  sleep 200ms
+  diag horn off
+  on-error abort
+  lock
+  expect vehicle.state == stand-by
+
+The command exits with 1 if any step failed. --junit writes the report as
+JUnit XML for CI systems.
+
+Examples:
+  lsc run bench.lsc
+  lsc --ssh deep-blue run bench.lsc --junit report.xml
+  lsc run bench.lsc --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Flags are reset by every command the script runs
+		path, junitPath := args[0], runJUnit
+
+		steps, err := loadScript(path)
+		if err != nil {
+			return err
+		}
+
+		state := lscApp.Output.Save()
+		session, end := startSession(cmd)
+		runner := &scriptRunner{session: session, structured: lscApp.Structured()}
+		if runner.structured {
+			// Commands print JSON envelopes that are collected into the report
+			sessionOutput = "json"
+		} else {
+			sessionOutput = ""
+		}
+		results := runner.run(steps)
+		end()
+		lscApp.Output.Restore(state)
+
+		if junitPath != "" {
+			if err := writeJUnit(junitPath, path, results); err != nil {
+				return fmt.Errorf("failed to write JUnit report: %w", err)
+			}
+		}
+
+		failed := countSteps(results, "failed")
+		if err := lscApp.Render(scriptData(path, results), func() {
+			printScriptReport(results)
+		}); err != nil {
+			return err
+		}
+		if failed > 0 {
+			return output.Reported(fmt.Errorf("%d of %d steps failed", failed, len(results)))
+		}
+		return nil
+	},
+}
+
+// loadScript reads and parses the script at path, or stdin for -
+func loadScript(path string) ([]scriptStep, error) {
+	if path == "-" {
+		return parseScript(os.Stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, output.InvalidArgument("failed to open script: %w", err)
+	}
+	defer f.Close()
+	return parseScript(f)
+}
+
+// parseScript parses all lines of a script, so syntax errors are reported
+// before any command is sent to the scooter
+func parseScript(r io.Reader) ([]scriptStep, error) {
+	var steps []scriptStep
+	continueOnError := false
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		args, err := splitArgs(text)
+		if err != nil {
+			return nil, output.InvalidArgument("line %d: %w", line, err)
+		}
+
+		step := scriptStep{Line: line, Text: text, Kind: args[0], ContinueOnError: continueOnError}
+		switch args[0] {
+		case "on-error":
+			if len(args) != 2 || (args[1] != "continue" && args[1] != "abort") {
+				return nil, output.InvalidArgument("line %d: usage: on-error continue|abort", line)
+			}
+			continueOnError = args[1] == "continue"
+			continue
+		case "sleep":
+			err = parseSleep(&step, args[1:])
+		case "wait":
+			err = parseWait(&step, args[1:])
+		case "expect":
+			err = parseExpect(&step, args[1:])
+		case "run", "shell", "serve", "sim":
+			err = fmt.Errorf("'%s' cannot be used in a script", args[0])
+		default:
+			step.Kind = "command"
+			step.Args = args
+			_, _, err = rootCmd.Find(args)
+		}
+		if err != nil {
+			return nil, output.InvalidArgument("line %d: %w", line, err)
+		}
+		steps = append(steps, step)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read script: %w", err)
+	}
+	return steps, nil
+}
+
+func parseSleep(step *scriptStep, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: sleep <duration>")
+	}
+	d, err := time.ParseDuration(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid sleep duration: %w", err)
+	}
+	step.Duration = d
+	return nil
+}
+
+func parseWait(step *scriptStep, args []string) error {
+	const usage = "usage: wait <hash>.<field> == <value> [timeout <duration>]"
+	if len(args) != 3 && len(args) != 5 {
+		return errors.New(usage)
+	}
+	if err := parseCondition(step, args[:3], "=="); err != nil {
+		return err
+	}
+
+	step.Duration = defaultWaitTimeout
+	if len(args) == 5 {
+		if args[3] != "timeout" {
+			return errors.New(usage)
+		}
+		d, err := time.ParseDuration(args[4])
+		if err != nil {
+			return fmt.Errorf("invalid wait timeout: %w", err)
+		}
+		step.Duration = d
+	}
+	return nil
+}
+
+func parseExpect(step *scriptStep, args []string) error {
+	if len(args) != 3 {
+		return errors.New("usage: expect <hash>.<field> ==|!= <value>")
+	}
+	return parseCondition(step, args, "==", "!=")
+}
+
+// parseCondition parses '<hash>.<field> <op> <value>'. The hash name ends at
+// the first dot, so settings.alarm.enabled is field alarm.enabled of settings.
+func parseCondition(step *scriptStep, args []string, ops ...string) error {
+	hash, field, ok := strings.Cut(args[0], ".")
+	if !ok || hash == "" || field == "" {
+		return fmt.Errorf("'%s' is not <hash>.<field>", args[0])
+	}
+	if !slices.Contains(ops, args[1]) {
+		return fmt.Errorf("unsupported operator '%s' (use %s)", args[1], strings.Join(ops, " or "))
+	}
+	step.Hash, step.Field, step.Op, step.Value = hash, field, args[1], args[2]
+	return nil
+}
+
+// run executes the steps in order. After a failed step the remaining steps
+// are skipped unless the step was preceded by 'on-error continue'.
+func (r *scriptRunner) run(steps []scriptStep) []stepResult {
+	results := make([]stepResult, 0, len(steps))
+	aborted := false
+	for _, step := range steps {
+		if aborted {
+			results = append(results, stepResult{Step: step, Status: "skipped"})
+			continue
+		}
+
+		if !r.structured {
+			fmt.Println(format.Info(fmt.Sprintf("[%d] %s", step.Line, step.Text)))
+		}
+
+		started := lscApp.Now()
+		data, err := r.runStep(step)
+		result := stepResult{Step: step, Status: "passed", Duration: lscApp.Now().Sub(started), Result: data}
+		if err != nil {
+			result.Status = "failed"
+			result.Code = output.Code(err)
+			result.Error = err.Error()
+			aborted = !step.ContinueOnError
+
+			// Failed commands already reported their error
+			if step.Kind != "command" && !r.structured {
+				fmt.Fprintln(os.Stderr, format.Error("Error: "+err.Error()))
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func (r *scriptRunner) runStep(step scriptStep) (interface{}, error) {
+	switch step.Kind {
+	case "command":
+		return r.runCommand(step.Args)
+	case "sleep":
+		time.Sleep(step.Duration)
+		return nil, nil
+	case "wait":
+		return nil, confirm.WaitForFieldValue(context.Background(), lscApp.Redis, step.Hash, step.Field, step.Value, step.Duration)
+	case "expect":
+		value, err := lscApp.Redis.HGet(step.Hash, step.Field)
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return nil, output.Connection("failed to read %s.%s: %w", step.Hash, step.Field, err)
+		}
+		if (value == step.Value) != (step.Op == "==") {
+			return value, fmt.Errorf("expected %s.%s %s '%s', got '%s'", step.Hash, step.Field, step.Op, step.Value, value)
+		}
+		return value, nil
+	}
+	return nil, fmt.Errorf("unknown step '%s'", step.Kind)
+}
+
+// runCommand executes an lsc command. In structured output the command's
+// result envelope is captured and its data returned.
+func (r *scriptRunner) runCommand(args []string) (interface{}, error) {
+	if !r.structured {
+		return nil, r.session.execute(context.Background(), args)
+	}
+
+	var err error
+	printed, captureErr := captureStdout(func() {
+		err = r.session.execute(context.Background(), args)
+	})
+	if captureErr != nil {
+		return nil, captureErr
+	}
+
+	var result output.Result
+	if json.Unmarshal(printed, &result) != nil {
+		// Commands with --output or streaming output print something else
+		return nil, err
+	}
+	return result.Data, err
+}
+
+// captureStdout runs fn with os.Stdout redirected to a temporary file and
+// returns what it printed
+func captureStdout(fn func()) ([]byte, error) {
+	f, err := os.CreateTemp("", "lsc-run-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	stdout := os.Stdout
+	os.Stdout = f
+	fn()
+	os.Stdout = stdout
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(f)
+}
+
+func countSteps(results []stepResult, status string) int {
+	n := 0
+	for _, r := range results {
+		if r.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// scriptData builds the run report
+func scriptData(path string, results []stepResult) map[string]interface{} {
+	steps := make([]map[string]interface{}, 0, len(results))
+	for _, r := range results {
+		step := map[string]interface{}{
+			"line":        r.Step.Line,
+			"step":        r.Step.Text,
+			"status":      r.Status,
+			"duration_ms": r.Duration.Milliseconds(),
+		}
+		if r.Result != nil {
+			step["result"] = r.Result
+		}
+		if r.Error != "" {
+			step["error"] = map[string]interface{}{
+				"code":    r.Code,
+				"message": r.Error,
+			}
+		}
+		steps = append(steps, step)
+	}
+
+	return map[string]interface{}{
+		"script":  path,
+		"passed":  countSteps(results, "passed"),
+		"failed":  countSteps(results, "failed"),
+		"skipped": countSteps(results, "skipped"),
+		"steps":   steps,
+	}
+}
+
+func printScriptReport(results []stepResult) {
+	rows := make([][]string, 0, len(results))
+	for _, r := range results {
+		status := r.Status
+		if r.Code != "" {
+			status = r.Code
+		}
+		rows = append(rows, []string{
+			fmt.Sprintf("%d", r.Step.Line),
+			truncate(r.Step.Text, 50),
+			status,
+			fmt.Sprintf("%.1fs", r.Duration.Seconds()),
+			truncate(r.Error, 60),
+		})
+	}
+
+	fmt.Println()
+	format.PrintTable([]string{"LINE", "STEP", "STATUS", "TIME", "ERROR"}, rows)
+	fmt.Println()
+
+	passed := countSteps(results, "passed")
+	failed := countSteps(results, "failed")
+	skipped := countSteps(results, "skipped")
+	summary := fmt.Sprintf("%d passed, %d failed, %d skipped", passed, failed, skipped)
+	if failed > 0 {
+		fmt.Println(format.Warning(summary))
+	} else {
+		fmt.Println(format.Success(summary))
+	}
+}
+
+// JUnit XML report, one test case per step
+type junitTestSuite struct {
+	XMLName  xml.Name        `xml:"testsuite"`
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Type    string `xml:"type,attr"`
+	Message string `xml:"message,attr"`
+}
+
+func writeJUnit(path, script string, results []stepResult) error {
+	name := strings.TrimSuffix(filepath.Base(script), filepath.Ext(script))
+	suite := junitTestSuite{
+		Name:     name,
+		Tests:    len(results),
+		Failures: countSteps(results, "failed"),
+		Skipped:  countSteps(results, "skipped"),
+	}
+
+	var total time.Duration
+	for _, r := range results {
+		total += r.Duration
+		tc := junitTestCase{
+			Name:      fmt.Sprintf("line %d: %s", r.Step.Line, r.Step.Text),
+			Classname: name,
+			Time:      fmt.Sprintf("%.3f", r.Duration.Seconds()),
+		}
+		switch r.Status {
+		case "failed":
+			tc.Failure = &junitFailure{Type: r.Code, Message: r.Error}
+		case "skipped":
+			tc.Skipped = &struct{}{}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	suite.Time = fmt.Sprintf("%.3f", total.Seconds())
+
+	data, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append([]byte(xml.Header), append(data, '\n')...), 0644)
+}
+
+func init() {
+	runCmd.Flags().StringVar(&runJUnit, "junit", "", "Write the report as JUnit XML to this file")
+	rootCmd.AddCommand(runCmd)
+}
@@ -0,0 +1,435 @@
+package lsc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"librescoot/lsc/cmd/lsc/diag"
+	"librescoot/lsc/internal/output"
+	"librescoot/lsc/internal/redis"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveListen string
+	serveToken  string
+)
+
+// serveChannels are streamed by /api/stream when no channel is requested
+var serveChannels = []string{
+	"vehicle", "engine-ecu", "battery:0", "battery:1", "gps",
+	"power-manager", "alarm", "internet", "modem", "settings",
+}
+
+const (
+	// serveKeepAlive is the interval of comments that keep idle streams open
+	serveKeepAlive = 15 * time.Second
+	// maxRequestBody limits the size of request bodies
+	maxRequestBody = 64 << 10
+)
+
+// apiRoute maps an endpoint to the lsc command it runs. Path values are
+// appended after -- so they cannot be taken for flags.
+type apiRoute struct {
+	pattern string
+	command string
+	values  []string
+}
+
+var apiRoutes = []apiRoute{
+	{"GET /api/status", "status", nil},
+	{"GET /api/batteries", "diag battery", nil},
+	{"GET /api/batteries/{id}", "diag battery", []string{"id"}},
+	{"GET /api/faults", "diag faults", nil},
+	{"GET /api/gps", "gps status", nil},
+	{"GET /api/versions", "diag version", nil},
+	{"GET /api/settings", "settings list", nil},
+	{"GET /api/settings/{key}", "settings get", []string{"key"}},
+	{"DELETE /api/settings/{key}", "settings del", []string{"key"}},
+	{"POST /api/lock", "lock", nil},
+	{"POST /api/unlock", "unlock", nil},
+	{"POST /api/open", "open", nil},
+	{"POST /api/blinkers/{state}", "diag blinkers", []string{"state"}},
+	{"POST /api/horn/{state}", "diag horn", []string{"state"}},
+	{"POST /api/led/cue/{cue}", "led cue", []string{"cue"}},
+	{"POST /api/led/fade/{channel}/{fade}", "led fade", []string{"channel", "fade"}},
+}
+
+// apiQueryFlags are the query parameters passed on to commands as flags, on
+// the routes whose command has the flag
+var apiQueryFlags = map[string]string{
+	"timeout":  "command-timeout",
+	"no-block": "no-block",
+	"fields":   "fields",
+}
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve an HTTP/JSON API for lsc commands",
+	Long: `Serve a REST API that runs lsc commands over one Redis connection (and SSH
+tunnel), plus a Server-Sent Events stream of pub/sub channels and fault events.
+
+Every response is the JSON result envelope of the command (see --json). The
+HTTP status follows the error code: 400 invalid_argument, 401 unauthorized,
+403 permission_denied, 409 rejected, 412 precondition_failed, 502 connection,
+504 timeout, 500 anything else. Commands run one at a time; control commands
+wait for confirmation like on the command line, unless the client disconnects,
+which cancels its command. Start it with --read-only to
+serve the read endpoints only.
+
+  GET    /api/status                     lsc status
+  GET    /api/batteries[/{id}]           lsc diag battery [id]
+  GET    /api/faults                     lsc diag faults
+  GET    /api/gps                        lsc gps status
+  GET    /api/versions                   lsc diag version
+  GET    /api/settings[/{key}]           lsc settings list / get <key>
+  PUT    /api/settings/{key}             lsc settings set <key> <value>
+                                         with body {"value": "..."}
+  DELETE /api/settings/{key}             lsc settings del <key>
+  POST   /api/lock, /api/unlock, /api/open
+  POST   /api/blinkers/{off|left|right|both}
+  POST   /api/horn/{on|off}
+  POST   /api/led/cue/{cue}
+  POST   /api/led/fade/{channel}/{fade}
+  GET    /api/stream                     Server-Sent Events
+
+Query parameters: timeout=<duration> overrides the confirmation timeout,
+no-block=true does not wait for lock/unlock/open, fields=<paths> selects fields.
+Other routes answer no-block with 400.
+
+/api/stream sends 'channel' events for pub/sub messages, in the format of
+'lsc watch --json', and 'fault' events for new entries of events:faults, in
+the format of 'lsc diag events --json'. Select channels with
+channel=<name> (repeatable or comma-separated) and turn fault events off
+with faults=false. Fault events carry their stream ID, so reconnecting
+clients resume after the last event they received.
+
+Requests need the token as 'Authorization: Bearer <token>' or, for clients
+that cannot set headers such as EventSource, as token=<token>. Without
+--token or LSC_SERVE_TOKEN a random token is generated and printed.
+
+Examples:
+  lsc serve
+  lsc --ssh deep-blue serve --listen :8080 --token secret
+  curl -H "Authorization: Bearer secret" localhost:8080/api/status
+  curl -X POST -H "Authorization: Bearer secret" localhost:8080/api/unlock
+  curl -N "localhost:8080/api/stream?channel=vehicle&token=secret"`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if sessionActive {
+			return output.InvalidArgument("serve cannot run inside an lsc shell or script")
+		}
+
+		// Flags are reset by every command the server runs
+		listen, token := serveListen, serveToken
+		if token == "" {
+			token = os.Getenv("LSC_SERVE_TOKEN")
+		}
+		generated := token == ""
+		if generated {
+			var err error
+			if token, err = randomToken(); err != nil {
+				return fmt.Errorf("failed to generate token: %w", err)
+			}
+		}
+
+		listener, err := net.Listen("tcp", listen)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", listen, err)
+		}
+
+		defer lscApp.Output.Restore(lscApp.Output.Save())
+		session, end := startSession(cmd)
+		defer end()
+		// Commands print their result envelope, which becomes the response
+		sessionOutput = "json"
+
+		// Cancelled on shutdown to end open streams
+		ctx, cancel := context.WithCancel(cmd.Context())
+		defer cancel()
+
+		api := &apiServer{session: session, token: token}
+		server := &http.Server{
+			Handler:           api.routes(),
+			ReadHeaderTimeout: 10 * time.Second,
+			BaseContext:       func(net.Listener) context.Context { return ctx },
+		}
+
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(sigChan)
+		go func() {
+			<-sigChan
+			cancel()
+			shutdownCtx, done := context.WithTimeout(cmd.Context(), 5*time.Second)
+			defer done()
+			server.Shutdown(shutdownCtx)
+		}()
+
+		fmt.Fprintf(os.Stderr, "Serving lsc API for %s on http://%s\n", connectionTarget(), listener.Addr())
+		if generated {
+			fmt.Fprintf(os.Stderr, "Token: %s\n", token)
+		}
+
+		if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	},
+}
+
+// apiServer handles API requests on a command session
+type apiServer struct {
+	session *commandSession
+	token   string
+
+	// mu serializes commands, which share the global flag and output state
+	mu sync.Mutex
+}
+
+func (s *apiServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	for _, route := range apiRoutes {
+		mux.HandleFunc(route.pattern, func(w http.ResponseWriter, r *http.Request) {
+			args := strings.Fields(route.command)
+			values := make([]string, 0, len(route.values))
+			for _, name := range route.values {
+				values = append(values, r.PathValue(name))
+			}
+			s.runCommand(w, r, args, values)
+		})
+	}
+	mux.HandleFunc("PUT /api/settings/{key}", s.setSetting)
+	mux.HandleFunc("GET /api/stream", s.stream)
+	return s.authenticate(mux)
+}
+
+// authenticate rejects requests without the server token
+func (s *apiServer) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			token = r.URL.Query().Get("token")
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeAPIError(w, http.StatusUnauthorized, "unauthorized", "missing or invalid token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// setSetting handles PUT /api/settings/{key}
+func (s *apiServer) setSetting(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Value *string `json:"value"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody)).Decode(&body); err != nil || body.Value == nil {
+		writeAPIError(w, http.StatusBadRequest, "invalid_argument", `body must be a JSON object like {"value": "..."}`)
+		return
+	}
+	s.runCommand(w, r, []string{"settings", "set"}, []string{r.PathValue("key"), *body.Value})
+}
+
+// runCommand executes an lsc command and responds with its result envelope
+func (s *apiServer) runCommand(w http.ResponseWriter, r *http.Request, args, values []string) {
+	query := r.URL.Query()
+	cmd, _, _ := rootCmd.Find(args)
+	for param, flag := range apiQueryFlags {
+		if value := query.Get(param); value != "" {
+			if cmd == nil || cmd.Flag(flag) == nil {
+				writeAPIError(w, http.StatusBadRequest, "invalid_argument", fmt.Sprintf("%s does not take the %s parameter", r.URL.Path, param))
+				return
+			}
+			args = append(args, "--"+flag+"="+value)
+		}
+	}
+	args = append(append(args, "--"), values...)
+
+	started := lscApp.Now()
+	result, err := s.execute(r.Context(), args)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, "error", err.Error())
+		return
+	}
+
+	status := http.StatusOK
+	if result.Error != nil {
+		status = apiStatus(result.Error.Code)
+	}
+	writeJSON(w, status, result)
+	fmt.Fprintf(os.Stderr, "%s %s %s %d %s\n", started.Format("15:04:05"), r.Method, r.URL.Path, status, lscApp.Now().Sub(started).Round(time.Millisecond))
+}
+
+// execute runs one command on the session and returns the envelope it printed.
+// The command is cancelled when ctx ends, e.g. because the client went away.
+func (s *apiServer) execute(ctx context.Context, args []string) (output.Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result output.Result
+	if err := ctx.Err(); err != nil {
+		// The client left while waiting for another command
+		return result, err
+	}
+	printed, err := captureStdout(func() {
+		s.session.execute(ctx, args)
+	})
+	if err != nil {
+		return result, err
+	}
+	if err := json.Unmarshal(printed, &result); err != nil {
+		return result, fmt.Errorf("unexpected output of '%s'", strings.Join(args, " "))
+	}
+	return result, nil
+}
+
+// stream sends pub/sub messages and fault events as Server-Sent Events
+func (s *apiServer) stream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeAPIError(w, http.StatusInternalServerError, "error", "streaming is not supported")
+		return
+	}
+
+	query := r.URL.Query()
+	var channels []string
+	for _, value := range query["channel"] {
+		for _, channel := range strings.Split(value, ",") {
+			if channel = strings.TrimSpace(channel); channel != "" {
+				channels = append(channels, channel)
+			}
+		}
+	}
+	if len(channels) == 0 {
+		channels = serveChannels
+	}
+
+	ctx := r.Context()
+	pubsub := lscApp.Redis.Subscribe(ctx, channels...)
+	defer pubsub.Close()
+	// Wait for the subscription, so connection errors can still be reported
+	if _, err := pubsub.Receive(ctx); err != nil {
+		writeAPIError(w, apiStatus(output.Code(err)), output.Code(err), fmt.Sprintf("failed to subscribe: %v", err))
+		return
+	}
+	messages := pubsub.Channel()
+
+	faults := make(chan redis.XMessage, 16)
+	if query.Get("faults") != "false" {
+		lastID := r.Header.Get("Last-Event-ID")
+		if lastID == "" {
+			lastID = "$"
+		}
+		go lscApp.Redis.FollowStream(ctx, "events:faults", lastID, faults)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(serveKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		var err error
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-messages:
+			err = writeEvent(w, "", "channel", watchEvent(msg.Channel, msg.Payload))
+		case msg := <-faults:
+			err = writeEvent(w, msg.ID, "fault", diag.EventData(msg))
+		case <-keepAlive.C:
+			_, err = io.WriteString(w, ": keep-alive\n\n")
+		}
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// writeEvent writes one Server-Sent Event with data as JSON
+func writeEvent(w io.Writer, id, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+	return err
+}
+
+// apiStatus returns the HTTP status for an error code of the result envelope
+func apiStatus(code string) int {
+	switch code {
+	case "invalid_argument":
+		return http.StatusBadRequest
+	case "unauthorized":
+		return http.StatusUnauthorized
+	case "rejected":
+		return http.StatusConflict
+	case "precondition_failed":
+		return http.StatusPreconditionFailed
+	case "permission_denied":
+		return http.StatusForbidden
+	case "connection":
+		return http.StatusBadGateway
+	case "timeout":
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+// writeAPIError responds with an error envelope for failures of the server itself
+func writeAPIError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, output.Result{
+		Command: "serve",
+		Status:  "error",
+		Error:   &output.ErrorInfo{Code: code, Message: message},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// randomToken returns a random hex token
+func randomToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveListen, "listen", "localhost:8080", "Address to listen on (host:port)")
+	serveCmd.Flags().StringVar(&serveToken, "token", "", "Bearer token required by every request (env: LSC_SERVE_TOKEN)")
+
+	rootCmd.AddCommand(serveCmd)
+}
@@ -0,0 +1,161 @@
+package lsc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"librescoot/lsc/internal/output"
+	"librescoot/lsc/internal/redis"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// newAPIServer serves the API for srv like 'lsc serve --token secret'
+func newAPIServer(t *testing.T, srv *miniredis.Miniredis) *httptest.Server {
+	t.Helper()
+	client := redis.NewClient(srv.Addr())
+	lscApp.Redis = client
+	sessionActive, sessionOutput = true, "json"
+	t.Cleanup(func() {
+		sessionActive, sessionOutput = false, ""
+		lscApp.Redis = nil
+		client.Close()
+	})
+
+	api := &apiServer{session: &commandSession{flags: map[string]string{"redis-addr": srv.Addr()}}, token: "secret"}
+	server := httptest.NewServer(api.routes())
+	t.Cleanup(server.Close)
+	return server
+}
+
+// apiRequest sends a request with the token and returns the status and envelope
+func apiRequest(t *testing.T, server *httptest.Server, method, path string) (int, output.Result) {
+	t.Helper()
+	req, err := http.NewRequest(method, server.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	var envelope output.Result
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		t.Fatalf("%s %s: invalid response: %v", method, path, err)
+	}
+	return resp.StatusCode, envelope
+}
+
+func TestServeToken(t *testing.T) {
+	server := newAPIServer(t, newScooter(t))
+
+	for _, path := range []string{"/api/status", "/api/status?token=wrong"} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized || resp.Header.Get("WWW-Authenticate") != "Bearer" {
+			t.Errorf("%s: status %d", path, resp.StatusCode)
+		}
+	}
+
+	// EventSource clients pass the token in the query
+	resp, err := http.Get(server.URL + "/api/status?token=secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("token parameter: status %d", resp.StatusCode)
+	}
+}
+
+func TestServeRoutes(t *testing.T) {
+	srv := newScooter(t)
+	server := newAPIServer(t, srv)
+
+	status, envelope := apiRequest(t, server, "GET", "/api/status")
+	if status != http.StatusOK || envelope.Command != "status" {
+		t.Fatalf("status: %d %+v", status, envelope)
+	}
+	if got := lookup(t, envelope.Data.(map[string]interface{}), "vehicle.state"); got != "parked" {
+		t.Errorf("vehicle.state = %v, want parked", got)
+	}
+
+	status, envelope = apiRequest(t, server, "GET", "/api/settings/alarm.honk")
+	if data, ok := envelope.Data.(map[string]interface{}); status != http.StatusOK || !ok || data["value"] != "true" {
+		t.Errorf("settings get: %d %+v", status, envelope)
+	}
+
+	status, _ = apiRequest(t, server, "POST", "/api/lock?no-block=true")
+	if status != http.StatusOK {
+		t.Errorf("lock: status %d", status)
+	}
+	if got, _ := srv.List("scooter:state"); len(got) != 1 || got[0] != "lock" {
+		t.Errorf("scooter:state = %v, want [lock]", got)
+	}
+	srv.Del("scooter:state")
+
+	// Errors map onto HTTP statuses
+	if status, envelope := apiRequest(t, server, "POST", "/api/blinkers/sideways"); status != http.StatusBadRequest || envelope.Error.Code != "invalid_argument" {
+		t.Errorf("invalid blinker state: %d %+v", status, envelope.Error)
+	}
+}
+
+func TestServeQueryParameters(t *testing.T) {
+	srv := newScooter(t)
+	server := newAPIServer(t, srv)
+
+	// no-block only goes to commands that have it
+	status, envelope := apiRequest(t, server, "GET", "/api/status?no-block=true")
+	if status != http.StatusBadRequest || envelope.Command != "serve" {
+		t.Errorf("status with no-block: %d %+v", status, envelope)
+	}
+	assertContains(t, envelope.Error.Message, "/api/status does not take the no-block parameter")
+
+	if status, envelope := apiRequest(t, server, "POST", "/api/lock?timeout=soon"); status != http.StatusBadRequest {
+		t.Errorf("invalid timeout: %d %+v", status, envelope.Error)
+	}
+
+	// Nothing confirms the lock, so the timeout ends it
+	start := time.Now()
+	status, envelope = apiRequest(t, server, "POST", "/api/lock?timeout=200ms")
+	if status != http.StatusGatewayTimeout || envelope.Error.Code != "timeout" {
+		t.Errorf("lock with timeout: %d %+v", status, envelope.Error)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("lock with timeout=200ms took %s", elapsed)
+	}
+}
+
+func TestServeCancelsCommandOfGoneClient(t *testing.T) {
+	srv := newScooter(t)
+	server := newAPIServer(t, srv)
+
+	// The client gives up on a lock nothing confirms
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "POST", server.URL+"/api/lock", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	if resp, err := server.Client().Do(req); err == nil {
+		resp.Body.Close()
+		t.Fatalf("lock answered with %d before the client gave up", resp.StatusCode)
+	}
+
+	// The lock is cancelled instead of holding up the next request for its
+	// 10s confirmation timeout
+	start := time.Now()
+	if status, _ := apiRequest(t, server, "GET", "/api/status"); status != http.StatusOK {
+		t.Errorf("status: %d", status)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("status waited %s for the abandoned lock", elapsed)
+	}
+}
@@ -1,114 +1,99 @@
 package service
 
 import (
-	"encoding/json"
 	"fmt"
-	"os/exec"
 	"strings"
 
+	"librescoot/lsc/internal/app"
 	"librescoot/lsc/internal/format"
+	"librescoot/lsc/internal/runner"
 
 	"github.com/spf13/cobra"
 )
 
-func init() {
-	ServiceCmd.AddCommand(listCmd)
-}
-
-var listCmd = &cobra.Command{
-	Use:   "list",
-	Short: "List LibreScoot services and their status",
-	Long:  `List all LibreScoot systemd services with their current status.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		// All LibreScoot services (MDB + DBC)
-		// User knows which ones exist on their platform
-		services := []string{
-			// Core infrastructure (both MDB and DBC)
-			"redis",
-			// MDB-only services
-			"librescoot-vehicle",
-			"librescoot-battery",
-			"librescoot-ecu",
-			"librescoot-modem",
-			"librescoot-alarm",
-			"librescoot-settings",
-			"librescoot-keycard",
-			"librescoot-boot-led",
-			"librescoot-bluetooth",
-			"librescoot-ums",
-			"librescoot-pm",
-			"librescoot-netconfig",
-			"radio-gaga",
-			// DBC-only services
-			"scootui",
-			"valhalla",
-			"dbc-backlight",
-			"librescoot-brightness",
-			// Shared services (on both MDB and DBC)
-			"librescoot-onboot",
-			"librescoot-update",
-			"librescoot-version",
-		}
-
-		if *JSONOutput {
-			outputJSON(services)
-		} else {
-			outputTable(services)
-		}
-	},
+func newListCmd(a *app.App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List LibreScoot services and their status",
+		Long:  `List all LibreScoot systemd services with their current status.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// All LibreScoot services (MDB + DBC)
+			// User knows which ones exist on their platform
+			services := []string{
+				// Core infrastructure (both MDB and DBC)
+				"redis",
+				// MDB-only services
+				"librescoot-vehicle",
+				"librescoot-battery",
+				"librescoot-ecu",
+				"librescoot-modem",
+				"librescoot-alarm",
+				"librescoot-settings",
+				"librescoot-keycard",
+				"librescoot-boot-led",
+				"librescoot-bluetooth",
+				"librescoot-ums",
+				"librescoot-pm",
+				"librescoot-netconfig",
+				"radio-gaga",
+				// DBC-only services
+				"scootui",
+				"valhalla",
+				"dbc-backlight",
+				"librescoot-brightness",
+				// Shared services (on both MDB and DBC)
+				"librescoot-onboot",
+				"librescoot-update",
+				"librescoot-version",
+			}
+
+			statuses := make([]serviceStatus, 0, len(services))
+			for _, svc := range services {
+				statuses = append(statuses, getServiceStatus(a.Runner, svc))
+			}
+
+			return a.Render(statuses, func() {
+				printStatusTable(statuses)
+			})
+		},
+	}
 }
 
 type serviceStatus struct {
-	Name       string `json:"name"`
-	Active     string `json:"active"`
-	Enabled    string `json:"enabled"`
-	Running    bool   `json:"running"`
-	Status     string `json:"status"`
+	Name    string `json:"name"`
+	Active  string `json:"active"`
+	Enabled string `json:"enabled"`
+	Running bool   `json:"running"`
+	Status  string `json:"status"`
 }
 
-func getServiceStatus(service string) serviceStatus {
+func getServiceStatus(r runner.Runner, service string) serviceStatus {
 	status := serviceStatus{Name: service}
 
 	// Get active state (running/failed/inactive)
-	cmd := exec.Command("systemctl", "is-active", service)
-	output, _ := cmd.Output()
-	status.Active = strings.TrimSpace(string(output))
+	cmd := runner.Command(r, "systemctl", "is-active", service)
+	out, _ := cmd.Output()
+	status.Active = strings.TrimSpace(string(out))
 	status.Running = status.Active == "active"
 
 	// Get enabled state
-	cmd = exec.Command("systemctl", "is-enabled", service)
-	output, _ = cmd.Output()
-	status.Enabled = strings.TrimSpace(string(output))
+	cmd = runner.Command(r, "systemctl", "is-enabled", service)
+	out, _ = cmd.Output()
+	status.Enabled = strings.TrimSpace(string(out))
 
 	// Get one-line status
-	cmd = exec.Command("systemctl", "show", service, "--property=StatusText", "--value")
-	output, _ = cmd.Output()
-	status.Status = strings.TrimSpace(string(output))
+	cmd = runner.Command(r, "systemctl", "show", service, "--property=StatusText", "--value")
+	out, _ = cmd.Output()
+	status.Status = strings.TrimSpace(string(out))
 
 	return status
 }
 
-func outputJSON(services []string) {
-	statuses := make([]serviceStatus, 0, len(services))
-	for _, svc := range services {
-		statuses = append(statuses, getServiceStatus(svc))
-	}
-
-	data, err := json.MarshalIndent(statuses, "", "  ")
-	if err != nil {
-		fmt.Printf("Error marshaling JSON: %v\n", err)
-		return
-	}
-	fmt.Println(string(data))
-}
-
-func outputTable(services []string) {
+func printStatusTable(statuses []serviceStatus) {
 	fmt.Printf("%-30s %-10s %-10s\n", "SERVICE", "STATUS", "ENABLED")
 	fmt.Println(strings.Repeat("─", 52))
 
-	for _, svc := range services {
-		status := getServiceStatus(svc)
-
+	for _, status := range statuses {
 		var statusStr string
 		switch status.Active {
 		case "active":
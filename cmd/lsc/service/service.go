@@ -1,25 +1,17 @@
 package service
 
 import (
-	"librescoot/lsc/internal/redis"
+	"fmt"
+	"sort"
 	"strings"
 
+	"librescoot/lsc/internal/app"
+	"librescoot/lsc/internal/output"
+	"librescoot/lsc/internal/runner"
+
 	"github.com/spf13/cobra"
 )
 
-var RedisClient *redis.Client
-var JSONOutput *bool
-
-// SetRedisClient allows the parent command to inject the Redis client
-func SetRedisClient(client *redis.Client) {
-	RedisClient = client
-}
-
-// SetJSONOutput allows the parent command to inject the JSON output flag
-func SetJSONOutput(jsonOutput *bool) {
-	JSONOutput = jsonOutput
-}
-
 // serviceNameMap maps shorthand names to full service names
 var serviceNameMap = map[string]string{
 	"vehicle":    "librescoot-vehicle",
@@ -41,6 +33,40 @@ var serviceNameMap = map[string]string{
 	"netconfig":  "librescoot-netconfig",
 }
 
+// queueServices maps the scooter's command lists to the shorthand name of
+// the service reading them
+var queueServices = map[string]string{
+	"scooter:state":    "vehicle",
+	"scooter:seatbox":  "vehicle",
+	"scooter:blinker":  "vehicle",
+	"scooter:horn":     "vehicle",
+	"scooter:hardware": "vehicle",
+	"scooter:led:cue":  "vehicle",
+	"scooter:led:fade": "vehicle",
+	"scooter:alarm":    "alarm",
+	"scooter:power":    "pm",
+	"scooter:update":   "update",
+}
+
+// Queues returns the known command lists, sorted
+func Queues() []string {
+	queues := make([]string, 0, len(queueServices))
+	for queue := range queueServices {
+		queues = append(queues, queue)
+	}
+	sort.Strings(queues)
+	return queues
+}
+
+// QueueUnit returns the systemd unit reading a command list, or "" if the
+// list is not known
+func QueueUnit(list string) string {
+	if service, ok := queueServices[list]; ok {
+		return ensureServiceSuffix(service)
+	}
+	return ""
+}
+
 // resolveServiceName maps shorthand names to full service names
 func resolveServiceName(name string) string {
 	// Remove .service suffix if present for mapping
@@ -67,10 +93,59 @@ func ensureServiceSuffix(name string) string {
 	return resolved + ".service"
 }
 
-// ServiceCmd represents the service command
-var ServiceCmd = &cobra.Command{
-	Use:   "service",
-	Short: "Manage systemd services",
-	Long:  `Start, stop, restart, enable, disable, and view logs of LibreScoot systemd services.`,
-	Aliases: []string{"svc"},
+// systemctlEach runs 'systemctl <verb>' for each service, reporting progress
+// line by line. done is the past tense shown on success (e.g. "Started").
+func systemctlEach(a *app.App, verb, done string, services []string) error {
+	results := make([]map[string]interface{}, 0, len(services))
+	var failed []string
+
+	for _, service := range services {
+		serviceName := ensureServiceSuffix(service)
+
+		err := runner.Command(a.Runner, "systemctl", verb, serviceName).Run()
+		if output.ClassOf(err) == output.ClassForbidden {
+			// Denied for this command, so for every service
+			return err
+		}
+		if err != nil {
+			if !a.Structured() {
+				fmt.Printf("Failed to %s %s: %v\n", verb, serviceName, err)
+			}
+			failed = append(failed, serviceName)
+			continue
+		}
+		if !a.Structured() {
+			fmt.Printf("%s %s\n", done, serviceName)
+		}
+		results = append(results, map[string]interface{}{
+			"service": serviceName,
+			"action":  verb,
+		})
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to %s %s", verb, strings.Join(failed, ", "))
+	}
+	return a.Render(results, nil)
+}
+
+// NewCommand creates the service command and its subcommands
+func NewCommand(a *app.App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "service",
+		Short:   "Manage systemd services",
+		Long:    `Start, stop, restart, enable, disable, and view logs of LibreScoot systemd services.`,
+		Aliases: []string{"svc"},
+	}
+	cmd.AddCommand(
+		newDisableCmd(a),
+		newEnableCmd(a),
+		newListCmd(a),
+		newLogsCmd(a),
+		newRestartCmd(a),
+		newStartCmd(a),
+		newStatusCmd(a),
+		newStopCmd(a),
+	)
+	return cmd
 }
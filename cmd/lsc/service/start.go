@@ -1,31 +1,19 @@
 package service
 
 import (
-	"fmt"
-	"os/exec"
+	"librescoot/lsc/internal/app"
 
 	"github.com/spf13/cobra"
 )
 
-func init() {
-	ServiceCmd.AddCommand(startCmd)
-}
-
-var startCmd = &cobra.Command{
-	Use:   "start <service>",
-	Short: "Start a systemd service",
-	Long:  `Start a systemd service. Service name can be with or without .service suffix.`,
-	Args:  cobra.MinimumNArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		for _, service := range args {
-			serviceName := ensureServiceSuffix(service)
-
-			err := exec.Command("systemctl", "start", serviceName).Run()
-			if err != nil {
-				fmt.Printf("Failed to start %s: %v\n", serviceName, err)
-				continue
-			}
-			fmt.Printf("Started %s\n", serviceName)
-		}
-	},
+func newStartCmd(a *app.App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "start <service>",
+		Short: "Start a systemd service",
+		Long:  `Start a systemd service. Service name can be with or without .service suffix.`,
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return systemctlEach(a, "start", "Started", args)
+		},
+	}
 }
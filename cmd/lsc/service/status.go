@@ -1,41 +1,34 @@
 package service
 
 import (
-	"encoding/json"
-	"fmt"
 	"os"
-	"os/exec"
+
+	"librescoot/lsc/internal/app"
+	"librescoot/lsc/internal/runner"
 
 	"github.com/spf13/cobra"
 )
 
-func init() {
-	ServiceCmd.AddCommand(statusCmd)
-}
-
-var statusCmd = &cobra.Command{
-	Use:   "status <service>",
-	Short: "Show detailed status of a systemd service",
-	Long:  `Show detailed status of a systemd service including active state, enabled state, and recent logs. Service name can be with or without .service suffix.`,
-	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		service := args[0]
-		serviceName := ensureServiceSuffix(service)
+func newStatusCmd(a *app.App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status <service>",
+		Short: "Show detailed status of a systemd service",
+		Long:  `Show detailed status of a systemd service including active state, enabled state, and recent logs. Service name can be with or without .service suffix.`,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			serviceName := ensureServiceSuffix(args[0])
 
-		if *JSONOutput {
-			status := getServiceStatus(serviceName)
-			data, err := json.MarshalIndent(status, "", "  ")
-			if err != nil {
-				fmt.Printf("Error marshaling JSON: %v\n", err)
-				return
+			if a.Structured() {
+				return a.Render(getServiceStatus(a.Runner, serviceName), nil)
 			}
-			fmt.Println(string(data))
-		} else {
-			// Use systemctl status for detailed output
-			cmd := exec.Command("systemctl", "status", serviceName)
-			cmd.Stdout = os.Stdout
-			cmd.Stderr = os.Stderr
-			cmd.Run()
-		}
-	},
+
+			// Use systemctl status for detailed output. It exits non-zero for
+			// stopped services, which is not an error here.
+			systemctl := runner.Command(a.Runner, "systemctl", "status", serviceName)
+			systemctl.Stdout = os.Stdout
+			systemctl.Stderr = os.Stderr
+			systemctl.Run()
+			return nil
+		},
+	}
 }
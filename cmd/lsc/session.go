@@ -0,0 +1,175 @@
+package lsc
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var (
+	// sessionActive is set while 'lsc shell' or 'lsc run' executes commands on
+	// the connection opened for the session
+	sessionActive bool
+
+	// sessionOutput is the output format of commands run in the session
+	sessionOutput string
+)
+
+// commandSession runs lsc commands one after another on one connection
+type commandSession struct {
+	// flags are the global flags given when the session was started
+	flags map[string]string
+	// interrupts, if set, cancel the context of the running command
+	interrupts <-chan os.Signal
+}
+
+// startSession records the global flags and output format of cmd for the
+// commands it runs, and returns a function that ends the session
+func startSession(cmd *cobra.Command) (*commandSession, func()) {
+	s := &commandSession{flags: make(map[string]string)}
+	cmd.Root().PersistentFlags().VisitAll(func(f *pflag.Flag) {
+		switch f.Name {
+		case "json", "output", "fields":
+			// Output selection is session state, fields apply to one command only
+			return
+		}
+		if f.Changed {
+			s.flags[f.Name] = f.Value.String()
+		}
+	})
+
+	prevActive, prevOutput := sessionActive, sessionOutput
+	sessionActive = true
+	sessionOutput = resolvedOutput
+	return s, func() {
+		sessionActive, sessionOutput = prevActive, prevOutput
+	}
+}
+
+// execute runs one lsc command on the open connection. Flags are reset first
+// so values given to one command do not leak into the next, and each command
+// gets its own context below ctx, cancelled by an interrupt.
+func (s *commandSession) execute(ctx context.Context, args []string) error {
+	resetFlags(rootCmd)
+	for name, value := range s.flags {
+		rootCmd.PersistentFlags().Set(name, value)
+	}
+	commandStarted = false
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if s.interrupts != nil {
+		// Drop interrupts that arrived while no command was running
+		for len(s.interrupts) > 0 {
+			<-s.interrupts
+		}
+		go func() {
+			select {
+			case <-s.interrupts:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+	setContext(rootCmd, ctx)
+
+	rootCmd.SetArgs(args)
+	cmd, err := rootCmd.ExecuteC()
+	if err != nil {
+		err = reportError(cmd, args, err)
+	}
+	return err
+}
+
+// setContext gives cmd and its subcommands ctx. Cobra only passes the
+// context down to commands that have none yet, so a later command in the
+// session would otherwise keep the first one's.
+func setContext(cmd *cobra.Command, ctx context.Context) {
+	cmd.SetContext(ctx)
+	for _, child := range cmd.Commands() {
+		setContext(child, ctx)
+	}
+}
+
+// resetFlags restores every flag of cmd and its subcommands to its default
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			var values []string
+			if def := strings.Trim(f.DefValue, "[]"); def != "" {
+				values = strings.Split(def, ",")
+			}
+			slice.Replace(values)
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, child := range cmd.Commands() {
+		resetFlags(child)
+	}
+}
+
+// splitArgs splits a command line into arguments like a POSIX shell: single
+// quotes are literal, and a backslash escapes the next character outside
+// single quotes
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	escaped := false
+	var quote rune
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+			inArg = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if escaped {
+		return nil, fmt.Errorf("line ends with a backslash")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
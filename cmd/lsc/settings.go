@@ -1,191 +1,212 @@
 package lsc
 
 import (
-	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
 	"sort"
 
 	"librescoot/lsc/internal/format"
+	"librescoot/lsc/internal/output"
+	"librescoot/lsc/internal/redis"
 
 	"github.com/spf13/cobra"
 )
 
-// SettingInfo describes a known setting key
+// SettingInfo describes a known setting key and the values it accepts
 type SettingInfo struct {
 	Key         string
 	Description string
 	Default     string
 	Service     string
+	Type        SettingType
+	Values      []string      // allowed values of an enum
+	Range       *SettingRange // bounds of an int or float, if any
+	Unit        string        // unit of an int or float, e.g. "s"
 }
 
+// indicatorModes are the visibility modes of the dashboard status indicators
+var indicatorModes = []string{"always", "active-or-error", "error", "never"}
+
 // knownSettings is a registry of all LibreScoot settings (using dot notation)
 var knownSettings = []SettingInfo{
 	// Alarm settings (alarm-service)
-	{Key: "alarm.enabled", Description: "Enable/disable alarm system", Default: "false", Service: "alarm-service"},
-	{Key: "alarm.honk", Description: "Enable horn during alarm trigger", Default: "false", Service: "alarm-service"},
-	{Key: "alarm.duration", Description: "Duration in seconds for alarm sound", Default: "60", Service: "alarm-service"},
+	{Key: "alarm.enabled", Description: "Enable/disable alarm system", Default: "false", Service: "alarm-service", Type: SettingBool},
+	{Key: "alarm.honk", Description: "Enable horn during alarm trigger", Default: "false", Service: "alarm-service", Type: SettingBool},
+	{Key: "alarm.duration", Description: "Duration in seconds for alarm sound", Default: "60", Service: "alarm-service", Type: SettingInt, Range: &SettingRange{1, 3600}, Unit: "s"},
 
 	// Power management settings (pm-service)
-	{Key: "hibernation-timer", Description: "Hibernation timeout in seconds", Default: "900", Service: "pm-service"},
+	{Key: "hibernation-timer", Description: "Hibernation timeout in seconds", Default: "900", Service: "pm-service", Type: SettingInt, Range: &SettingRange{0, 604800}, Unit: "s"},
 
 	// Update service settings (update-service)
-	{Key: "updates.mdb.method", Description: "Update method for MDB (delta or full)", Default: "full", Service: "update-service"},
-	{Key: "updates.mdb.channel", Description: "Release channel for MDB (stable/testing/nightly)", Default: "nightly", Service: "update-service"},
-	{Key: "updates.mdb.check-interval", Description: "Time between update checks for MDB (hours, 0=never)", Default: "6", Service: "update-service"},
-	{Key: "updates.mdb.github-releases-url", Description: "GitHub Releases API endpoint for MDB", Default: "https://api.github.com/repos/librescoot/librescoot/releases", Service: "update-service"},
-	{Key: "updates.mdb.dry-run", Description: "Enable dry-run mode for MDB updates (no reboot)", Default: "false", Service: "update-service"},
-	{Key: "updates.dbc.method", Description: "Update method for DBC (delta or full)", Default: "full", Service: "update-service"},
-	{Key: "updates.dbc.channel", Description: "Release channel for DBC (stable/testing/nightly)", Default: "nightly", Service: "update-service"},
-	{Key: "updates.dbc.check-interval", Description: "Time between update checks for DBC (hours, 0=never)", Default: "6", Service: "update-service"},
-	{Key: "updates.dbc.github-releases-url", Description: "GitHub Releases API endpoint for DBC", Default: "https://api.github.com/repos/librescoot/librescoot/releases", Service: "update-service"},
-	{Key: "updates.dbc.dry-run", Description: "Enable dry-run mode for DBC updates (no reboot)", Default: "false", Service: "update-service"},
+	{Key: "updates.mdb.method", Description: "Update method for MDB (delta or full)", Default: "full", Service: "update-service", Type: SettingEnum, Values: []string{"delta", "full"}},
+	{Key: "updates.mdb.channel", Description: "Release channel for MDB (stable/testing/nightly)", Default: "nightly", Service: "update-service", Type: SettingEnum, Values: []string{"stable", "testing", "nightly"}},
+	{Key: "updates.mdb.check-interval", Description: "Time between update checks for MDB (hours, 0=never)", Default: "6", Service: "update-service", Type: SettingInt, Range: &SettingRange{0, 8760}, Unit: "h"},
+	{Key: "updates.mdb.github-releases-url", Description: "GitHub Releases API endpoint for MDB", Default: "https://api.github.com/repos/librescoot/librescoot/releases", Service: "update-service", Type: SettingURL},
+	{Key: "updates.mdb.dry-run", Description: "Enable dry-run mode for MDB updates (no reboot)", Default: "false", Service: "update-service", Type: SettingBool},
+	{Key: "updates.dbc.method", Description: "Update method for DBC (delta or full)", Default: "full", Service: "update-service", Type: SettingEnum, Values: []string{"delta", "full"}},
+	{Key: "updates.dbc.channel", Description: "Release channel for DBC (stable/testing/nightly)", Default: "nightly", Service: "update-service", Type: SettingEnum, Values: []string{"stable", "testing", "nightly"}},
+	{Key: "updates.dbc.check-interval", Description: "Time between update checks for DBC (hours, 0=never)", Default: "6", Service: "update-service", Type: SettingInt, Range: &SettingRange{0, 8760}, Unit: "h"},
+	{Key: "updates.dbc.github-releases-url", Description: "GitHub Releases API endpoint for DBC", Default: "https://api.github.com/repos/librescoot/librescoot/releases", Service: "update-service", Type: SettingURL},
+	{Key: "updates.dbc.dry-run", Description: "Enable dry-run mode for DBC updates (no reboot)", Default: "false", Service: "update-service", Type: SettingBool},
 
 	// Network settings
-	{Key: "cellular.apn", Description: "Cellular APN string", Default: "", Service: "modem-service"},
+	{Key: "cellular.apn", Description: "Cellular APN string", Default: "", Service: "modem-service", Type: SettingString},
 
 	// Dashboard settings (scootui)
-	{Key: "dashboard.show-raw-speed", Description: "Show raw uncorrected speed from ECU", Default: "false", Service: "scootui"},
-	{Key: "dashboard.show-gps", Description: "GPS indicator visibility (always/active-or-error/error/never)", Default: "error", Service: "scootui"},
-	{Key: "dashboard.show-bluetooth", Description: "Bluetooth indicator visibility (always/active-or-error/error/never)", Default: "active-or-error", Service: "scootui"},
-	{Key: "dashboard.show-cloud", Description: "Cloud indicator visibility (always/active-or-error/error/never)", Default: "error", Service: "scootui"},
-	{Key: "dashboard.show-internet", Description: "Internet indicator visibility (always/active-or-error/error/never)", Default: "always", Service: "scootui"},
-	{Key: "dashboard.map.type", Description: "Map tile source (online/offline)", Default: "offline", Service: "scootui"},
-	{Key: "dashboard.map.render-mode", Description: "Map rendering mode (vector/raster)", Default: "raster", Service: "scootui"},
-	{Key: "dashboard.theme", Description: "UI theme (light/dark/auto)", Default: "dark", Service: "scootui"},
-	{Key: "dashboard.mode", Description: "Default screen mode (speedometer/navigation)", Default: "speedometer", Service: "scootui"},
-	{Key: "dashboard.valhalla-url", Description: "Valhalla routing service endpoint", Default: "http://localhost:8002/", Service: "scootui"},
+	{Key: "dashboard.show-raw-speed", Description: "Show raw uncorrected speed from ECU", Default: "false", Service: "scootui", Type: SettingBool},
+	{Key: "dashboard.show-gps", Description: "GPS indicator visibility (always/active-or-error/error/never)", Default: "error", Service: "scootui", Type: SettingEnum, Values: indicatorModes},
+	{Key: "dashboard.show-bluetooth", Description: "Bluetooth indicator visibility (always/active-or-error/error/never)", Default: "active-or-error", Service: "scootui", Type: SettingEnum, Values: indicatorModes},
+	{Key: "dashboard.show-cloud", Description: "Cloud indicator visibility (always/active-or-error/error/never)", Default: "error", Service: "scootui", Type: SettingEnum, Values: indicatorModes},
+	{Key: "dashboard.show-internet", Description: "Internet indicator visibility (always/active-or-error/error/never)", Default: "always", Service: "scootui", Type: SettingEnum, Values: indicatorModes},
+	{Key: "dashboard.map.type", Description: "Map tile source (online/offline)", Default: "offline", Service: "scootui", Type: SettingEnum, Values: []string{"online", "offline"}},
+	{Key: "dashboard.map.render-mode", Description: "Map rendering mode (vector/raster)", Default: "raster", Service: "scootui", Type: SettingEnum, Values: []string{"vector", "raster"}},
+	{Key: "dashboard.theme", Description: "UI theme (light/dark/auto)", Default: "dark", Service: "scootui", Type: SettingEnum, Values: []string{"light", "dark", "auto"}},
+	{Key: "dashboard.mode", Description: "Default screen mode (speedometer/navigation)", Default: "speedometer", Service: "scootui", Type: SettingEnum, Values: []string{"speedometer", "navigation"}},
+	{Key: "dashboard.valhalla-url", Description: "Valhalla routing service endpoint", Default: "http://localhost:8002/", Service: "scootui", Type: SettingURL},
 
 	// Saved locations (scootui)
 	// Pattern: dashboard.saved-locations.<index>.<field>
 	// Fields: created-at (ISO8601), label (string), last-used-at (ISO8601), latitude (float), longitude (float)
-	{Key: "dashboard.saved-locations.0.created-at", Description: "Creation timestamp for location 0", Default: "", Service: "scootui"},
-	{Key: "dashboard.saved-locations.0.label", Description: "Label for location 0", Default: "", Service: "scootui"},
-	{Key: "dashboard.saved-locations.0.last-used-at", Description: "Last used timestamp for location 0", Default: "", Service: "scootui"},
-	{Key: "dashboard.saved-locations.0.latitude", Description: "Latitude for location 0", Default: "", Service: "scootui"},
-	{Key: "dashboard.saved-locations.0.longitude", Description: "Longitude for location 0", Default: "", Service: "scootui"},
+	{Key: "dashboard.saved-locations.0.created-at", Description: "Creation timestamp for location 0", Default: "", Service: "scootui", Type: SettingTime},
+	{Key: "dashboard.saved-locations.0.label", Description: "Label for location 0", Default: "", Service: "scootui", Type: SettingString},
+	{Key: "dashboard.saved-locations.0.last-used-at", Description: "Last used timestamp for location 0", Default: "", Service: "scootui", Type: SettingTime},
+	{Key: "dashboard.saved-locations.0.latitude", Description: "Latitude for location 0", Default: "", Service: "scootui", Type: SettingFloat, Range: &SettingRange{-90, 90}, Unit: "°"},
+	{Key: "dashboard.saved-locations.0.longitude", Description: "Longitude for location 0", Default: "", Service: "scootui", Type: SettingFloat, Range: &SettingRange{-180, 180}, Unit: "°"},
 }
 
 var settingsCmd = &cobra.Command{
 	Use:   "settings",
 	Short: "Manage scooter settings",
 	Long:  `View and modify scooter settings stored in Redis.`,
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		// When called without subcommand, show all settings
-		settingsListCmd.Run(cmd, args)
+		return settingsListCmd.RunE(cmd, args)
 	},
 }
 
 var settingsListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all settings",
-	Long:  `Display all known settings. Shows current values from Redis, with unset settings shown as (not set).`,
-	Run: func(cmd *cobra.Command, args []string) {
-		settings, err := redisClient.HGetAll("settings")
+	Long: `Display all known settings with their type and allowed values. Shows current
+values from Redis, with unset settings shown as (not set).`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		settings, err := lscApp.Redis.HGetAll("settings")
 		if err != nil {
-			if JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"error": err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Failed to fetch settings: %v\n"), err)
-			}
-			return
-		}
-
-		if JSONOutput {
-			// For JSON output, merge known settings with current values
-			result := make(map[string]interface{})
-			for _, info := range knownSettings {
-				value, exists := settings[info.Key]
-				if !exists || value == "" {
-					result[info.Key] = nil
-				} else {
-					result[info.Key] = value
-				}
-			}
-			jsonBytes, _ := json.MarshalIndent(result, "", "  ")
-			fmt.Println(string(jsonBytes))
-			return
+			return fmt.Errorf("failed to fetch settings: %w", err)
 		}
 
-		// Show LibreScoot settings
-		format.PrintSection("Settings")
+		// Merge known settings with current values
+		result := make(map[string]settingEntry)
 		for _, info := range knownSettings {
-			value, exists := settings[info.Key]
-			if !exists || value == "" {
-				format.PrintKV(info.Key, format.Dim("(not set)"))
-			} else {
-				format.PrintKV(info.Key, value)
-			}
+			result[info.Key] = newSettingEntry(info, settings[info.Key])
 		}
 
-		// Show any unknown settings that exist in Redis but aren't in our known list
-		unknownKeys := make([]string, 0)
-		for key := range settings {
-			known := false
-			for _, info := range knownSettings {
-				if info.Key == key {
-					known = true
-					break
-				}
-			}
-			if !known && settings[key] != "" {
-				unknownKeys = append(unknownKeys, key)
-			}
+		return lscApp.Render(result, func() {
+			printSettings(settings)
+		})
+	},
+}
+
+// settingEntry is the structured form of a known setting in 'settings list'
+type settingEntry struct {
+	Value   *string       `json:"value"`
+	Valid   bool          `json:"valid"`
+	Default string        `json:"default"`
+	Type    SettingType   `json:"type"`
+	Schema  string        `json:"schema"`
+	Values  []string      `json:"values,omitempty"`
+	Range   *SettingRange `json:"range,omitempty"`
+	Unit    string        `json:"unit,omitempty"`
+}
+
+// newSettingEntry describes a setting and its value; "" is not set
+func newSettingEntry(info SettingInfo, value string) settingEntry {
+	entry := settingEntry{
+		Valid:   true,
+		Default: info.Default,
+		Type:    info.Type,
+		Schema:  info.Schema(),
+		Values:  info.Values,
+		Range:   info.Range,
+		Unit:    info.Unit,
+	}
+	if entry.Type == "" {
+		entry.Type = SettingString
+	}
+	if value != "" {
+		entry.Value = &value
+		entry.Valid = info.Validate(value) == nil
+	}
+	return entry
+}
+
+// printSettings shows known settings followed by any unknown keys found in Redis
+func printSettings(settings map[string]string) {
+	// Show LibreScoot settings
+	format.PrintSection("Settings")
+	for _, info := range knownSettings {
+		value, exists := settings[info.Key]
+		schema := format.Dim("[" + info.Schema() + "]")
+		if !exists || value == "" {
+			format.PrintKV(info.Key, format.Dim("(not set)")+" "+schema)
+		} else if info.Validate(value) != nil {
+			format.PrintKV(info.Key, format.Error(value)+" "+schema)
+		} else {
+			format.PrintKV(info.Key, value+" "+schema)
 		}
+	}
 
-		if len(unknownKeys) > 0 {
-			sort.Strings(unknownKeys)
-			fmt.Println()
-			format.PrintSection("Unknown Settings")
-			for _, key := range unknownKeys {
-				format.PrintKV(key, settings[key])
+	// Show any unknown settings that exist in Redis but aren't in our known list
+	unknownKeys := make([]string, 0)
+	for key := range settings {
+		known := false
+		for _, info := range knownSettings {
+			if info.Key == key {
+				known = true
+				break
 			}
 		}
+		if !known && settings[key] != "" {
+			unknownKeys = append(unknownKeys, key)
+		}
+	}
 
+	if len(unknownKeys) > 0 {
+		sort.Strings(unknownKeys)
 		fmt.Println()
-	},
+		format.PrintSection("Unknown Settings")
+		for _, key := range unknownKeys {
+			format.PrintKV(key, settings[key])
+		}
+	}
+
+	fmt.Println()
 }
 
 var settingsGetCmd = &cobra.Command{
-	Use:   "get <key>",
-	Short: "Get a setting value",
-	Long:  `Retrieve the value of a specific setting.`,
-	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
+	Use:               "get <key>",
+	Short:             "Get a setting value",
+	Long:              `Retrieve the value of a specific setting.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSettingKeys,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		key := args[0]
 
-		value, err := redisClient.HGet("settings", key)
-		if err != nil {
-			if JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"error": err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Failed to get setting '%s': %v\n"), key, err)
-			}
-			return
-		}
-
-		if JSONOutput {
-			output, _ := json.Marshal(map[string]interface{}{
-				"key":   key,
-				"value": value,
-			})
-			fmt.Println(string(output))
-			return
-		}
-
-		if value == "" {
-			fmt.Println(format.Dim("(not set)"))
-			return
+		value, err := lscApp.Redis.HGet("settings", key)
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return fmt.Errorf("failed to get setting '%s': %w", key, err)
 		}
 
-		fmt.Println(value)
+		return lscApp.Render(map[string]interface{}{
+			"key":   key,
+			"value": value,
+		}, func() {
+			if value == "" {
+				fmt.Println(format.Dim("(not set)"))
+				return
+			}
+			fmt.Println(value)
+		})
 	},
 }
 
@@ -207,107 +228,77 @@ Common Settings:
   updates.dbc.check-interval      - Update check interval for DBC (hours, 0=never)
   cellular.apn                    - Cellular APN string
 
+Values of known settings are checked against their type and allowed values, shown
+by 'lsc settings list'; --force sets them anyway. Unknown keys are set as given.
+
 Use 'lsc settings list' to see all available settings and their current values.`,
-	Args: cobra.ExactArgs(2),
-	Run: func(cmd *cobra.Command, args []string) {
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeSettingValue,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		key := args[0]
 		value := args[1]
 
-		// Set the value in Redis hash
-		if err := redisClient.HSet("settings", key, value); err != nil {
-			if JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"error": err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Failed to set setting '%s': %v\n"), key, err)
+		if info, ok := lookupSetting(key); ok && !settingsForce {
+			if err := info.Validate(value); err != nil {
+				return output.InvalidArgument("%w (use --force to set it anyway)", err)
 			}
-			return
+		}
+
+		// Set the value in Redis hash
+		if err := lscApp.Redis.HSet("settings", key, value); err != nil {
+			return fmt.Errorf("failed to set setting '%s': %w", key, err)
 		}
 
 		// Publish the change so services can react
-		ctx := context.Background()
-		if err := redisClient.Publish(ctx, "settings", key); err != nil {
-			if JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"key":     key,
-					"value":   value,
-					"status":  "warning",
-					"message": "Setting updated but publish failed",
-					"error":   err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Warning("Setting updated but publish failed: %v\n"), err)
-			}
-			return
+		ctx := cmd.Context()
+		if err := lscApp.Redis.Publish(ctx, "settings", key); err != nil {
+			return fmt.Errorf("setting updated but publish failed: %w", err)
 		}
 
-		if JSONOutput {
-			output, _ := json.Marshal(map[string]interface{}{
-				"key":    key,
-				"value":  value,
-				"status": "success",
-			})
-			fmt.Println(string(output))
-		} else {
+		return lscApp.Render(map[string]interface{}{
+			"key":   key,
+			"value": value,
+		}, func() {
 			fmt.Println(format.Success(fmt.Sprintf("Setting '%s' = '%s'", key, value)))
-		}
+		})
 	},
 }
 
 var settingsDelCmd = &cobra.Command{
-	Use:   "del <key>",
-	Short: "Delete a setting key",
-	Long:  `Delete a setting key from the settings hash and publish the change.`,
-	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
+	Use:               "del <key>",
+	Short:             "Delete a setting key",
+	Long:              `Delete a setting key from the settings hash and publish the change.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSettingKeys,
+	RunE: func(cmd *cobra.Command, args []string) error {
 		key := args[0]
 
 		// Delete the key from Redis hash
-		if err := redisClient.HDel("settings", key); err != nil {
-			if JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"error": err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Failed to delete setting '%s': %v\n"), key, err)
-			}
-			return
+		if err := lscApp.Redis.HDel("settings", key); err != nil {
+			return fmt.Errorf("failed to delete setting '%s': %w", key, err)
 		}
 
 		// Publish the change so services can react
-		ctx := context.Background()
-		if err := redisClient.Publish(ctx, "settings", key); err != nil {
-			if JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"key":     key,
-					"status":  "warning",
-					"message": "Setting deleted but publish failed",
-					"error":   err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Warning("Setting deleted but publish failed: %v\n"), err)
-			}
-			return
+		ctx := cmd.Context()
+		if err := lscApp.Redis.Publish(ctx, "settings", key); err != nil {
+			return fmt.Errorf("setting deleted but publish failed: %w", err)
 		}
 
-		if JSONOutput {
-			output, _ := json.Marshal(map[string]interface{}{
-				"key":    key,
-				"status": "success",
-			})
-			fmt.Println(string(output))
-		} else {
+		return lscApp.Render(map[string]interface{}{
+			"key":     key,
+			"deleted": true,
+		}, func() {
 			fmt.Println(format.Success(fmt.Sprintf("Setting '%s' deleted", key)))
-		}
+		})
 	},
 }
 
+// settingsForce is the --force flag of settings set
+var settingsForce bool
+
 func init() {
+	settingsSetCmd.Flags().BoolVar(&settingsForce, "force", false, "Set the value even if it does not match the setting's type")
+
 	settingsCmd.AddCommand(settingsListCmd)
 	settingsCmd.AddCommand(settingsGetCmd)
 	settingsCmd.AddCommand(settingsSetCmd)
@@ -0,0 +1,244 @@
+package lsc
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"librescoot/lsc/internal/config"
+	"librescoot/lsc/internal/format"
+	"librescoot/lsc/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffFile        string
+	diffWithProfile string
+	diffWithSSH     string
+)
+
+var settingsDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare the settings with the defaults, a file or another scooter",
+	Long: `Compare the live settings hash with the defaults of the known settings, or with
+an exported settings file (--file), another profile (--with-profile) or another scooter
+reached over SSH (--with-ssh). Only keys that differ are listed, with the default, the
+live value and the other value side by side and one of these statuses:
+
+  changed  the live value differs
+  missing  the key is not set on this scooter
+  extra    the key is only set on this scooter
+  unknown  the key is only set on this scooter and lsc does not know it`,
+	Example: `  lsc settings diff
+  lsc settings diff --file scooter.toml
+  lsc --profile deep-blue settings diff --with-profile bench-1`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sources := 0
+		for _, flag := range []string{diffFile, diffWithProfile, diffWithSSH} {
+			if flag != "" {
+				sources++
+			}
+		}
+		if sources > 1 {
+			return output.InvalidArgument("--file, --with-profile and --with-ssh exclude each other")
+		}
+
+		live, err := lscApp.Redis.HGetAll("settings")
+		if err != nil {
+			return fmt.Errorf("failed to fetch settings: %w", err)
+		}
+
+		against, label := "defaults", ""
+		var other map[string]string
+		switch {
+		case diffFile != "":
+			fileFormat, err := settingsFileFormat(diffFile, "")
+			if err != nil {
+				return err
+			}
+			if other, err = readSettingsFile(diffFile, fileFormat); err != nil {
+				if os.IsNotExist(err) {
+					return output.InvalidArgument("file not found: %s", diffFile)
+				}
+				return err
+			}
+			against, label = "file "+diffFile, filepath.Base(diffFile)
+		case diffWithProfile != "":
+			if other, err = remoteSettings(fleetTarget{Name: diffWithProfile, Flags: []string{"--profile", diffWithProfile}}); err != nil {
+				return err
+			}
+			against, label = "profile "+diffWithProfile, diffWithProfile
+		case diffWithSSH != "":
+			if other, err = remoteSettings(fleetTarget{Name: diffWithSSH, Flags: []string{"--profile", config.NoProfile, "--ssh", diffWithSSH}}); err != nil {
+				return err
+			}
+			against, label = "scooter "+diffWithSSH, diffWithSSH
+		}
+
+		diffs := diffSettings(live, other)
+		return lscApp.Render(map[string]interface{}{
+			"against":     against,
+			"differences": diffs,
+		}, func() {
+			printSettingDiffs(diffs, label)
+		})
+	},
+}
+
+// settingDiff is a key that differs; values are nil where the key is not set
+type settingDiff struct {
+	Key     string  `json:"key"`
+	Status  string  `json:"status"` // changed, missing, extra or unknown
+	Default *string `json:"default"`
+	Live    *string `json:"live"`
+	Other   *string `json:"other,omitempty"`
+}
+
+// diffSettings compares live with other, or with the defaults of the known
+// settings if other is nil
+func diffSettings(live, other map[string]string) []settingDiff {
+	keys := make(map[string]bool)
+	for key := range live {
+		keys[key] = true
+	}
+	for key := range other {
+		keys[key] = true
+	}
+	if other == nil {
+		for _, info := range knownSettings {
+			keys[info.Key] = true
+		}
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	diffs := make([]settingDiff, 0)
+	for _, key := range sorted {
+		d := settingDiff{Key: key}
+		info, known := lookupSetting(key)
+		if known && info.Default != "" {
+			d.Default = stringPtr(info.Default)
+		}
+		if value, ok := live[key]; ok {
+			d.Live = stringPtr(value)
+		}
+
+		// The reference is the other side, or the default
+		reference := d.Default
+		if known && other == nil && reference == nil {
+			reference = stringPtr("")
+		}
+		if other != nil {
+			reference = nil
+			if value, ok := other[key]; ok {
+				d.Other = stringPtr(value)
+				reference = d.Other
+			}
+		}
+
+		switch {
+		case d.Live == nil && reference == nil:
+			continue
+		case d.Live == nil:
+			if other == nil && *reference == "" {
+				// Known without a default, nothing is missing
+				continue
+			}
+			d.Status = "missing"
+		case reference == nil && !known:
+			d.Status = "unknown"
+		case reference == nil:
+			d.Status = "extra"
+		case *d.Live != *reference:
+			d.Status = "changed"
+		default:
+			continue
+		}
+		diffs = append(diffs, d)
+	}
+	return diffs
+}
+
+// remoteSettings reads the settings of another scooter by running
+// 'lsc settings export' against it
+func remoteSettings(target fleetTarget) (map[string]string, error) {
+	result := runFleet([]fleetTarget{target}, []string{"settings", "export"})[0]
+	if result.Status != "success" {
+		return nil, fmt.Errorf("failed to read the settings of %s: %s", target.Name, result.Error)
+	}
+	data, ok := result.Result.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("failed to read the settings of %s: unexpected result", target.Name)
+	}
+	settings := make(map[string]string, len(data))
+	for key, value := range data {
+		settings[key] = fmt.Sprint(value)
+	}
+	return settings, nil
+}
+
+func printSettingDiffs(diffs []settingDiff, label string) {
+	if len(diffs) == 0 {
+		fmt.Println(format.Success("No differences"))
+		return
+	}
+
+	headers := []string{"Key", "Status", "Default", "Live"}
+	if label != "" {
+		headers = append(headers, label)
+	}
+	rows := make([][]string, 0, len(diffs))
+	for _, d := range diffs {
+		live := diffValue(d.Live)
+		switch d.Status {
+		case "changed", "extra":
+			live = format.Warning(live)
+		case "unknown":
+			live = format.Info(live)
+		}
+		row := []string{d.Key, diffStatus(d.Status), diffValue(d.Default), live}
+		if label != "" {
+			row = append(row, diffValue(d.Other))
+		}
+		rows = append(rows, row)
+	}
+	format.PrintTable(headers, rows)
+}
+
+func diffStatus(status string) string {
+	switch status {
+	case "changed":
+		return format.Warning(status)
+	case "missing":
+		return format.Error(status)
+	case "unknown":
+		return format.Info(status)
+	}
+	return status
+}
+
+// diffValue shows a value, or (not set)
+func diffValue(value *string) string {
+	if value == nil {
+		return format.Dim("(not set)")
+	}
+	return *value
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func init() {
+	settingsDiffCmd.Flags().StringVar(&diffFile, "file", "", "Compare with an exported settings file")
+	settingsDiffCmd.Flags().StringVar(&diffWithProfile, "with-profile", "", "Compare with the scooter of this profile")
+	settingsDiffCmd.Flags().StringVar(&diffWithSSH, "with-ssh", "", "Compare with the scooter at this SSH host")
+
+	settingsCmd.AddCommand(settingsDiffCmd)
+}
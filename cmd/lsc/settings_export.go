@@ -0,0 +1,221 @@
+package lsc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"librescoot/lsc/internal/format"
+	"librescoot/lsc/internal/output"
+	"librescoot/lsc/internal/redis"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	settingsFileFlag string
+	settingsOnly     []string
+	importYes        bool
+	importDryRun     bool
+	importForce      bool
+)
+
+var settingsExportCmd = &cobra.Command{
+	Use:   "export [file]",
+	Short: "Export the settings hash to a file",
+	Long: `Write the settings hash as a TOML, JSON or YAML document of "key" = "value" pairs,
+to stdout or to a file. The format follows --format, then the file extension, and is
+TOML otherwise. With --json and no file the settings are the data of the result
+envelope instead; with a file the envelope names the file written.`,
+	Example: `  lsc settings export > scooter.toml
+  lsc settings export --format yaml --only dashboard.saved-locations > locations.yaml
+  lsc settings export backup.json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file := ""
+		if len(args) == 1 {
+			file = args[0]
+		}
+		fileFormat, err := settingsFileFormat(file, settingsFileFlag)
+		if err != nil {
+			return err
+		}
+
+		settings, err := lscApp.Redis.HGetAll("settings")
+		if err != nil {
+			return fmt.Errorf("failed to fetch settings: %w", err)
+		}
+		settings = filterSettings(settings, settingsOnly)
+
+		if file == "" && lscApp.Structured() {
+			return lscApp.Render(settings, nil)
+		}
+
+		var doc bytes.Buffer
+		if err := encodeSettings(&doc, settings, fileFormat); err != nil {
+			return fmt.Errorf("failed to encode settings: %w", err)
+		}
+		if file == "" {
+			_, err := os.Stdout.Write(doc.Bytes())
+			return err
+		}
+		if err := os.WriteFile(file, doc.Bytes(), 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file, err)
+		}
+		return lscApp.Render(map[string]interface{}{
+			"file":     file,
+			"format":   fileFormat,
+			"settings": len(settings),
+		}, func() {
+			fmt.Println(format.Success(fmt.Sprintf("Exported %d setting(s) to %s", len(settings), file)))
+		})
+	},
+}
+
+var settingsImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import settings from a file",
+	Long: `Read settings exported with 'lsc settings export' (or written by hand, "-" for
+stdin) and show how they differ from the settings hash. After confirmation the changed
+keys are written in one MULTI/EXEC transaction and each is published on the
+"settings" channel. Keys missing from the file are left alone.
+
+Values of known settings are checked like 'lsc settings set' does; --force imports
+them anyway.`,
+	Example: `  lsc settings import scooter.toml
+  lsc settings import --only alarm.* --yes scooter.toml
+  lsc settings import --dry-run backup.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file := args[0]
+		fileFormat, err := settingsFileFormat(file, settingsFileFlag)
+		if err != nil {
+			return err
+		}
+		imported, err := readSettingsFile(file, fileFormat)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return output.InvalidArgument("file not found: %s", file)
+			}
+			return err
+		}
+		imported = filterSettings(imported, settingsOnly)
+
+		if !importForce {
+			var invalid []string
+			for _, key := range sortedKeys(imported) {
+				if info, ok := lookupSetting(key); ok {
+					if err := info.Validate(imported[key]); err != nil {
+						invalid = append(invalid, err.Error())
+					}
+				}
+			}
+			if len(invalid) > 0 {
+				return output.InvalidArgument("%s (use --force to import anyway)", strings.Join(invalid, "; "))
+			}
+		}
+
+		current, err := lscApp.Redis.HGetAll("settings")
+		if err != nil {
+			return fmt.Errorf("failed to fetch settings: %w", err)
+		}
+		changes := settingChanges(current, imported)
+
+		result := map[string]interface{}{
+			"file":    file,
+			"changes": changes,
+			"applied": false,
+		}
+		if len(changes) == 0 {
+			return lscApp.Render(result, func() {
+				fmt.Println(format.Success("Settings already match " + file))
+			})
+		}
+		if !lscApp.Structured() {
+			printSettingChanges(changes)
+		}
+		if importDryRun {
+			return lscApp.Render(result, func() {
+				fmt.Println(format.Dim("Dry run, nothing was changed"))
+			})
+		}
+
+		if !importYes && (lscApp.Structured() || !lscApp.Ask(fmt.Sprintf("Apply %d change(s)?", len(changes)))) {
+			return fmt.Errorf("import not confirmed, nothing was changed (use --yes to apply without asking)")
+		}
+
+		if err := applySettings(cmd.Context(), lscApp.Redis, changes); err != nil {
+			return fmt.Errorf("failed to apply settings: %w", err)
+		}
+		result["applied"] = true
+		return lscApp.Render(result, func() {
+			fmt.Println(format.Success(fmt.Sprintf("Imported %d setting(s)", len(changes))))
+		})
+	},
+}
+
+// settingChange is a key whose value differs between two sets of settings;
+// Old is nil where the key is not set
+type settingChange struct {
+	Key string  `json:"key"`
+	Old *string `json:"old"`
+	New string  `json:"new"`
+}
+
+// settingChanges returns the keys of imported whose values differ from current, in order
+func settingChanges(current, imported map[string]string) []settingChange {
+	changes := make([]settingChange, 0)
+	for _, key := range sortedKeys(imported) {
+		old, ok := current[key]
+		if ok && old == imported[key] {
+			continue
+		}
+		change := settingChange{Key: key, New: imported[key]}
+		if ok {
+			change.Old = &old
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+func printSettingChanges(changes []settingChange) {
+	rows := make([][]string, 0, len(changes))
+	for _, change := range changes {
+		old := format.Dim("(not set)")
+		if change.Old != nil {
+			old = format.Error(*change.Old)
+		}
+		rows = append(rows, []string{change.Key, old, format.Success(change.New)})
+	}
+	format.PrintTable([]string{"Key", "Current", "Imported"}, rows)
+	fmt.Println()
+}
+
+// applySettings writes the changes in one transaction and publishes each key
+func applySettings(ctx context.Context, client *redis.Client, changes []settingChange) error {
+	return client.TxPipelined(ctx, func(tx redis.Pipeliner) error {
+		for _, change := range changes {
+			tx.HSet(ctx, "settings", change.Key, change.New)
+		}
+		for _, change := range changes {
+			tx.Publish(ctx, "settings", change.Key)
+		}
+		return nil
+	})
+}
+
+func init() {
+	for _, c := range []*cobra.Command{settingsExportCmd, settingsImportCmd} {
+		c.Flags().StringVar(&settingsFileFlag, "format", "", "File format: toml, json or yaml (default: from the file extension, else toml)")
+		c.Flags().StringSliceVar(&settingsOnly, "only", nil, "Only these keys (comma-separated, * wildcards or prefixes, e.g. alarm.*)")
+	}
+	settingsImportCmd.Flags().BoolVarP(&importYes, "yes", "y", false, "Apply the changes without asking")
+	settingsImportCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Only show the changes")
+	settingsImportCmd.Flags().BoolVar(&importForce, "force", false, "Import values that do not match the setting's type")
+
+	settingsCmd.AddCommand(settingsExportCmd)
+	settingsCmd.AddCommand(settingsImportCmd)
+}
@@ -0,0 +1,146 @@
+package lsc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"librescoot/lsc/internal/output"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// settingsFileFormats are the formats of exported settings files
+var settingsFileFormats = []string{"toml", "json", "yaml"}
+
+// settingsFileFormat returns the format of a settings file: the --format
+// value if given, otherwise the one its extension names, otherwise TOML
+func settingsFileFormat(file, flag string) (string, error) {
+	switch flag {
+	case "":
+	case "toml", "json", "yaml":
+		return flag, nil
+	case "yml":
+		return "yaml", nil
+	default:
+		return "", output.InvalidArgument("invalid format '%s'; use %s", flag, strings.Join(settingsFileFormats, ", "))
+	}
+	switch filepath.Ext(file) {
+	case ".json":
+		return "json", nil
+	case ".yaml", ".yml":
+		return "yaml", nil
+	}
+	return "toml", nil
+}
+
+// encodeSettings writes settings as a flat document of string values
+func encodeSettings(w io.Writer, settings map[string]string, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(settings)
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(settings); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+	return toml.NewEncoder(w).Encode(settings)
+}
+
+// readSettingsFile reads a settings file, "-" for stdin. Nested tables are
+// flattened to dotted keys and values of any type are read as strings, so
+// hand-written files need not quote everything.
+func readSettingsFile(file, format string) (map[string]string, error) {
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]interface{}
+	switch format {
+	case "json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		err = dec.Decode(&doc)
+	case "yaml":
+		err = yaml.Unmarshal(data, &doc)
+	default:
+		err = toml.Unmarshal(data, &doc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s in %s: %w", strings.ToUpper(format), file, err)
+	}
+
+	settings := make(map[string]string)
+	flattenSettings(settings, "", doc)
+	return settings, nil
+}
+
+func flattenSettings(settings map[string]string, prefix string, doc map[string]interface{}) {
+	for key, value := range doc {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch value := value.(type) {
+		case map[string]interface{}:
+			flattenSettings(settings, key, value)
+		case nil:
+			settings[key] = ""
+		default:
+			settings[key] = fmt.Sprint(value)
+		}
+	}
+}
+
+// filterSettings returns the settings whose keys match one of patterns, all
+// of them if there are none. A pattern matches like path.Match, where * also
+// matches dots, or as a prefix of the key up to a dot.
+func filterSettings(settings map[string]string, patterns []string) map[string]string {
+	if len(patterns) == 0 {
+		return settings
+	}
+	filtered := make(map[string]string)
+	for key, value := range settings {
+		if settingMatches(key, patterns) {
+			filtered[key] = value
+		}
+	}
+	return filtered
+}
+
+func settingMatches(key string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, key); ok || strings.HasPrefix(key, pattern+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedKeys returns the keys of settings in order
+func sortedKeys(settings map[string]string) []string {
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,171 @@
+package lsc
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// SettingType is the kind of value a setting holds
+type SettingType string
+
+const (
+	SettingString   SettingType = "string"
+	SettingBool     SettingType = "bool"     // true or false
+	SettingInt      SettingType = "int"      // decimal integer
+	SettingFloat    SettingType = "float"    // decimal number
+	SettingEnum     SettingType = "enum"     // one of Values
+	SettingURL      SettingType = "url"      // absolute http or https URL
+	SettingDuration SettingType = "duration" // Go duration, e.g. 90s
+	SettingTime     SettingType = "time"     // RFC 3339 timestamp
+)
+
+// SettingRange bounds the value of an int or float setting, inclusive
+type SettingRange struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// savedLocationKey matches the keys of saved locations at any index
+var savedLocationKey = regexp.MustCompile(`^dashboard\.saved-locations\.\d+\.`)
+
+// lookupSetting returns the schema of a setting. Saved locations share the
+// schema of location 0.
+func lookupSetting(key string) (SettingInfo, bool) {
+	schemaKey := savedLocationKey.ReplaceAllString(key, "dashboard.saved-locations.0.")
+	for _, info := range knownSettings {
+		if info.Key == schemaKey {
+			return info, true
+		}
+	}
+	return SettingInfo{}, false
+}
+
+// Validate checks a value against the schema of the setting
+func (s SettingInfo) Validate(value string) error {
+	var ok bool
+	switch s.Type {
+	case SettingBool:
+		ok = value == "true" || value == "false"
+	case SettingInt:
+		n, err := strconv.ParseInt(value, 10, 64)
+		ok = err == nil && s.Range.contains(float64(n))
+	case SettingFloat:
+		f, err := strconv.ParseFloat(value, 64)
+		ok = err == nil && s.Range.contains(f)
+	case SettingEnum:
+		ok = slices.Contains(s.Values, value)
+	case SettingURL:
+		u, err := url.Parse(value)
+		ok = err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+	case SettingDuration:
+		_, err := time.ParseDuration(value)
+		ok = err == nil
+	case SettingTime:
+		_, err := time.Parse(time.RFC3339, value)
+		ok = err == nil
+	default:
+		ok = true
+	}
+	if !ok {
+		return fmt.Errorf("invalid value '%s' for %s: expected %s", value, s.Key, s.Expected())
+	}
+	return nil
+}
+
+// Expected describes the values the setting accepts, e.g. "an integer from 1 to 3600 s"
+func (s SettingInfo) Expected() string {
+	switch s.Type {
+	case SettingBool:
+		return "true or false"
+	case SettingInt:
+		return "an integer" + s.Range.describe(s.Unit)
+	case SettingFloat:
+		return "a number" + s.Range.describe(s.Unit)
+	case SettingEnum:
+		return "one of " + strings.Join(s.Values, ", ")
+	case SettingURL:
+		return "an http or https URL"
+	case SettingDuration:
+		return "a duration such as 90s or 5m"
+	case SettingTime:
+		return "an RFC 3339 time such as 2025-01-02T15:04:05Z"
+	}
+	return "any text"
+}
+
+// Schema is the short form of the type shown by 'settings list', e.g. "int 1..3600 s"
+func (s SettingInfo) Schema() string {
+	switch s.Type {
+	case SettingEnum:
+		return strings.Join(s.Values, "|")
+	case SettingInt, SettingFloat:
+		schema := string(s.Type)
+		if s.Range != nil {
+			schema += fmt.Sprintf(" %g..%g", s.Range.Min, s.Range.Max)
+		}
+		if s.Unit != "" {
+			schema += " " + s.Unit
+		}
+		return schema
+	case "":
+		return string(SettingString)
+	}
+	return string(s.Type)
+}
+
+// Completions returns the values offered for the setting by shell completion
+func (s SettingInfo) Completions() []string {
+	switch s.Type {
+	case SettingBool:
+		return []string{"true", "false"}
+	case SettingEnum:
+		return s.Values
+	}
+	return nil
+}
+
+func (r *SettingRange) contains(v float64) bool {
+	return r == nil || (v >= r.Min && v <= r.Max)
+}
+
+func (r *SettingRange) describe(unit string) string {
+	if r == nil {
+		return ""
+	}
+	text := fmt.Sprintf(" from %g to %g", r.Min, r.Max)
+	if unit != "" {
+		text += " " + unit
+	}
+	return text
+}
+
+// completeSettingKeys offers the known setting keys for the first argument
+func completeSettingKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) != 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	keys := make([]string, 0, len(knownSettings))
+	for _, info := range knownSettings {
+		keys = append(keys, info.Key)
+	}
+	return keys, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeSettingValue offers the known keys for the first argument and the
+// allowed values of the setting for the second
+func completeSettingValue(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 1 {
+		if info, ok := lookupSetting(args[0]); ok {
+			return info.Completions(), cobra.ShellCompDirectiveNoFileComp
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeSettingKeys(cmd, args, toComplete)
+}
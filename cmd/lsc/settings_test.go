@@ -0,0 +1,318 @@
+package lsc
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSettingsList(t *testing.T) {
+	srv := newScooter(t)
+
+	res := mustRun(t, srv, "settings", "list")
+	assertContains(t, res.stdout,
+		"=== Settings ===",
+		"alarm.honk:          true",
+		"alarm.duration:      (not set)",
+		"=== Unknown Settings ===",
+		"scooter.speed_limit: 25",
+	)
+
+	data := jsonData(t, srv, "settings", "list")
+	honk := data["alarm.honk"].(map[string]interface{})
+	if honk["value"] != "true" || honk["type"] != "bool" || honk["valid"] != true {
+		t.Errorf("alarm.honk = %v", honk)
+	}
+	duration := data["alarm.duration"].(map[string]interface{})
+	if value, ok := duration["value"]; !ok || value != nil {
+		t.Errorf("alarm.duration value = %v, want null", value)
+	}
+	if duration["schema"] != "int 1..3600 s" || duration["unit"] != "s" ||
+		!reflect.DeepEqual(duration["range"], map[string]interface{}{"min": 1.0, "max": 3600.0}) {
+		t.Errorf("alarm.duration = %v", duration)
+	}
+	theme := data["dashboard.theme"].(map[string]interface{})
+	if !reflect.DeepEqual(theme["values"], []interface{}{"light", "dark", "auto"}) {
+		t.Errorf("dashboard.theme values = %v", theme["values"])
+	}
+}
+
+func TestSettingsGet(t *testing.T) {
+	srv := newScooter(t)
+
+	if res := mustRun(t, srv, "settings", "get", "alarm.honk"); res.stdout != "true\n" {
+		t.Errorf("get alarm.honk = %q", res.stdout)
+	}
+	if res := mustRun(t, srv, "settings", "get", "alarm.duration"); res.stdout != "(not set)\n" {
+		t.Errorf("get alarm.duration = %q", res.stdout)
+	}
+
+	data := jsonData(t, srv, "settings", "get", "alarm.duration")
+	if data["key"] != "alarm.duration" || data["value"] != "" {
+		t.Errorf("unexpected data %v", data)
+	}
+}
+
+func TestSettingsSetPublishes(t *testing.T) {
+	srv := newScooter(t)
+	next := subscribe(t, srv, "settings")
+
+	res := mustRun(t, srv, "settings", "set", "alarm.duration", "30")
+	assertContains(t, res.stdout, "Setting 'alarm.duration' = '30'")
+	if got := srv.HGet("settings", "alarm.duration"); got != "30" {
+		t.Errorf("alarm.duration = %q, want 30", got)
+	}
+	if payload := next(); payload != "alarm.duration" {
+		t.Errorf("published %q, want alarm.duration", payload)
+	}
+
+	data := jsonData(t, srv, "settings", "del", "alarm.duration")
+	if data["deleted"] != true {
+		t.Errorf("unexpected data %v", data)
+	}
+	if next() != "alarm.duration" || srv.HGet("settings", "alarm.duration") != "" {
+		t.Error("alarm.duration was not deleted and published")
+	}
+}
+
+func TestSettingsInvalidArguments(t *testing.T) {
+	srv := newScooter(t)
+
+	res := runLSC(t, srv, "settings", "set", "alarm.enabled")
+	assertCode(t, res.err, "invalid_argument")
+	assertContains(t, res.stderr, "accepts 2 arg(s), received 1")
+}
+
+func TestSettingsSetValidates(t *testing.T) {
+	srv := newScooter(t)
+
+	res := runLSC(t, srv, "settings", "set", "alarm.duration", "abc")
+	assertCode(t, res.err, "invalid_argument")
+	assertContains(t, res.stderr, "invalid value 'abc' for alarm.duration: expected an integer from 1 to 3600 s (use --force to set it anyway)")
+
+	res = runLSC(t, srv, "set", "dashboard.theme", "purple")
+	assertCode(t, res.err, "invalid_argument")
+	assertContains(t, res.stderr, "expected one of light, dark, auto")
+
+	res = runLSC(t, srv, "settings", "set", "dashboard.saved-locations.3.latitude", "91")
+	assertCode(t, res.err, "invalid_argument")
+	if srv.HGet("settings", "dashboard.theme") != "" || srv.HGet("settings", "alarm.duration") != "" {
+		t.Error("an invalid value was written")
+	}
+
+	mustRun(t, srv, "set", "dashboard.theme", "purple", "--force")
+	if got := srv.HGet("settings", "dashboard.theme"); got != "purple" {
+		t.Errorf("dashboard.theme = %q, want purple", got)
+	}
+	// Keys without a schema are set as given
+	mustRun(t, srv, "settings", "set", "scooter.speed_limit", "anything")
+}
+
+func TestSettingsSchema(t *testing.T) {
+	for _, info := range knownSettings {
+		if info.Default == "" {
+			continue
+		}
+		if err := info.Validate(info.Default); err != nil {
+			t.Errorf("default of %s: %v", info.Key, err)
+		}
+	}
+
+	srv := newScooter(t)
+	res := mustRun(t, srv, "settings", "list")
+	assertContains(t, res.stdout, "alarm.honk:          true [bool]", "[int 1..3600 s]", "[light|dark|auto]")
+
+	if values, _ := completeSettingValue(settingsSetCmd, []string{"dashboard.theme"}, ""); !reflect.DeepEqual(values, []string{"light", "dark", "auto"}) {
+		t.Errorf("dashboard.theme completions = %v", values)
+	}
+	if values, _ := completeSettingValue(setCmd, []string{"alarm.enabled"}, ""); !reflect.DeepEqual(values, []string{"true", "false"}) {
+		t.Errorf("alarm.enabled completions = %v", values)
+	}
+}
+
+func TestSettingsExportImport(t *testing.T) {
+	srv := newScooter(t)
+	dir := t.TempDir()
+
+	res := mustRun(t, srv, "settings", "export")
+	assertContains(t, res.stdout, `"alarm.honk" = "true"`, `"scooter.speed_limit" = "25"`)
+
+	for _, file := range []string{"backup.toml", "backup.json", "backup.yaml"} {
+		path := filepath.Join(dir, file)
+		mustRun(t, srv, "settings", "export", path)
+		imported, err := readSettingsFile(path, strings.TrimPrefix(filepath.Ext(file), "."))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if imported["alarm.honk"] != "true" || imported["scooter.speed_limit"] != "25" {
+			t.Errorf("%s = %v", file, imported)
+		}
+	}
+
+	// A file is written with structured output too, the envelope only names it
+	path := filepath.Join(dir, "structured.json")
+	data := jsonData(t, srv, "settings", "export", path)
+	if data["file"] != path || data["format"] != "json" {
+		t.Errorf("export data = %v", data)
+	}
+	if imported, err := readSettingsFile(path, "json"); err != nil || imported["alarm.honk"] != "true" {
+		t.Errorf("%s = %v, %v", path, imported, err)
+	}
+
+	// Hand-written files may nest tables and leave values unquoted
+	path = filepath.Join(dir, "edited.toml")
+	os.WriteFile(path, []byte(`
+"alarm.honk" = "true"
+[alarm]
+enabled = true
+duration = 30
+[dashboard]
+theme = "light"
+`), 0o644)
+
+	next := subscribe(t, srv, "settings")
+	res = mustRun(t, srv, "settings", "import", "--dry-run", path)
+	assertContains(t, res.stdout, "alarm.duration", "(not set)", "Dry run, nothing was changed")
+	if srv.HGet("settings", "alarm.enabled") != "false" {
+		t.Fatal("dry run changed the settings")
+	}
+
+	res = runLSC(t, srv, "settings", "import", path)
+	if res.err == nil || srv.HGet("settings", "alarm.enabled") != "false" {
+		t.Fatal("import without a terminal applied unconfirmed changes")
+	}
+
+	data = jsonData(t, srv, "settings", "import", "--only", "alarm.*", "--yes", path)
+	if data["applied"] != true || len(data["changes"].([]interface{})) != 2 {
+		t.Errorf("import data = %v", data)
+	}
+	if srv.HGet("settings", "alarm.enabled") != "true" || srv.HGet("settings", "alarm.duration") != "30" {
+		t.Error("alarm settings were not imported")
+	}
+	if srv.HGet("settings", "dashboard.theme") != "" {
+		t.Error("dashboard.theme was imported despite --only")
+	}
+	published := []string{next(), next()}
+	if !reflect.DeepEqual(published, []string{"alarm.duration", "alarm.enabled"}) {
+		t.Errorf("published %v", published)
+	}
+
+	os.WriteFile(path, []byte(`"dashboard.theme" = "purple"`), 0o644)
+	res = runLSC(t, srv, "settings", "import", "--yes", path)
+	assertCode(t, res.err, "invalid_argument")
+	assertContains(t, res.stderr, "invalid value 'purple' for dashboard.theme")
+}
+
+func TestSettingsDiff(t *testing.T) {
+	srv := newScooter(t)
+
+	statuses := func(args ...string) map[string]string {
+		t.Helper()
+		data := jsonData(t, srv, append([]string{"settings", "diff"}, args...)...)
+		got := make(map[string]string)
+		for _, d := range data["differences"].([]interface{}) {
+			d := d.(map[string]interface{})
+			got[d["key"].(string)] = d["status"].(string)
+		}
+		return got
+	}
+
+	got := statuses()
+	for key, want := range map[string]string{
+		"alarm.honk":          "changed",
+		"alarm.duration":      "missing",
+		"scooter.speed_limit": "unknown",
+	} {
+		if got[key] != want {
+			t.Errorf("%s = %q, want %q", key, got[key], want)
+		}
+	}
+	if _, ok := got["alarm.enabled"]; ok {
+		t.Error("alarm.enabled matches its default but was listed")
+	}
+
+	path := filepath.Join(t.TempDir(), "other.toml")
+	os.WriteFile(path, []byte(`
+"alarm.enabled" = "false"
+"alarm.honk" = "false"
+"alarm.duration" = "30"
+`), 0o644)
+	got = statuses("--file", path)
+	for key, want := range map[string]string{
+		"alarm.honk":                           "changed",
+		"alarm.duration":                       "missing",
+		"scooter.speed_limit":                  "unknown",
+		"dashboard.saved-locations.1.latitude": "extra",
+	} {
+		if got[key] != want {
+			t.Errorf("against the file, %s = %q, want %q", key, got[key], want)
+		}
+	}
+	if _, ok := got["alarm.enabled"]; ok {
+		t.Error("alarm.enabled matches the file but was listed")
+	}
+
+	res := mustRun(t, srv, "settings", "diff", "--file", path)
+	assertContains(t, res.stdout, "other.toml", "alarm.honk", "changed", "(not set)")
+
+	res = runLSC(t, srv, "settings", "diff", "--file", path, "--with-ssh", "deep-blue")
+	assertCode(t, res.err, "invalid_argument")
+}
+
+func TestSettingsWatch(t *testing.T) {
+	srv := newScooter(t)
+	now := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	lscApp.Clock = func() time.Time { return now }
+	t.Cleanup(func() { lscApp.Clock = time.Now })
+
+	done := make(chan result)
+	go func() {
+		done <- runLSC(t, srv, "settings", "watch", "--count", "2", "--json", "alarm")
+	}()
+	for deadline := time.Now().Add(2 * time.Second); srv.PubSubNumSub("settings")["settings"] == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("settings watch did not subscribe")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// Give it time to read the settings hash
+	time.Sleep(100 * time.Millisecond)
+
+	srv.HSet("settings", "dashboard.theme", "light")
+	srv.Publish("settings", "dashboard.theme")
+	srv.HSet("settings", "alarm.honk", "false")
+	srv.Publish("settings", "alarm.honk")
+	srv.HDel("settings", "alarm.enabled")
+	srv.Publish("settings", "alarm.enabled")
+
+	var res result
+	select {
+	case res = <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("settings watch did not stop after --count changes")
+	}
+	if res.err != nil {
+		t.Fatalf("settings watch: %v\n%s", res.err, res.stderr)
+	}
+
+	lines := strings.Split(strings.TrimSpace(res.stdout), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("output = %q, want 2 lines", res.stdout)
+	}
+	var honk, enabled settingsEvent
+	json.Unmarshal([]byte(lines[0]), &honk)
+	json.Unmarshal([]byte(lines[1]), &enabled)
+	if honk.Key != "alarm.honk" || honk.Old == nil || *honk.Old != "true" || honk.New == nil || *honk.New != "false" {
+		t.Errorf("first change = %s", lines[0])
+	}
+	if enabled.Key != "alarm.enabled" || enabled.Old == nil || *enabled.Old != "false" || enabled.New != nil {
+		t.Errorf("second change = %s", lines[1])
+	}
+	if !honk.Time.Equal(now) || !enabled.Time.Equal(now) {
+		t.Errorf("changes at %s and %s, want the app clock's %s", honk.Time, enabled.Time, now)
+	}
+}
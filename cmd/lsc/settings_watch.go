@@ -0,0 +1,117 @@
+package lsc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"librescoot/lsc/internal/format"
+	"librescoot/lsc/internal/redis"
+
+	"github.com/spf13/cobra"
+)
+
+var watchCount int
+
+var settingsWatchCmd = &cobra.Command{
+	Use:   "watch [prefix...]",
+	Short: "Show settings changes with their old and new values",
+	Long: `Watch the "settings" channel and print each change as key: old → new. The channel
+only carries the key, so lsc keeps a copy of the settings hash and reads the new value
+of every published key. Prefixes (alarm, dashboard.saved-locations) or * wildcards
+limit the keys shown. With --json each change is one JSON object per line.`,
+	Example: `  lsc settings watch
+  lsc settings watch alarm updates.*.channel
+  lsc settings watch --json >> settings-changes.jsonl`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+
+		// Subscribe before reading the hash so no change falls in between
+		pubsub := lscApp.Redis.Subscribe(ctx, "settings")
+		defer pubsub.Close()
+		if _, err := pubsub.Receive(ctx); err != nil {
+			return fmt.Errorf("failed to subscribe to settings: %w", err)
+		}
+		snapshot, err := lscApp.Redis.HGetAllWithContext(ctx, "settings")
+		if err != nil {
+			return fmt.Errorf("failed to fetch settings: %w", err)
+		}
+
+		if !lscApp.Structured() {
+			fmt.Println(format.Info(fmt.Sprintf("Watching %d setting(s)", len(filterSettings(snapshot, args)))))
+			fmt.Println(format.Dim("Press Ctrl+C to stop\n"))
+		}
+
+		ch := pubsub.Channel()
+		for seen := 0; watchCount == 0 || seen < watchCount; {
+			var key string
+			select {
+			case <-ctx.Done():
+				return nil
+			case msg := <-ch:
+				key = msg.Payload
+			}
+			if len(args) > 0 && !settingMatches(key, args) {
+				continue
+			}
+
+			change := settingsEvent{Time: lscApp.Now(), Key: key}
+			if old, ok := snapshot[key]; ok {
+				change.Old = &old
+			}
+			value, err := lscApp.Redis.HGetWithContext(ctx, "settings", key)
+			switch {
+			case err == nil:
+				change.New = &value
+				snapshot[key] = value
+			case errors.Is(err, redis.Nil):
+				delete(snapshot, key)
+			default:
+				if ctx.Err() != nil {
+					return nil
+				}
+				return fmt.Errorf("failed to fetch %s: %w", key, err)
+			}
+
+			if lscApp.Structured() {
+				line, _ := json.Marshal(change)
+				fmt.Println(string(line))
+			} else {
+				printSettingsEvent(change)
+			}
+			seen++
+		}
+		return nil
+	},
+}
+
+// settingsEvent is a published settings change; Old and New are nil where
+// the key was not set
+type settingsEvent struct {
+	Time time.Time `json:"time"`
+	Key  string    `json:"key"`
+	Old  *string   `json:"old"`
+	New  *string   `json:"new"`
+}
+
+func printSettingsEvent(e settingsEvent) {
+	old, value := diffValue(e.Old), diffValue(e.New)
+	if e.Old != nil {
+		old = format.Error(old)
+	}
+	if e.New != nil {
+		value = format.Success(value)
+	}
+	fmt.Printf("[%s] %s: %s → %s\n", format.Dim(e.Time.Format("15:04:05.000")), e.Key, old, value)
+}
+
+func init() {
+	settingsWatchCmd.Flags().IntVarP(&watchCount, "count", "n", 0, "Stop after this many changes (0: until interrupted)")
+
+	settingsCmd.AddCommand(settingsWatchCmd)
+}
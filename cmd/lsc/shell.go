@@ -0,0 +1,396 @@
+package lsc
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"librescoot/lsc/internal/config"
+	"librescoot/lsc/internal/output"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/term"
+)
+
+// historySize is the number of lines kept in the shell history file
+const historySize = 1000
+
+// shellBuiltins are handled by the shell itself instead of a command
+var shellBuiltins = []string{"exit", "quit", "json", "output"}
+
+var shellCmd = &cobra.Command{
+	Use:   "shell",
+	Short: "Interactive shell on a persistent connection",
+	Long: `Start an interactive shell that keeps one Redis connection (and SSH tunnel)
+open and runs any lsc command without the 'lsc' prefix.
+
+Global flags given when starting the shell apply to every command; connection
+flags given inside the shell are ignored. Tab completes commands, flags and
+arguments, and the history is kept in ~/.config/lsc/history. Ctrl-C cancels the
+running command, such as a watch or a wait for confirmation, and returns to the prompt.
+
+Built-in commands:
+  json [on|off]     Toggle JSON output for the following commands
+  output [format]   Show or set the output format (pretty, json, yaml, ...)
+  exit, quit        Leave the shell (or press Ctrl-D)
+
+When standard input is not a terminal, commands are read line by line without
+prompt or history. Lines starting with # are ignored.
+
+Examples:
+  lsc shell
+  lsc --ssh deep-blue shell
+  echo "status" | lsc shell`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if sessionActive {
+			return output.InvalidArgument("already running in an lsc shell")
+		}
+
+		session, end := startSession(cmd)
+		defer end()
+
+		fd := int(os.Stdin.Fd())
+		if !term.IsTerminal(fd) {
+			return session.runScript(os.Stdin)
+		}
+		return session.runInteractive(fd)
+	},
+}
+
+// runInteractive reads commands from the terminal with line editing, completion and history
+func (s *commandSession) runInteractive(fd int) error {
+	history := loadHistory()
+	terminal := newShellTerminal(history)
+	terminal.AutoCompleteCallback = completeShellLine
+
+	// Interrupts cancel the running command, not the shell
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+	s.interrupts = interrupts
+	defer func() { s.interrupts = nil }()
+
+	fmt.Printf("Connected to %s. Type 'help' for commands, 'exit' or Ctrl-D to quit.\n", connectionTarget())
+	for {
+		line, err := readShellLine(fd, terminal)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if strings.TrimSpace(line) != "" {
+			appendHistory(line)
+		}
+		if s.runLine(line) {
+			return nil
+		}
+	}
+}
+
+// runScript runs one command per line from r
+func (s *commandSession) runScript(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if s.runLine(scanner.Text()) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// runLine handles built-ins and executes everything else as an lsc command.
+// It reports whether the session should end.
+func (s *commandSession) runLine(line string) bool {
+	if strings.HasPrefix(strings.TrimSpace(line), "#") {
+		return false
+	}
+	args, err := splitArgs(line)
+	if err != nil {
+		shellError(output.InvalidArgument("%w", err))
+		return false
+	}
+	if len(args) == 0 {
+		return false
+	}
+
+	switch args[0] {
+	case "exit", "quit":
+		return true
+	case "json":
+		shellJSON(args[1:])
+	case "output":
+		shellSetOutput(args[1:])
+	default:
+		s.execute(context.Background(), args)
+	}
+	return false
+}
+
+// shellJSON implements the json built-in
+func shellJSON(args []string) {
+	switch {
+	case len(args) == 0 && sessionOutput == "json":
+		sessionOutput = ""
+	case len(args) == 0:
+		sessionOutput = "json"
+	case len(args) == 1 && args[0] == "on":
+		sessionOutput = "json"
+	case len(args) == 1 && args[0] == "off":
+		sessionOutput = ""
+	default:
+		shellError(output.InvalidArgument("usage: json [on|off]"))
+		return
+	}
+
+	if sessionOutput == "json" {
+		fmt.Fprintln(os.Stderr, "JSON output on")
+	} else {
+		fmt.Fprintln(os.Stderr, "JSON output off")
+	}
+}
+
+// shellSetOutput implements the output built-in
+func shellSetOutput(args []string) {
+	switch len(args) {
+	case 0:
+		if sessionOutput == "" {
+			fmt.Println("pretty")
+		} else {
+			fmt.Println(sessionOutput)
+		}
+	case 1:
+		if err := lscApp.Output.SetFormat(args[0]); err != nil {
+			shellError(err)
+			return
+		}
+		sessionOutput = args[0]
+	default:
+		shellError(output.InvalidArgument("usage: output [pretty|json|yaml|csv|table|template=<go-template>]"))
+	}
+}
+
+// shellError reports an error of the shell itself in the session's output format
+func shellError(err error) {
+	lscApp.Output.SetFormat(sessionOutput)
+	lscApp.Output.Begin("shell")
+	lscApp.Output.PrintError(err)
+}
+
+// connectionTarget describes where the shell is connected to
+func connectionTarget() string {
+	switch {
+	case sshTarget != "":
+		return sshTarget
+	case redisSocket != "":
+		return redisSocket
+	}
+	return redisAddr
+}
+
+// completeShellLine is the terminal's tab handler. It completes the word
+// before the cursor to the longest prefix shared by all candidates.
+func completeShellLine(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' {
+		return "", 0, false
+	}
+
+	head := line[:pos]
+	words := strings.Fields(head)
+	word := ""
+	if len(words) > 0 && !strings.HasSuffix(head, " ") {
+		word = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+
+	candidates := shellCompletions(words, word)
+	if len(candidates) == 0 {
+		return line, pos, true
+	}
+	completion := commonPrefix(candidates)
+	if len(candidates) == 1 {
+		completion += " "
+	}
+	if len(completion) <= len(word) {
+		return line, pos, true
+	}
+
+	head = head[:len(head)-len(word)] + completion
+	return head + line[pos:], len(head), true
+}
+
+// shellCompletions returns the subcommands, flags and valid arguments
+// starting with word after the given preceding words
+func shellCompletions(words []string, word string) []string {
+	seen := make(map[string]bool)
+	var candidates []string
+	add := func(candidate string) {
+		// Completions may carry a tab-separated description
+		candidate, _, _ = strings.Cut(candidate, "\t")
+		if strings.HasPrefix(candidate, word) && !seen[candidate] {
+			seen[candidate] = true
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	if len(words) == 0 {
+		for _, builtin := range shellBuiltins {
+			add(builtin)
+		}
+	}
+	if len(words) == 1 {
+		switch words[0] {
+		case "json":
+			add("on")
+			add("off")
+			return candidates
+		case "output":
+			for _, name := range []string{"pretty", "json", "yaml", "csv", "table", "template="} {
+				add(name)
+			}
+			return candidates
+		}
+	}
+
+	cmd, args, err := rootCmd.Find(words)
+	if err != nil {
+		sort.Strings(candidates)
+		return candidates
+	}
+
+	if strings.HasPrefix(word, "-") {
+		addFlag := func(f *pflag.Flag) {
+			if !f.Hidden {
+				add("--" + f.Name)
+			}
+		}
+		cmd.Flags().VisitAll(addFlag)
+		cmd.InheritedFlags().VisitAll(addFlag)
+	} else {
+		if len(args) == 0 {
+			for _, child := range cmd.Commands() {
+				if child.IsAvailableCommand() {
+					add(child.Name())
+				}
+			}
+		}
+		for _, arg := range cmd.ValidArgs {
+			add(arg)
+		}
+		if cmd.ValidArgsFunction != nil {
+			values, _ := cmd.ValidArgsFunction(cmd, args, word)
+			for _, value := range values {
+				add(value)
+			}
+		}
+	}
+
+	sort.Strings(candidates)
+	return candidates
+}
+
+func commonPrefix(values []string) string {
+	prefix := values[0]
+	for _, value := range values[1:] {
+		for !strings.HasPrefix(value, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// shellConn lets the terminal replay the history before it is attached to stdin/stdout
+type shellConn struct {
+	io.Reader
+	io.Writer
+}
+
+// newShellTerminal creates the line editor with history preloaded. The
+// terminal has no API to add history entries, so the lines are fed through
+// it with the echo discarded.
+func newShellTerminal(history []string) *term.Terminal {
+	conn := &shellConn{Writer: io.Discard}
+	if len(history) > 0 {
+		conn.Reader = strings.NewReader(strings.Join(history, "\r") + "\r")
+	}
+
+	terminal := term.NewTerminal(conn, "lsc> ")
+	for range history {
+		terminal.ReadLine()
+	}
+
+	conn.Reader, conn.Writer = os.Stdin, os.Stdout
+	return terminal
+}
+
+// readShellLine reads one line with the terminal in raw mode. Commands run
+// with the terminal restored so they can be interrupted with Ctrl-C.
+func readShellLine(fd int, terminal *term.Terminal) (string, error) {
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", err
+	}
+	defer term.Restore(fd, state)
+
+	if width, height, err := term.GetSize(fd); err == nil && width > 0 {
+		terminal.SetSize(width, height)
+	}
+	return terminal.ReadLine()
+}
+
+// loadHistory returns the saved shell history, trimming the file to historySize lines
+func loadHistory() []string {
+	path, err := config.HistoryPath()
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) > historySize {
+		lines = lines[len(lines)-historySize:]
+		os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600)
+	}
+	return lines
+}
+
+// appendHistory adds line to the history file. Lines with a password are not saved.
+func appendHistory(line string) {
+	if strings.Contains(line, "password") {
+		return
+	}
+	path, err := config.HistoryPath()
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, line)
+}
+
+func init() {
+	rootCmd.AddCommand(shellCmd)
+}
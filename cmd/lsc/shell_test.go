@@ -0,0 +1,38 @@
+package lsc
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShellInterruptCancelsCommand(t *testing.T) {
+	srv := newScooter(t)
+	interrupts := make(chan os.Signal, 1)
+	s := &commandSession{flags: map[string]string{"redis-addr": srv.Addr()}, interrupts: interrupts}
+
+	// Nothing confirms the lock, so only the interrupt ends it
+	time.AfterFunc(200*time.Millisecond, func() { interrupts <- os.Interrupt })
+	var err error
+	start := time.Now()
+	_, stderr := captureOutput(t, func() {
+		err = s.execute(context.Background(), []string{"lock"})
+	})
+	if err == nil || !strings.Contains(stderr, "interrupted while waiting for vehicle:state") {
+		t.Fatalf("err = %v, stderr = %s", err, stderr)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("lock ran for %s after the interrupt", elapsed)
+	}
+
+	// The next command gets a fresh context
+	srv.Del("scooter:state")
+	stdout, _ := captureOutput(t, func() {
+		err = s.execute(context.Background(), []string{"lock", "--no-block"})
+	})
+	if err != nil {
+		t.Fatalf("lock --no-block after the interrupt: %v\n%s", err, stdout)
+	}
+}
@@ -7,106 +7,128 @@ import (
 )
 
 // Shortcut commands for common operations
-// These shortcuts simply delegate to the real vehicle commands
+// These shortcuts simply delegate to the real vehicle commands, and are
+// checked against the access lists under the path of the command they stand for
 
 // lock shortcut - delegates to vehicle lock
 var lockCmd = &cobra.Command{
-	Use:   "lock",
-	Short: "Lock the scooter (shortcut for 'vehicle lock')",
-	Run:   vehicleLockCmd.Run,
+	Use:         "lock",
+	Short:       "Lock the scooter (shortcut for 'vehicle lock')",
+	RunE:        vehicleLockCmd.RunE,
+	Annotations: map[string]string{annotationPath: "vehicle.lock"},
 }
 
 // unlock shortcut - delegates to vehicle unlock
 var unlockCmd = &cobra.Command{
-	Use:   "unlock",
-	Short: "Unlock the scooter (shortcut for 'vehicle unlock')",
-	Run:   vehicleUnlockCmd.Run,
+	Use:         "unlock",
+	Short:       "Unlock the scooter (shortcut for 'vehicle unlock')",
+	RunE:        vehicleUnlockCmd.RunE,
+	Annotations: map[string]string{annotationPath: "vehicle.unlock"},
 }
 
 // open shortcut (seatbox) - delegates to vehicle open
 var openCmd = &cobra.Command{
-	Use:   "open",
-	Short: "Open the seatbox (shortcut for 'vehicle open')",
-	Run:   vehicleOpenCmd.Run,
+	Use:         "open",
+	Short:       "Open the seatbox (shortcut for 'vehicle open')",
+	RunE:        vehicleOpenCmd.RunE,
+	Annotations: map[string]string{annotationPath: "vehicle.open"},
 }
 
 // dbc, engine, and blink shortcuts - will be created by createDiagShortcut below
 
-// createDiagShortcut creates a shortcut command that mirrors a diag subcommand
+// createDiagShortcut creates a shortcut command from a diag subcommand
 func createDiagShortcut(name string, aliases []string) *cobra.Command {
-	// Find the real command
-	var realCmd *cobra.Command
-	for _, c := range diag.DiagCmd.Commands() {
+	// Build a separate diag tree, so the shortcut has its own flags
+	parent := diag.NewCommand(lscApp)
+	for _, c := range parent.Commands() {
 		if c.Name() == name {
-			realCmd = c
-			break
+			parent.RemoveCommand(c)
+			c.Aliases = aliases
+			if c.Annotations == nil {
+				c.Annotations = make(map[string]string)
+			}
+			c.Annotations[annotationPath] = "diag." + name
+			return c
 		}
 	}
-	if realCmd == nil {
-		return nil
-	}
+	return nil
+}
 
-	// Create shortcut with same properties
-	shortcut := &cobra.Command{
-		Use:                realCmd.Use,
-		Aliases:            aliases,
-		Short:              realCmd.Short,
-		Long:               realCmd.Long,
-		Args:               realCmd.Args,
-		ValidArgs:          realCmd.ValidArgs,
-		ValidArgsFunction:  realCmd.ValidArgsFunction,
-		Run:                realCmd.Run,
-		RunE:               realCmd.RunE,
-		PreRun:             realCmd.PreRun,
-		PreRunE:            realCmd.PreRunE,
-		PostRun:            realCmd.PostRun,
-		PostRunE:           realCmd.PostRunE,
-		PersistentPreRun:   realCmd.PersistentPreRun,
-		PersistentPreRunE:  realCmd.PersistentPreRunE,
-		PersistentPostRun:  realCmd.PersistentPostRun,
-		PersistentPostRunE: realCmd.PersistentPostRunE,
+// keepDashboardPower keeps 'lsc dashboard on|off' and its subcommands (ping,
+// status, ...) working now that 'lsc dashboard' without arguments is the live
+// view. They run as diag.dashboard for the access lists and the audit log.
+func keepDashboardPower(power *cobra.Command) {
+	var live *cobra.Command
+	for _, c := range rootCmd.Commands() {
+		if c.Name() == "dashboard" {
+			live = c
+		}
+	}
+	if live == nil {
+		return
 	}
 
-	// Copy flags
-	shortcut.Flags().AddFlagSet(realCmd.Flags())
-	shortcut.PersistentFlags().AddFlagSet(realCmd.PersistentFlags())
-
-	// Copy subcommands
-	for _, subcmd := range realCmd.Commands() {
-		shortcut.AddCommand(subcmd)
+	view := live.RunE
+	live.Use = "dashboard [on|off]"
+	live.Long += "\n\nWith on or off it switches the dashboard power instead, like 'lsc dbc'."
+	live.Args = cobra.MaximumNArgs(1)
+	live.ValidArgs = []string{"on", "off"}
+	live.RunE = func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return view(cmd, args)
+		}
+		accessPath = "diag.dashboard"
+		configureAudit()
+		return power.RunE(cmd, args)
 	}
 
-	return shortcut
+	// A separate diag tree, so the subcommands are not shared with dbc
+	if tree := createDiagShortcut("dashboard", nil); tree != nil {
+		for _, c := range tree.Commands() {
+			tree.RemoveCommand(c)
+			if c.Annotations == nil {
+				c.Annotations = make(map[string]string)
+			}
+			c.Annotations[annotationPath] = "diag.dashboard." + c.Name()
+			live.AddCommand(c)
+		}
+	}
 }
 
 // get shortcut (get setting)
 var getCmd = &cobra.Command{
-	Use:   "get <key>",
-	Short: "Get a setting value (shortcut for 'settings get')",
-	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		settingsGetCmd.Run(cmd, args)
+	Use:               "get <key>",
+	Short:             "Get a setting value (shortcut for 'settings get')",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSettingKeys,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return settingsGetCmd.RunE(cmd, args)
 	},
+	Annotations: map[string]string{annotationPath: "settings.get"},
 }
 
 // set shortcut (set setting)
 var setCmd = &cobra.Command{
-	Use:   "set <key> <value>",
-	Short: "Set a setting value (shortcut for 'settings set')",
-	Args:  cobra.ExactArgs(2),
-	Run: func(cmd *cobra.Command, args []string) {
-		settingsSetCmd.Run(cmd, args)
+	Use:               "set <key> <value>",
+	Short:             "Set a setting value (shortcut for 'settings set')",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeSettingValue,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return settingsSetCmd.RunE(cmd, args)
 	},
+	Annotations: map[string]string{annotationPath: "settings.set"},
 }
 
 // del shortcut (delete setting)
 var delCmd = &cobra.Command{
-	Use:   "del <key>",
-	Short: "Delete a setting key (shortcut for 'settings del')",
-	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
-		settingsDelCmd.Run(cmd, args)
+	Use:               "del <key>",
+	Short:             "Delete a setting key (shortcut for 'settings del')",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSettingKeys,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return settingsDelCmd.RunE(cmd, args)
 	},
+	Annotations: map[string]string{annotationPath: "settings.del"},
 }
 
 func init() {
@@ -114,6 +136,7 @@ func init() {
 	lockCmd.Flags().BoolVar(&noBlock, "no-block", false, "Don't wait for state change confirmation")
 	unlockCmd.Flags().BoolVar(&noBlock, "no-block", false, "Don't wait for state change confirmation")
 	openCmd.Flags().BoolVar(&noBlock, "no-block", false, "Don't wait for state change confirmation")
+	setCmd.Flags().BoolVar(&settingsForce, "force", false, "Set the value even if it does not match the setting's type")
 
 	// Add vehicle shortcut commands to root
 	rootCmd.AddCommand(lockCmd)
@@ -136,8 +159,11 @@ func init() {
 	if eventsCmd := createDiagShortcut("events", nil); eventsCmd != nil {
 		rootCmd.AddCommand(eventsCmd)
 	}
-	if dbcCmd := createDiagShortcut("dashboard", []string{"dbc", "dash"}); dbcCmd != nil {
+	if dbcCmd := createDiagShortcut("dashboard", []string{"dash"}); dbcCmd != nil {
+		// 'lsc dashboard' is the live view, so the shortcut goes by dbc
+		dbcCmd.Use = "dbc [on|off]"
 		rootCmd.AddCommand(dbcCmd)
+		keepDashboardPower(dbcCmd)
 	}
 	if engineCmd := createDiagShortcut("engine", nil); engineCmd != nil {
 		rootCmd.AddCommand(engineCmd)
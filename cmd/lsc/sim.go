@@ -0,0 +1,86 @@
+package lsc
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"librescoot/lsc/internal/output"
+	"librescoot/lsc/internal/sim"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	simDelay         time.Duration
+	simFaultInterval time.Duration
+	simRide          bool
+	simForce         bool
+)
+
+var simCmd = &cobra.Command{
+	Use:   "sim",
+	Short: "Simulate the scooter services on a local Redis",
+	Long: `Simulate the vehicle, battery, engine ECU, alarm and power manager services
+on a local Redis, so lsc can be tried and developed without a scooter.
+
+The simulator pops commands from the scooter:* lists, runs a simplified
+vehicle state machine (stand-by, parked, ready-to-drive), writes the state
+hashes and publishes the changed field names just like the real services.
+Run it in one terminal and use lsc against the same Redis in another:
+lock and unlock, alarm arm and disarm, blinkers, seatbox, dashboard and
+power commands all confirm as they do on a scooter.
+
+On start the service hashes and fault sets are overwritten with a locked,
+parked scooter. The simulator therefore refuses to run over --ssh, or on a
+Redis that holds a real scooter's state, unless --force is given.
+
+Examples:
+  redis-server --port 6379 &
+  lsc sim
+  lsc sim --ride --fault-interval 30s
+
+  lsc unlock
+  lsc alarm arm`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if sessionActive {
+			return output.InvalidArgument("sim cannot run inside an lsc shell or script")
+		}
+		if !simForce {
+			if sshTarget != "" {
+				return output.Rejected("refusing to simulate over --ssh, which usually reaches a real scooter (use --force to override)")
+			}
+			if env, err := lscApp.Redis.HGet("system", "environment"); err == nil && env != sim.Environment {
+				if version, _ := lscApp.Redis.HGet("system", "mdb-version"); version != "" {
+					return output.Rejected("Redis at %s holds the state of a real scooter (mdb %s); use --force to overwrite it", redisAddr, version)
+				}
+			}
+		}
+		if simFaultInterval < 0 {
+			return output.InvalidArgument("--fault-interval must not be negative")
+		}
+
+		ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+
+		fmt.Fprintf(os.Stderr, "Simulating a scooter on %s (Ctrl-C to stop)\n", redisAddr)
+		return sim.New(lscApp.Redis, sim.Options{
+			Delay:         simDelay,
+			FaultInterval: simFaultInterval,
+			Ride:          simRide,
+			Log:           os.Stderr,
+		}).Run(ctx)
+	},
+}
+
+func init() {
+	simCmd.Flags().DurationVar(&simDelay, "delay", 500*time.Millisecond, "How long the simulated hardware takes to react")
+	simCmd.Flags().DurationVar(&simFaultInterval, "fault-interval", 0, "Inject a random fault this often (0 disables faults)")
+	simCmd.Flags().BoolVar(&simRide, "ride", false, "Ride the scooter around whenever it is unlocked")
+	simCmd.Flags().BoolVar(&simForce, "force", false, "Run even over --ssh or on a real scooter's Redis")
+
+	rootCmd.AddCommand(simCmd)
+}
@@ -0,0 +1,56 @@
+package lsc
+
+import "testing"
+
+func TestStatus(t *testing.T) {
+	srv := newScooter(t)
+
+	res := mustRun(t, srv, "status")
+	assertContains(t, res.stdout,
+		"=== Vehicle Status ===",
+		"State:               parked",
+		"Brakes:              L:off R:on",
+		"Odometer:            1234.5 km",
+		"Current:             1.5 A",
+		"Charge:              87%",
+		"=== Battery 1 ===\n  Not Present",
+	)
+
+	data := jsonData(t, srv, "status")
+	for path, want := range map[string]interface{}{
+		"vehicle.state":            "parked",
+		"vehicle.brakes.right":     "on",
+		"motor.odometer_km":        1234.5,
+		"motor.voltage_v":          54.0,
+		"motor.kers":               true,
+		"battery_0.charge_percent": 87.0,
+		"battery_0.current_a":      -1.2,
+		"battery_1.present":        false,
+	} {
+		if got := lookup(t, data, path); got != want {
+			t.Errorf("%s = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestStatusFields(t *testing.T) {
+	srv := newScooter(t)
+
+	res := mustRun(t, srv, "status", "--output", "csv", "--fields", "vehicle.state,battery_0.charge_percent")
+	if want := "field,value\nvehicle.state,parked\nbattery_0.charge_percent,87\n"; res.stdout != want {
+		t.Errorf("csv output = %q, want %q", res.stdout, want)
+	}
+}
+
+func TestStatusConnectionError(t *testing.T) {
+	srv := newScooter(t)
+	addr := srv.Addr()
+	srv.Close()
+
+	res := runAt(t, addr, "status", "--json")
+	assertCode(t, res.err, "connection")
+	envelope := decodeResult(t, res)
+	if envelope.Status != "error" || envelope.Error == nil || envelope.Error.Code != "connection" {
+		t.Errorf("unexpected envelope %+v", envelope)
+	}
+}
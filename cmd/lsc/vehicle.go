@@ -2,13 +2,12 @@ package lsc
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"os"
 	"time"
 
 	"librescoot/lsc/internal/confirm"
 	"librescoot/lsc/internal/format"
+	"librescoot/lsc/internal/policy"
 
 	"github.com/spf13/cobra"
 )
@@ -24,76 +23,79 @@ Note: Handlebar lock/unlock is automatic and controlled by the vehicle state mac
 It unlocks when the vehicle is unlocked and locks when locked.`,
 }
 
-var vehicleLockCmd = &cobra.Command{
-	Use:   "lock",
-	Short: "Lock the scooter",
-	Long:  `Lock the scooter and transition to stand-by state.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		if !JSONOutput {
-			fmt.Println("Locking scooter...")
-		}
-
-		if noBlock {
-			// Send lock command without waiting
-			if err := redisClient.LPush("scooter:state", "lock"); err != nil {
-				if JSONOutput {
-					output, _ := json.Marshal(map[string]interface{}{
-						"command": "lock",
-						"status":  "error",
-						"error":   err.Error(),
-					})
-					fmt.Println(string(output))
-				} else {
-					fmt.Fprintf(os.Stderr, format.Error("Failed to send lock command: %v\n"), err)
-				}
-				return
-			}
-
-			if JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "lock",
-					"status":  "sent",
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Println(format.Success("Lock command sent"))
-			}
-			return
-		}
-
-		// Wait for state to change to stand-by
-		// Subscribe first, then send command to avoid missing the notification
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
+// vehicleCommand is a command sent to the vehicle service and how its
+// progress and outcome are reported
+type vehicleCommand struct {
+	confirm.Command
+	// starting is printed before the command is sent, sent when it is sent
+	// with --no-block
+	starting string
+	sent     string
+	// failed names the command in confirmation errors
+	failed string
+	// key is the JSON key of the confirmed value
+	key  string
+	done func(value string) string
+}
 
-		err := confirm.WaitForFieldValueAfterCommand(ctx, redisClient, "vehicle", "state", "stand-by", 10*time.Second, func() error {
-			return redisClient.LPush("scooter:state", "lock")
+// run sends the command and, unless --no-block is set, waits for the vehicle
+// to confirm it
+func (v vehicleCommand) run(ctx context.Context) error {
+	if !lscApp.Structured() {
+		fmt.Println(v.starting)
+	}
+
+	if noBlock {
+		if err := lscApp.Send(ctx, v.Command); err != nil {
+			return fmt.Errorf("failed to send %s command: %w", v.Payload, err)
+		}
+		return lscApp.Render(map[string]interface{}{
+			"confirmed": false,
+		}, func() {
+			fmt.Println(format.Success(v.sent))
 		})
+	}
+
+	v.Timeout = confirmTimeout(v.Timeout)
+	result, err := lscApp.Confirm(ctx, v.Command)
+	if err != nil {
+		return fmt.Errorf("failed to confirm %s: %w", v.failed, err)
+	}
+
+	return lscApp.Render(map[string]interface{}{
+		"confirmed": true,
+		v.key:       result.Value,
+	}, func() {
+		fmt.Println(format.Success(v.done(result.Value)))
+	})
+}
 
-		if err != nil {
-			if JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "lock",
-					"status":  "timeout",
-					"error":   err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Warning("Lock command sent but state confirmation timed out\n"))
-			}
-			return
-		}
+// vehicleStateCommand returns a command for the vehicle state machine,
+// confirmed by the vehicle entering one of the accepted states
+func vehicleStateCommand(payload string, accept ...string) confirm.Command {
+	return confirm.Command{
+		List:    "scooter:state",
+		Payload: payload,
+		Hash:    "vehicle",
+		Field:   "state",
+		Accept:  accept,
+		Timeout: 10 * time.Second,
+	}
+}
 
-		if JSONOutput {
-			output, _ := json.Marshal(map[string]interface{}{
-				"command": "lock",
-				"status":  "success",
-				"state":   "stand-by",
-			})
-			fmt.Println(string(output))
-		} else {
-			fmt.Println(format.Success("Scooter locked successfully"))
-		}
+var vehicleLockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Lock the scooter",
+	Long:  `Lock the scooter and transition to stand-by state.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return vehicleCommand{
+			Command:  vehicleStateCommand("lock", "stand-by"),
+			starting: "Locking scooter...",
+			sent:     "Lock command sent",
+			failed:   "lock",
+			key:      "state",
+			done:     func(string) string { return "Scooter locked successfully" },
+		}.run(cmd.Context())
 	},
 }
 
@@ -101,102 +103,21 @@ var vehicleUnlockCmd = &cobra.Command{
 	Use:   "unlock",
 	Short: "Unlock the scooter",
 	Long:  `Unlock the scooter and transition to parked or ready-to-drive state.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		if !JSONOutput {
-			fmt.Println("Unlocking scooter...")
-		}
-
-		if noBlock {
-			// Send unlock command without waiting
-			if err := redisClient.LPush("scooter:state", "unlock"); err != nil {
-				if JSONOutput {
-					output, _ := json.Marshal(map[string]interface{}{
-						"command": "unlock",
-						"status":  "error",
-						"error":   err.Error(),
-					})
-					fmt.Println(string(output))
-				} else {
-					fmt.Fprintf(os.Stderr, format.Error("Failed to send unlock command: %v\n"), err)
-				}
-				return
-			}
-
-			if JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "unlock",
-					"status":  "sent",
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Println(format.Success("Unlock command sent"))
-			}
-			return
-		}
-
-		// Wait for state to change (could be parked or ready-to-drive)
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-
-		// Subscribe BEFORE sending command to avoid missing the notification
-		pubsub := redisClient.Subscribe(ctx, "vehicle")
-		defer pubsub.Close()
-
-		ch := pubsub.Channel()
-
-		// Give subscription a moment to establish
-		time.Sleep(100 * time.Millisecond)
-
-		// Send unlock command after subscription is established
-		if err := redisClient.LPush("scooter:state", "unlock"); err != nil {
-			if JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "unlock",
-					"status":  "error",
-					"error":   err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Error("Failed to send unlock command: %v\n"), err)
-			}
-			return
-		}
-
-		timeout := time.After(10 * time.Second)
-
-		for {
-			select {
-			case <-timeout:
-				if JSONOutput {
-					output, _ := json.Marshal(map[string]interface{}{
-						"command": "unlock",
-						"status":  "timeout",
-					})
-					fmt.Println(string(output))
-				} else {
-					fmt.Fprintf(os.Stderr, format.Warning("Unlock command sent but state confirmation timed out\n"))
-				}
-				return
-			case msg := <-ch:
-				if msg.Payload == "state" {
-					// Check current state
-					state, err := redisClient.HGet("vehicle", "state")
-					if err == nil && (state == "parked" || state == "ready-to-drive") {
-						if JSONOutput {
-							output, _ := json.Marshal(map[string]interface{}{
-								"command": "unlock",
-								"status":  "success",
-								"state":   state,
-							})
-							fmt.Println(string(output))
-						} else {
-							fmt.Println(format.Success(fmt.Sprintf("Scooter unlocked successfully (state: %s)", state)))
-						}
-						return
-					}
-				}
-			}
-		}
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Unlocking a parked scooter leaves it parked, so only a state the
+		// vehicle service publishes in response confirms the command
+		unlock := vehicleStateCommand("unlock", "parked", "ready-to-drive")
+		unlock.Published = true
+		return vehicleCommand{
+			Command:  unlock,
+			starting: "Unlocking scooter...",
+			sent:     "Unlock command sent",
+			failed:   "unlock",
+			key:      "state",
+			done: func(state string) string {
+				return fmt.Sprintf("Scooter unlocked successfully (state: %s)", state)
+			},
+		}.run(cmd.Context())
 	},
 }
 
@@ -204,145 +125,37 @@ var vehicleHibernateCmd = &cobra.Command{
 	Use:   "hibernate",
 	Short: "Lock and request hibernation",
 	Long:  `Lock the scooter and request the system to enter hibernation mode.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		if !JSONOutput {
-			fmt.Println("Requesting hibernation...")
-		}
-
-		if noBlock {
-			// Send hibernate command without waiting
-			if err := redisClient.LPush("scooter:state", "lock-hibernate"); err != nil {
-				if JSONOutput {
-					output, _ := json.Marshal(map[string]interface{}{
-						"command": "hibernate",
-						"status":  "error",
-						"error":   err.Error(),
-					})
-					fmt.Println(string(output))
-				} else {
-					fmt.Fprintf(os.Stderr, format.Error("Failed to send hibernate command: %v\n"), err)
-				}
-				return
-			}
-
-			if JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "hibernate",
-					"status":  "sent",
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Println(format.Success("Hibernate command sent"))
-			}
-			return
-		}
-
-		// Wait for state to change to stand-by
-		// Subscribe first, then send command to avoid missing the notification
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-
-		err := confirm.WaitForFieldValueAfterCommand(ctx, redisClient, "vehicle", "state", "stand-by", 10*time.Second, func() error {
-			return redisClient.LPush("scooter:state", "lock-hibernate")
-		})
-
-		if err != nil {
-			if JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "hibernate",
-					"status":  "timeout",
-					"error":   err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Warning("Hibernate command sent but state confirmation timed out\n"))
-			}
-			return
-		}
-
-		if JSONOutput {
-			output, _ := json.Marshal(map[string]interface{}{
-				"command": "hibernate",
-				"status":  "success",
-				"state":   "stand-by",
-			})
-			fmt.Println(string(output))
-		} else {
-			fmt.Println(format.Success("Hibernation requested successfully"))
-		}
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return vehicleCommand{
+			Command:  vehicleStateCommand("lock-hibernate", "stand-by"),
+			starting: "Requesting hibernation...",
+			sent:     "Hibernate command sent",
+			failed:   "hibernation",
+			key:      "state",
+			done:     func(string) string { return "Hibernation requested successfully" },
+		}.run(cmd.Context())
 	},
 }
 
+// forceLockForce overrides the state checks of force-lock
+var forceLockForce bool
+
 var vehicleForceLockCmd = &cobra.Command{
 	Use:   "force-lock",
 	Short: "Force lock without physical locking",
 	Long:  `Force the scooter into stand-by state without waiting for physical locks to engage. Use with caution.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		if !JSONOutput {
-			fmt.Println("Force locking scooter...")
-		}
-
-		if noBlock {
-			// Send force-lock command without waiting
-			if err := redisClient.LPush("scooter:state", "force-lock"); err != nil {
-				if JSONOutput {
-					output, _ := json.Marshal(map[string]interface{}{
-						"command": "force-lock",
-						"status":  "error",
-						"error":   err.Error(),
-					})
-					fmt.Println(string(output))
-				} else {
-					fmt.Fprintf(os.Stderr, format.Error("Failed to send force-lock command: %v\n"), err)
-				}
-				return
-			}
-
-			if JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "force-lock",
-					"status":  "sent",
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Println(format.Success("Force-lock command sent"))
-			}
-			return
-		}
-
-		// Wait for state to change to stand-by
-		// Subscribe first, then send command to avoid missing the notification
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-
-		err := confirm.WaitForFieldValueAfterCommand(ctx, redisClient, "vehicle", "state", "stand-by", 10*time.Second, func() error {
-			return redisClient.LPush("scooter:state", "force-lock")
-		})
-
-		if err != nil {
-			if JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "force-lock",
-					"status":  "timeout",
-					"error":   err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Warning("Force-lock command sent but state confirmation timed out\n"))
-			}
-			return
-		}
-
-		if JSONOutput {
-			output, _ := json.Marshal(map[string]interface{}{
-				"command": "force-lock",
-				"status":  "success",
-				"state":   "stand-by",
-			})
-			fmt.Println(string(output))
-		} else {
-			fmt.Println(format.Success("Scooter force-locked successfully"))
-		}
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := lscApp.Guard(cmd.Context(), "force-lock the scooter", forceLockForce, policy.Riding...); err != nil {
+			return err
+		}
+		return vehicleCommand{
+			Command:  vehicleStateCommand("force-lock", "stand-by"),
+			starting: "Force locking scooter...",
+			sent:     "Force-lock command sent",
+			failed:   "force-lock",
+			key:      "state",
+			done:     func(string) string { return "Scooter force-locked successfully" },
+		}.run(cmd.Context())
 	},
 }
 
@@ -351,71 +164,22 @@ var vehicleOpenCmd = &cobra.Command{
 	Aliases: []string{"open-seatbox"},
 	Short:   "Open the seatbox",
 	Long:    `Send command to open the seatbox lock.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		if !JSONOutput {
-			fmt.Println("Opening seatbox...")
-		}
-
-		if noBlock {
-			// Send open command without waiting
-			if err := redisClient.LPush("scooter:seatbox", "open"); err != nil {
-				if JSONOutput {
-					output, _ := json.Marshal(map[string]interface{}{
-						"command": "open",
-						"status":  "error",
-						"error":   err.Error(),
-					})
-					fmt.Println(string(output))
-				} else {
-					fmt.Fprintf(os.Stderr, format.Error("Failed to send seatbox open command: %v\n"), err)
-				}
-				return
-			}
-
-			if JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "open",
-					"status":  "sent",
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Println(format.Success("Seatbox open command sent"))
-			}
-			return
-		}
-
-		// Wait briefly for lock state to change to open
-		// Subscribe first, then send command to avoid missing the notification
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-
-		err := confirm.WaitForFieldValueAfterCommand(ctx, redisClient, "vehicle", "seatbox:lock", "open", 5*time.Second, func() error {
-			return redisClient.LPush("scooter:seatbox", "open")
-		})
-
-		if err != nil {
-			if JSONOutput {
-				output, _ := json.Marshal(map[string]interface{}{
-					"command": "open",
-					"status":  "timeout",
-					"error":   err.Error(),
-				})
-				fmt.Println(string(output))
-			} else {
-				fmt.Fprintf(os.Stderr, format.Warning("Seatbox command sent but lock confirmation timed out\n"))
-			}
-			return
-		}
-
-		if JSONOutput {
-			output, _ := json.Marshal(map[string]interface{}{
-				"command": "open",
-				"status":  "success",
-			})
-			fmt.Println(string(output))
-		} else {
-			fmt.Println(format.Success("Seatbox opened successfully"))
-		}
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return vehicleCommand{
+			Command: confirm.Command{
+				List:    "scooter:seatbox",
+				Payload: "open",
+				Hash:    "vehicle",
+				Field:   "seatbox:lock",
+				Accept:  []string{"open"},
+				Timeout: 5 * time.Second,
+			},
+			starting: "Opening seatbox...",
+			sent:     "Seatbox open command sent",
+			failed:   "seatbox opening",
+			key:      "seatbox_lock",
+			done:     func(string) string { return "Seatbox opened successfully" },
+		}.run(cmd.Context())
 	},
 }
 
@@ -423,6 +187,8 @@ func init() {
 	// Add --no-block flag to all vehicle commands
 	vehicleCmd.PersistentFlags().BoolVar(&noBlock, "no-block", false, "Don't wait for state change confirmation")
 
+	vehicleForceLockCmd.Flags().BoolVar(&forceLockForce, "force", false, "Force-lock even while the scooter is ridden")
+
 	// Add subcommands
 	vehicleCmd.AddCommand(vehicleLockCmd)
 	vehicleCmd.AddCommand(vehicleUnlockCmd)
@@ -0,0 +1,136 @@
+package lsc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVehicleUnlockAndLock(t *testing.T) {
+	srv := newScooter(t)
+	startSim(t, srv)
+
+	res := mustRun(t, srv, "vehicle", "unlock")
+	assertContains(t, res.stdout, "Unlocking scooter...", "Scooter unlocked successfully (state: parked)")
+	if state := srv.HGet("vehicle", "state"); state != "parked" {
+		t.Errorf("state after unlock = %s, want parked", state)
+	}
+
+	data := jsonData(t, srv, "vehicle", "lock")
+	if data["confirmed"] != true || data["state"] != "stand-by" {
+		t.Errorf("lock data = %v", data)
+	}
+
+	data = jsonData(t, srv, "vehicle", "unlock")
+	if data["confirmed"] != true || data["state"] != "parked" {
+		t.Errorf("unlock data = %v", data)
+	}
+
+	res = mustRun(t, srv, "vehicle", "lock")
+	assertContains(t, res.stdout, "Locking scooter...", "state: shutting-down", "Scooter locked successfully")
+}
+
+func TestVehicleForceLockAndHibernate(t *testing.T) {
+	srv := newScooter(t)
+	startSim(t, srv)
+
+	mustRun(t, srv, "vehicle", "unlock")
+	res := mustRun(t, srv, "vehicle", "force-lock")
+	assertContains(t, res.stdout, "Scooter force-locked successfully")
+
+	mustRun(t, srv, "vehicle", "unlock")
+	data := jsonData(t, srv, "vehicle", "hibernate")
+	if data["confirmed"] != true || data["state"] != "stand-by" {
+		t.Errorf("hibernate data = %v", data)
+	}
+}
+
+func TestVehicleOpenSeatbox(t *testing.T) {
+	srv := newScooter(t)
+	startSim(t, srv)
+
+	res := mustRun(t, srv, "vehicle", "open")
+	assertContains(t, res.stdout, "Seatbox opened successfully")
+
+	// The seatbox stays open for a while, so start over with a closed one
+	srv = newScooter(t)
+	startSim(t, srv)
+	data := jsonData(t, srv, "vehicle", "open")
+	if data["confirmed"] != true || data["seatbox_lock"] != "open" {
+		t.Errorf("open data = %v", data)
+	}
+}
+
+func TestVehicleNoBlock(t *testing.T) {
+	srv := newScooter(t)
+
+	for _, tc := range []struct {
+		args  []string
+		list  string
+		value string
+	}{
+		{[]string{"vehicle", "lock"}, "scooter:state", "lock"},
+		{[]string{"vehicle", "unlock"}, "scooter:state", "unlock"},
+		{[]string{"vehicle", "force-lock"}, "scooter:state", "force-lock"},
+		{[]string{"vehicle", "hibernate"}, "scooter:state", "lock-hibernate"},
+		{[]string{"vehicle", "open"}, "scooter:seatbox", "open"},
+	} {
+		srv.Del(tc.list)
+		data := jsonData(t, srv, append(tc.args, "--no-block")...)
+		if data["confirmed"] != false {
+			t.Errorf("%v: data = %v", tc.args, data)
+		}
+		if list, _ := srv.List(tc.list); !reflect.DeepEqual(list, []string{tc.value}) {
+			t.Errorf("%v: %s = %v, want [%s]", tc.args, tc.list, list, tc.value)
+		}
+	}
+}
+
+func TestVehicleConfirmationTimeout(t *testing.T) {
+	srv := newScooter(t)
+	srv.HSet("vehicle", "state", "parked")
+
+	for _, tc := range []struct {
+		args    []string
+		message string
+	}{
+		{[]string{"vehicle", "lock"}, "failed to confirm lock"},
+		{[]string{"vehicle", "force-lock"}, "failed to confirm force-lock"},
+		{[]string{"vehicle", "hibernate"}, "failed to confirm hibernation"},
+		{[]string{"vehicle", "open"}, "failed to confirm seatbox opening"},
+		{[]string{"vehicle", "unlock"}, "unlock command sent but state confirmation timed out"},
+	} {
+		// Without a service the command stays queued, so start from empty lists
+		srv.Del("scooter:state")
+		srv.Del("scooter:seatbox")
+		res := runLSC(t, srv, append([]string{"--command-timeout", "200ms"}, tc.args...)...)
+		assertCode(t, res.err, "timeout")
+		assertContains(t, res.stderr, tc.message)
+
+		srv.Del("scooter:state")
+		srv.Del("scooter:seatbox")
+		res = runLSC(t, srv, append([]string{"--command-timeout", "200ms", "--json"}, tc.args...)...)
+		assertCode(t, res.err, "timeout")
+		envelope := decodeResult(t, res)
+		if envelope.Status != "error" || envelope.Error == nil || envelope.Error.Code != "timeout" {
+			t.Errorf("%v: envelope = %+v", tc.args, envelope)
+		}
+	}
+}
+
+func TestVehicleForceLockWhileRidden(t *testing.T) {
+	srv := newScooter(t)
+	srv.HSet("vehicle", "state", "ready-to-drive")
+	srv.HSet("vehicle", "kickstand", "up")
+	srv.HSet("engine-ecu", "speed", "23")
+
+	res := runLSC(t, srv, "vehicle", "force-lock")
+	assertCode(t, res.err, "precondition_failed")
+	assertContains(t, res.stderr, "scooter is moving at 23 km/h", "vehicle is ready to drive", "kickstand is up", "--force")
+
+	// Handlebar and engine commands are guarded the same way
+	res = runLSC(t, srv, "diag", "handlebar", "lock")
+	assertCode(t, res.err, "precondition_failed")
+	res = runLSC(t, srv, "diag", "engine", "off")
+	assertCode(t, res.err, "precondition_failed")
+	mustRun(t, srv, "diag", "engine", "on")
+}
@@ -8,9 +8,9 @@ import (
 	"os/signal"
 	"regexp"
 	"syscall"
-	"time"
 
 	"librescoot/lsc/internal/format"
+	"librescoot/lsc/internal/output"
 
 	"github.com/spf13/cobra"
 )
@@ -32,8 +32,9 @@ Examples:
   # Watch multiple channels
   lsc watch vehicle alarm battery:0
 
-  # Watch sensors with JSON output
+  # Watch sensors with JSON output (one object per line)
   lsc watch bmx:sensors --format=json
+  lsc watch bmx:sensors --json
 
   # Watch and filter messages
   lsc watch vehicle --filter="state|lock"
@@ -52,22 +53,32 @@ Useful channels:
   dashboard        - Dashboard status
   settings         - Settings changes`,
 	Args: cobra.MinimumNArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		channels := args
 
+		// --output/--json select JSON lines unless --format says otherwise
+		if lscApp.Structured() && !cmd.Flags().Changed("format") {
+			watchFormat = "json"
+		}
+
+		switch watchFormat {
+		case "pretty", "json", "raw":
+		default:
+			return output.InvalidArgument("invalid format '%s': must be pretty, json or raw", watchFormat)
+		}
+
 		// Compile filter regex if provided
 		var filterRegex *regexp.Regexp
 		if watchFilter != "" {
 			var err error
 			filterRegex, err = regexp.Compile(watchFilter)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, format.Error("Invalid filter regex: %v\n"), err)
-				return
+				return output.InvalidArgument("invalid filter regex: %v", err)
 			}
 		}
 
 		// Create context that can be cancelled
-		ctx, cancel := context.WithCancel(context.Background())
+		ctx, cancel := context.WithCancel(cmd.Context())
 		defer cancel()
 
 		// Handle Ctrl+C gracefully
@@ -80,7 +91,7 @@ Useful channels:
 		}()
 
 		// Subscribe to channels
-		pubsub := redisClient.Subscribe(ctx, channels...)
+		pubsub := lscApp.Redis.Subscribe(ctx, channels...)
 		defer pubsub.Close()
 
 		// Print header
@@ -94,7 +105,7 @@ Useful channels:
 		for {
 			select {
 			case <-ctx.Done():
-				return
+				return nil
 			case msg := <-ch:
 				// Apply filter if provided
 				if filterRegex != nil {
@@ -119,7 +130,7 @@ Useful channels:
 }
 
 func printPretty(channel, payload string) {
-	timestamp := time.Now().Format("15:04:05.000")
+	timestamp := lscApp.Now().Format("15:04:05.000")
 	fmt.Printf("[%s] [%s] %s\n",
 		format.Dim(timestamp),
 		format.Info(channel),
@@ -127,8 +138,15 @@ func printPretty(channel, payload string) {
 }
 
 func printJSON(channel, payload string) {
-	output := map[string]interface{}{
-		"timestamp": time.Now().Unix(),
+	jsonBytes, _ := json.Marshal(watchEvent(channel, payload))
+	fmt.Println(string(jsonBytes))
+}
+
+// watchEvent is the JSON form of a pub/sub message. JSON payloads are embedded
+// as objects.
+func watchEvent(channel, payload string) map[string]interface{} {
+	event := map[string]interface{}{
+		"timestamp": lscApp.Now().Unix(),
 		"channel":   channel,
 		"payload":   payload,
 	}
@@ -136,11 +154,9 @@ func printJSON(channel, payload string) {
 	// Try to parse payload as JSON
 	var payloadJSON interface{}
 	if err := json.Unmarshal([]byte(payload), &payloadJSON); err == nil {
-		output["payload"] = payloadJSON
+		event["payload"] = payloadJSON
 	}
-
-	jsonBytes, _ := json.Marshal(output)
-	fmt.Println(string(jsonBytes))
+	return event
 }
 
 func init() {
@@ -0,0 +1,190 @@
+// Package app holds the dependencies lsc commands run with: the Redis client,
+// the result renderer, the clock and the runner for system commands.
+package app
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"librescoot/lsc/internal/audit"
+	"librescoot/lsc/internal/confirm"
+	"librescoot/lsc/internal/format"
+	"librescoot/lsc/internal/output"
+	"librescoot/lsc/internal/policy"
+	"librescoot/lsc/internal/redis"
+	"librescoot/lsc/internal/runner"
+
+	"golang.org/x/term"
+)
+
+// App is passed to the command constructors. The root command fills in
+// Redis and Runner once it has connected, before any command runs.
+type App struct {
+	// Redis is the connection to the scooter's Redis
+	Redis *redis.Client
+	// Runner runs system commands such as systemctl, locally or over SSH
+	Runner runner.Runner
+	// Output renders command results in the selected format
+	Output *output.Renderer
+	// Clock returns the current time
+	Clock func() time.Time
+	// QueueService returns the systemd unit reading a command list, or ""
+	QueueService func(list string) string
+	// Ask asks the user a yes/no question; it returns false when nobody
+	// can answer
+	Ask func(question string) bool
+	// Authorize refuses actions that change the scooter when read-only mode
+	// or the access lists deny the running command. Redis writes and
+	// systemctl are checked without asking; commands call it for anything else.
+	Authorize func(action string) error
+	// AuthorizeAs is Authorize for an action checked as the command at the
+	// dotted path, for commands such as the MQTT bridge that run the actions
+	// of other commands
+	AuthorizeAs func(path, action string) error
+	// Audit records the operations that change the scooter; Redis writes
+	// and systemctl are recorded by the root command, commands call Record
+	// for anything else
+	Audit *audit.Log
+}
+
+// New returns an App with its own result renderer, running system commands
+// locally and using the wall clock
+func New() *App {
+	return &App{
+		Runner: runner.Local{},
+		Output: output.NewRenderer(),
+		Clock:  time.Now,
+		Ask:    askTerminal,
+	}
+}
+
+// askTerminal asks on the terminal, if stdin is one
+func askTerminal(question string) bool {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false
+	}
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// Now returns the current time of the App's clock
+func (a *App) Now() time.Time {
+	return a.Clock()
+}
+
+// Structured reports whether results are printed in a machine-readable
+// format, in which case progress messages meant for humans are suppressed
+func (a *App) Structured() bool {
+	return a.Output.Structured()
+}
+
+// Render prints a successful result; pretty prints it for humans
+func (a *App) Render(data interface{}, pretty func()) error {
+	return a.Output.Render(data, pretty)
+}
+
+// Send pushes a command onto a service's command list without waiting for
+// it to be carried out
+func (a *App) Send(ctx context.Context, cmd confirm.Command) error {
+	result, err := confirm.Send(ctx, a.Redis, a.withService(cmd))
+	if err != nil {
+		return err
+	}
+	if result.Warning != "" {
+		fmt.Fprintln(os.Stderr, format.Warning("Warning: "+result.Warning))
+	}
+	return nil
+}
+
+// Confirm pushes a command and waits for the state change that confirms it.
+// For humans, the intermediate states are printed as they are seen.
+func (a *App) Confirm(ctx context.Context, cmd confirm.Command) (*confirm.Result, error) {
+	if cmd.Progress == nil && !a.Structured() {
+		cmd.Progress = func(value string) {
+			fmt.Println(format.Dim(fmt.Sprintf("  %s: %s", cmd.Field, value)))
+		}
+	}
+	return confirm.Run(ctx, a.Redis, a.withService(cmd))
+}
+
+// Allow checks that the running command may perform action, e.g.
+// "install an update"
+func (a *App) Allow(action string) error {
+	if a.Authorize == nil {
+		return nil
+	}
+	return a.Authorize(action)
+}
+
+// AllowAs checks that the command at path, e.g. "power.reboot", may perform
+// action
+func (a *App) AllowAs(path, action string) error {
+	if a.AuthorizeAs == nil {
+		return nil
+	}
+	return a.AuthorizeAs(path, action)
+}
+
+// Record adds an operation with its outcome err to the audit log. The log
+// failing is reported as a warning, the operation has been done already.
+func (a *App) Record(r audit.Record, err error) {
+	if a.Audit == nil {
+		return
+	}
+	if err := a.Audit.Write(r, err); err != nil {
+		fmt.Fprintln(os.Stderr, format.Warning("Warning: "+err.Error()))
+	}
+}
+
+// Guard checks the scooter's state before a command that is unsafe under
+// conditions. If any holds, the command is refused unless force is set or
+// the user confirms it on the terminal.
+func (a *App) Guard(ctx context.Context, command string, force bool, conditions ...policy.Condition) error {
+	state, err := policy.Read(ctx, a.Redis)
+	if err != nil {
+		return err
+	}
+	violations := state.Check(conditions...)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	if force {
+		if !a.Structured() {
+			for _, v := range violations {
+				fmt.Fprintln(os.Stderr, format.Warning("Warning: "+v.Reason))
+			}
+		}
+		return nil
+	}
+	if !a.Structured() && a.Ask != nil {
+		reasons := make([]string, len(violations))
+		for i, v := range violations {
+			reasons[i] = v.Reason
+		}
+		question := fmt.Sprintf("%s. %s anyway?", capitalize(strings.Join(reasons, ", ")), capitalize(command))
+		if a.Ask(format.Warning(question)) {
+			return nil
+		}
+	}
+	return policy.Blocked(command, violations)
+}
+
+// capitalize upper-cases the first letter of s
+func capitalize(s string) string {
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// withService fills in the systemd unit reading the command's list
+func (a *App) withService(cmd confirm.Command) confirm.Command {
+	if cmd.Service == "" && cmd.List != "" && a.QueueService != nil {
+		cmd.Service = a.QueueService(cmd.List)
+	}
+	return cmd
+}
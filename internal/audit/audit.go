@@ -0,0 +1,189 @@
+// Package audit records the operations lsc performs that change the scooter:
+// commands pushed to its services, settings changes, systemctl and update
+// installations. Records are appended to a local JSONL file and optionally to
+// a Redis stream.
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"librescoot/lsc/internal/redis"
+)
+
+// StreamLength is about the number of records kept in the Redis stream
+const StreamLength = 10000
+
+// Record is one audited operation
+type Record struct {
+	Time      time.Time `json:"time"`
+	User      string    `json:"user"`
+	SSHClient string    `json:"ssh_client,omitempty"` // address of the SSH client lsc runs for
+	Profile   string    `json:"profile,omitempty"`
+	Target    string    `json:"target,omitempty"`  // --ssh host or Redis address
+	Command   string    `json:"command,omitempty"` // lsc command path, e.g. vehicle.lock
+	Operation string    `json:"operation"`         // LPUSH, HSET, HDEL, DEL, systemctl or mender-update
+	Key       string    `json:"key"`               // list, hash, unit or file
+	Field     string    `json:"field,omitempty"`   // hash field
+	Payload   string    `json:"payload,omitempty"` // pushed command, value or verb
+	Result    string    `json:"result"`            // ok or error
+	Error     string    `json:"error,omitempty"`
+}
+
+// Log appends records to the JSONL file at Path and, if Stream and Redis are
+// set, to that Redis stream
+type Log struct {
+	Path   string
+	Stream string
+	Redis  *redis.Client
+	Clock  func() time.Time
+
+	// Base holds the fields shared by every record of the running command
+	Base Record
+}
+
+// Session returns the Unix user and SSH client address lsc runs for
+func Session() (userName, sshClient string) {
+	if u, err := user.Current(); err == nil {
+		userName = u.Username
+	} else {
+		userName = os.Getenv("USER")
+	}
+	if fields := strings.Fields(os.Getenv("SSH_CLIENT")); len(fields) > 0 {
+		sshClient = fields[0]
+	}
+	return userName, sshClient
+}
+
+// Write records the outcome err of an operation described by r
+func (l *Log) Write(r Record, err error) error {
+	r.Time = l.Clock()
+	r.User, r.SSHClient = l.Base.User, l.Base.SSHClient
+	r.Profile, r.Target, r.Command = l.Base.Profile, l.Base.Target, l.Base.Command
+	r.Result = "ok"
+	if err != nil {
+		r.Result = "error"
+		r.Error = err.Error()
+	}
+
+	if err := l.append(r); err != nil {
+		return fmt.Errorf("failed to write audit log %s: %w", l.Path, err)
+	}
+	if l.Stream != "" && l.Redis != nil {
+		if _, err := l.Redis.XAddWithContext(context.Background(), l.Stream, StreamLength, r.values()); err != nil {
+			return fmt.Errorf("failed to add to audit stream %s: %w", l.Stream, err)
+		}
+	}
+	return nil
+}
+
+func (l *Log) append(r Record) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(l.Path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// values returns the record as stream entry fields, named like the JSON fields
+func (r Record) values() map[string]interface{} {
+	var fields map[string]interface{}
+	data, _ := json.Marshal(r)
+	json.Unmarshal(data, &fields)
+	return fields
+}
+
+// FromRedis describes a Redis write, given with the command name first, if
+// the audit log covers it: pushes to and deletes of scooter:* command lists
+// and changes to the settings hash
+func FromRedis(args []string) (Record, bool) {
+	if len(args) < 2 {
+		return Record{}, false
+	}
+	r := Record{Operation: args[0], Key: args[1]}
+	switch {
+	case (r.Operation == "LPUSH" || r.Operation == "RPUSH") && strings.HasPrefix(r.Key, "scooter:"):
+		r.Payload = strings.Join(args[2:], " ")
+	case r.Operation == "DEL" && strings.HasPrefix(r.Key, "scooter:"):
+		r.Key = strings.Join(args[1:], " ")
+	case r.Operation == "HSET" && r.Key == "settings" && len(args) >= 4:
+		r.Field = args[2]
+		r.Payload = strings.Join(args[3:], " ")
+	case r.Operation == "HDEL" && r.Key == "settings":
+		r.Field = strings.Join(args[2:], " ")
+	default:
+		return Record{}, false
+	}
+	return r, true
+}
+
+// ReadFile returns the records of the JSONL file at path written at or after
+// since. A missing file holds no records.
+func ReadFile(path string, since time.Time) ([]Record, error) {
+	records := make([]Record, 0)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		if !r.Time.Before(since) {
+			records = append(records, r)
+		}
+	}
+	return records, scanner.Err()
+}
+
+// ReadStream returns the records of a Redis stream added at or after since
+func ReadStream(ctx context.Context, client *redis.Client, stream string, since time.Time) ([]Record, error) {
+	start := "-"
+	if !since.IsZero() {
+		start = strconv.FormatInt(since.UnixMilli(), 10)
+	}
+	messages, err := client.XRangeWithContext(ctx, stream, start, "+")
+	if err != nil {
+		return nil, err
+	}
+	records := make([]Record, 0, len(messages))
+	for _, msg := range messages {
+		data, _ := json.Marshal(msg.Values)
+		var r Record
+		if err := json.Unmarshal(data, &r); err != nil {
+			return nil, fmt.Errorf("invalid entry %s in %s: %w", msg.ID, stream, err)
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
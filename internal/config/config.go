@@ -0,0 +1,446 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// SystemPath is the system-wide configuration file
+const SystemPath = "/etc/lsc.conf"
+
+// NoProfile is the profile name that selects no profile, not even the
+// default one
+const NoProfile = "-"
+
+// Profile holds connection and output defaults for a single scooter. DB and
+// TLS are nil when not set, so a file can set them back to 0 or false.
+type Profile struct {
+	SSH            string `toml:"ssh,omitempty"`
+	RedisAddr      string `toml:"redis-addr,omitempty"`
+	Socket         string `toml:"socket,omitempty"`
+	Username       string `toml:"username,omitempty"`
+	Password       string `toml:"password,omitempty"`
+	DB             *int   `toml:"db,omitempty"`
+	TLS            *bool  `toml:"tls,omitempty"`
+	TLSCA          string `toml:"tls-ca,omitempty"`
+	TLSCert        string `toml:"tls-cert,omitempty"`
+	TLSKey         string `toml:"tls-key,omitempty"`
+	Output         string `toml:"output,omitempty"`
+	ConnectTimeout string `toml:"connect-timeout,omitempty"`
+	DialTimeout    string `toml:"dial-timeout,omitempty"`
+	ReadTimeout    string `toml:"read-timeout,omitempty"`
+	CommandTimeout string `toml:"command-timeout,omitempty"`
+}
+
+// Config is the on-disk lsc configuration
+type Config struct {
+	DefaultProfile string              `toml:"default-profile,omitempty"`
+	Access         Access              `toml:"access,omitempty"`
+	Audit          Audit               `toml:"audit,omitempty"`
+	Profiles       map[string]*Profile `toml:"profiles,omitempty"`
+}
+
+// Access restricts the commands that may change the scooter: write to Redis
+// or run systemctl. Commands are named by their dotted path, e.g.
+// diag.handlebar; patterns may use * as in power.*.
+type Access struct {
+	// ReadOnly denies every command that changes the scooter
+	ReadOnly bool `toml:"read-only,omitempty"`
+	// Allow, if not empty, lists the only commands that may change the scooter
+	Allow []string `toml:"allow,omitempty"`
+	// Deny lists commands that may not change the scooter
+	Deny []string `toml:"deny,omitempty"`
+
+	// narrowed holds the allow lists of files merged on top of the first
+	// one; a command has to be in all of them
+	narrowed [][]string
+}
+
+// Denied returns why the access lists deny the command at path, or "" if
+// they allow it
+func (a *Access) Denied(path string) string {
+	for _, pattern := range a.Deny {
+		if matchCommand(pattern, path) {
+			return fmt.Sprintf("denied by the access list entry '%s'", pattern)
+		}
+	}
+	for _, allow := range append([][]string{a.Allow}, a.narrowed...) {
+		if len(allow) > 0 && !allowed(allow, path) {
+			return "not in the access allow list"
+		}
+	}
+	return ""
+}
+
+func allowed(allow []string, path string) bool {
+	for _, pattern := range allow {
+		if matchCommand(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Audit configures where the operations that change the scooter are recorded
+type Audit struct {
+	// Path is the JSONL log file, AuditPath by default
+	Path string `toml:"path,omitempty"`
+	// Stream is a Redis stream on the scooter to record to as well, e.g. lsc:audit
+	Stream string `toml:"stream,omitempty"`
+}
+
+// matchCommand reports whether a command path matches an access pattern. A
+// pattern also matches the subcommands of the commands it names.
+func matchCommand(pattern, command string) bool {
+	for {
+		if ok, _ := path.Match(pattern, command); ok {
+			return true
+		}
+		i := strings.LastIndex(command, ".")
+		if i < 0 {
+			return false
+		}
+		command = command[:i]
+	}
+}
+
+// ProfileKeys lists the keys accepted by Set, in display order
+var ProfileKeys = []string{
+	"ssh",
+	"redis-addr",
+	"socket",
+	"username",
+	"password",
+	"db",
+	"tls",
+	"tls-ca",
+	"tls-cert",
+	"tls-key",
+	"output",
+	"connect-timeout",
+	"dial-timeout",
+	"read-timeout",
+	"command-timeout",
+}
+
+// UserPath returns the per-user configuration file (~/.config/lsc/config.toml)
+func UserPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "lsc", "config.toml"), nil
+}
+
+// HistoryPath returns the file holding the 'lsc shell' history (~/.config/lsc/history)
+func HistoryPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "lsc", "history"), nil
+}
+
+// AuditPath returns the default audit log file (~/.config/lsc/audit.jsonl)
+func AuditPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "lsc", "audit.jsonl"), nil
+}
+
+// Load reads the system configuration and overlays the user configuration on top.
+// Missing files are not an error.
+func Load() (*Config, error) {
+	cfg := &Config{Profiles: make(map[string]*Profile)}
+
+	paths := []string{SystemPath}
+	if userPath, err := UserPath(); err == nil {
+		paths = append(paths, userPath)
+	}
+
+	for _, path := range paths {
+		overlay, err := ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		cfg.merge(overlay)
+	}
+
+	return cfg, nil
+}
+
+// ReadFile parses a single configuration file
+func ReadFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{}
+	if _, err := toml.Decode(string(data), cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = make(map[string]*Profile)
+	}
+	return cfg, nil
+}
+
+// WriteFile writes the configuration to path, creating parent directories as needed
+func (c *Config) WriteFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	enc := toml.NewEncoder(file)
+	enc.Indent = ""
+	return enc.Encode(c)
+}
+
+// merge overlays other on top of c, field by field. Access only ever
+// narrows: read-only mode and deny lists accumulate, and a later allow list
+// can only take commands away from an earlier one, so the user file cannot
+// widen what the system file allows.
+func (c *Config) merge(other *Config) {
+	if other.DefaultProfile != "" {
+		c.DefaultProfile = other.DefaultProfile
+	}
+	if other.Access.ReadOnly {
+		c.Access.ReadOnly = true
+	}
+	if len(other.Access.Allow) > 0 {
+		if len(c.Access.Allow) == 0 {
+			c.Access.Allow = other.Access.Allow
+		} else {
+			c.Access.narrowed = append(c.Access.narrowed, other.Access.Allow)
+		}
+	}
+	c.Access.Deny = append(c.Access.Deny, other.Access.Deny...)
+	if other.Audit.Path != "" {
+		c.Audit.Path = other.Audit.Path
+	}
+	if other.Audit.Stream != "" {
+		c.Audit.Stream = other.Audit.Stream
+	}
+	for name, p := range other.Profiles {
+		base, ok := c.Profiles[name]
+		if !ok {
+			copied := *p
+			c.Profiles[name] = &copied
+			continue
+		}
+		base.merge(p)
+	}
+}
+
+func (p *Profile) merge(other *Profile) {
+	if other.SSH != "" {
+		p.SSH = other.SSH
+	}
+	if other.RedisAddr != "" {
+		p.RedisAddr = other.RedisAddr
+	}
+	if other.Socket != "" {
+		p.Socket = other.Socket
+	}
+	if other.Username != "" {
+		p.Username = other.Username
+	}
+	if other.Password != "" {
+		p.Password = other.Password
+	}
+	if other.DB != nil {
+		p.DB = other.DB
+	}
+	if other.TLS != nil {
+		p.TLS = other.TLS
+	}
+	if other.TLSCA != "" {
+		p.TLSCA = other.TLSCA
+	}
+	if other.TLSCert != "" {
+		p.TLSCert = other.TLSCert
+	}
+	if other.TLSKey != "" {
+		p.TLSKey = other.TLSKey
+	}
+	if other.Output != "" {
+		p.Output = other.Output
+	}
+	if other.ConnectTimeout != "" {
+		p.ConnectTimeout = other.ConnectTimeout
+	}
+	if other.DialTimeout != "" {
+		p.DialTimeout = other.DialTimeout
+	}
+	if other.ReadTimeout != "" {
+		p.ReadTimeout = other.ReadTimeout
+	}
+	if other.CommandTimeout != "" {
+		p.CommandTimeout = other.CommandTimeout
+	}
+}
+
+// Profile returns the named profile. An empty name selects the default profile;
+// if no default is configured an empty profile is returned.
+func (c *Config) Profile(name string) (*Profile, error) {
+	if name == "" {
+		name = c.DefaultProfile
+	}
+	if name == "" || name == NoProfile {
+		return &Profile{}, nil
+	}
+	p, ok := c.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile '%s'", name)
+	}
+	return p, nil
+}
+
+// ProfileNames returns all profile names sorted alphabetically
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the string form of a profile key
+func (p *Profile) Get(key string) (string, error) {
+	switch key {
+	case "ssh":
+		return p.SSH, nil
+	case "redis-addr":
+		return p.RedisAddr, nil
+	case "socket":
+		return p.Socket, nil
+	case "username":
+		return p.Username, nil
+	case "password":
+		return p.Password, nil
+	case "db":
+		if p.DB == nil {
+			return "", nil
+		}
+		return strconv.Itoa(*p.DB), nil
+	case "tls":
+		if p.TLS == nil {
+			return "", nil
+		}
+		return strconv.FormatBool(*p.TLS), nil
+	case "tls-ca":
+		return p.TLSCA, nil
+	case "tls-cert":
+		return p.TLSCert, nil
+	case "tls-key":
+		return p.TLSKey, nil
+	case "output":
+		return p.Output, nil
+	case "connect-timeout":
+		return p.ConnectTimeout, nil
+	case "dial-timeout":
+		return p.DialTimeout, nil
+	case "read-timeout":
+		return p.ReadTimeout, nil
+	case "command-timeout":
+		return p.CommandTimeout, nil
+	}
+	return "", fmt.Errorf("unknown key '%s' (valid: %s)", key, strings.Join(ProfileKeys, ", "))
+}
+
+// Set parses and stores a profile key. An empty value clears the key.
+func (p *Profile) Set(key, value string) error {
+	switch key {
+	case "ssh":
+		p.SSH = value
+	case "redis-addr":
+		p.RedisAddr = value
+	case "socket":
+		p.Socket = value
+	case "username":
+		p.Username = value
+	case "password":
+		p.Password = value
+	case "db":
+		if value == "" {
+			p.DB = nil
+			return nil
+		}
+		db, err := strconv.Atoi(value)
+		if err != nil || db < 0 {
+			return fmt.Errorf("invalid db '%s': must be a non-negative integer", value)
+		}
+		p.DB = &db
+	case "tls":
+		if value == "" {
+			p.TLS = nil
+			return nil
+		}
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid tls '%s': must be true or false", value)
+		}
+		p.TLS = &enabled
+	case "tls-ca":
+		p.TLSCA = value
+	case "tls-cert":
+		p.TLSCert = value
+	case "tls-key":
+		p.TLSKey = value
+	case "output":
+		switch name, _, _ := strings.Cut(value, "="); name {
+		case "", "pretty", "json", "yaml", "yml", "csv", "table", "template":
+		default:
+			return fmt.Errorf("invalid output '%s': must be pretty, json, yaml, csv, table or template=<go-template>", value)
+		}
+		p.Output = value
+	case "connect-timeout", "dial-timeout", "read-timeout", "command-timeout":
+		if value != "" {
+			if _, err := time.ParseDuration(value); err != nil {
+				return fmt.Errorf("invalid %s '%s': %v", key, value, err)
+			}
+		}
+		switch key {
+		case "connect-timeout":
+			p.ConnectTimeout = value
+		case "dial-timeout":
+			p.DialTimeout = value
+		case "read-timeout":
+			p.ReadTimeout = value
+		default:
+			p.CommandTimeout = value
+		}
+	default:
+		return fmt.Errorf("unknown key '%s' (valid: %s)", key, strings.Join(ProfileKeys, ", "))
+	}
+	return nil
+}
+
+// Duration parses a timeout field, returning 0 when unset
+func Duration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(value)
+}
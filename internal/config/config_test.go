@@ -0,0 +1,65 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMergeAccessNarrows(t *testing.T) {
+	cfg := &Config{Profiles: make(map[string]*Profile)}
+	cfg.merge(&Config{Access: Access{Allow: []string{"vehicle.*", "settings.get"}}})
+	cfg.merge(&Config{Access: Access{Allow: []string{"*"}}})
+	cfg.merge(&Config{Access: Access{Allow: []string{"vehicle.lock", "power.*"}}})
+
+	for path, want := range map[string]bool{
+		"vehicle.lock":   true,
+		"vehicle.unlock": false, // taken away by the last file
+		"power.reboot":   false, // not in the first file
+		"settings.set":   false,
+	} {
+		if got := cfg.Access.Denied(path) == ""; got != want {
+			t.Errorf("%s allowed = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestMergeProfileOverridesZeroValues(t *testing.T) {
+	dir := t.TempDir()
+	system := filepath.Join(dir, "lsc.conf")
+	os.WriteFile(system, []byte(`
+[profiles.deep-blue]
+redis-addr = "10.0.0.12:6379"
+db = 2
+tls = true
+`), 0o644)
+
+	// The user file turns TLS off and selects db 0, as lsc config set writes it
+	user := &Config{Profiles: map[string]*Profile{"deep-blue": {}}}
+	for key, value := range map[string]string{"db": "0", "tls": "false"} {
+		if err := user.Profiles["deep-blue"].Set(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	userPath := filepath.Join(dir, "config.toml")
+	if err := user.WriteFile(userPath); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := ReadFile(system)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ReadFile(userPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.merge(other)
+
+	p := cfg.Profiles["deep-blue"]
+	for key, want := range map[string]string{"redis-addr": "10.0.0.12:6379", "db": "0", "tls": "false"} {
+		if got, _ := p.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package confirm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"librescoot/lsc/internal/output"
+	"librescoot/lsc/internal/redis"
+)
+
+// Command is a command pushed onto a service's command list, and the hash
+// field change that confirms it was carried out
+type Command struct {
+	// List is the command list, e.g. scooter:state, and Payload the command
+	List    string
+	Payload string
+	// Service is the systemd unit reading List, named in errors
+	Service string
+
+	// Hash and Field hold the state the command changes. The service
+	// publishes the field name on the channel named after the hash.
+	Hash  string
+	Field string
+	// Accept are the values that confirm the command. Match, if set, is
+	// used instead.
+	Accept []string
+	Match  func(value string) bool
+	// Fail are the values that mean the service refused or failed the command
+	Fail []string
+	// Published requires the value to be published after the command was
+	// sent; the value the field already has does not count
+	Published bool
+
+	// Timeout bounds the wait for confirmation
+	Timeout time.Duration
+	// Progress, if set, is called with each intermediate value of the field
+	Progress func(value string)
+}
+
+// Result is the outcome of a command
+type Result struct {
+	// Value is the field value that confirmed the command
+	Value string
+	// States are the intermediate values seen while waiting, oldest first
+	States  []string
+	Elapsed time.Duration
+	// Warning is set when a sent command was not taken from the list in time
+	Warning string
+}
+
+// accepts reports whether value confirms the command
+func (c *Command) accepts(value string) bool {
+	if c.Match != nil {
+		return c.Match(value)
+	}
+	return slices.Contains(c.Accept, value)
+}
+
+// expected describes the accepted values, for messages
+func (c *Command) expected() string {
+	if c.Match != nil || len(c.Accept) == 0 {
+		return "an accepted value"
+	}
+	quoted := make([]string, len(c.Accept))
+	for i, value := range c.Accept {
+		quoted[i] = "'" + value + "'"
+	}
+	return strings.Join(quoted, " or ")
+}
+
+// Send pushes the command without waiting for confirmation. It then gives
+// the service a moment to take the command from the list, and sets a
+// warning on the result if it did not.
+func Send(ctx context.Context, client *redis.Client, cmd Command) (*Result, error) {
+	if err := push(ctx, client, cmd); err != nil {
+		return nil, err
+	}
+	result := &Result{}
+	if !taken(ctx, client, cmd.List, takeGrace) {
+		result.Warning = fmt.Sprintf("%s has not taken the command from %s; it will run once the service does (clear it with 'lsc queue flush %s')",
+			cmd.reader(), cmd.List, cmd.List)
+	}
+	return result, nil
+}
+
+// Run pushes the command and waits until the field takes an accepted value.
+// The subscription is confirmed by Redis before the command is pushed, so
+// the change cannot be missed. Without a List, Run only waits.
+//
+// A failure value is reported as a rejected error. On timeout, the list is
+// checked again: if the command is still in it, the error names the service
+// that is not taking commands.
+func Run(ctx context.Context, client *redis.Client, cmd Command) (*Result, error) {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, cmd.Timeout)
+	defer cancel()
+
+	pubsub := client.Subscribe(ctx, cmd.Hash)
+	defer pubsub.Close()
+	// The first reply is the subscription confirmation
+	if _, err := pubsub.Receive(ctx); err != nil {
+		if ctx.Err() != nil {
+			return nil, output.Timeout("timeout subscribing to %s", cmd.Hash)
+		}
+		return nil, output.Connection("failed to subscribe to %s: %w", cmd.Hash, err)
+	}
+	messages := pubsub.Channel()
+
+	result := &Result{}
+	if cmd.List != "" {
+		if err := push(ctx, client, cmd); err != nil {
+			return nil, err
+		}
+	}
+
+	last := ""
+	check := func() (bool, error) {
+		value, err := client.HGetWithContext(ctx, cmd.Hash, cmd.Field)
+		if err != nil || value == last {
+			return false, nil
+		}
+		last = value
+		switch {
+		case cmd.accepts(value):
+			result.Value = value
+			result.Elapsed = time.Since(start)
+			return true, nil
+		case slices.Contains(cmd.Fail, value):
+			return true, output.Rejected("%s:%s became '%s'", cmd.Hash, cmd.Field, value)
+		}
+		result.States = append(result.States, value)
+		if cmd.Progress != nil {
+			cmd.Progress(value)
+		}
+		return false, nil
+	}
+
+	// The field may already have the value
+	if !cmd.Published {
+		if done, err := check(); done {
+			return result, err
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.Canceled) {
+				return nil, fmt.Errorf("interrupted while waiting for %s:%s to become %s", cmd.Hash, cmd.Field, cmd.expected())
+			}
+			return nil, timeoutError(client, cmd)
+		case msg := <-messages:
+			// Services publish the name of the field that changed
+			if msg.Payload != cmd.Field && msg.Payload != "" {
+				continue
+			}
+			if done, err := check(); done {
+				return result, err
+			}
+		}
+	}
+}
+
+// takeGrace is how long Send gives a service to take a command. Services
+// block on their list, so a running one takes it within milliseconds.
+const takeGrace = 300 * time.Millisecond
+
+// reader names the service reading the command list
+func (c *Command) reader() string {
+	if c.Service != "" {
+		return c.Service
+	}
+	return "the service reading " + c.List
+}
+
+// push pushes the command. It refuses when the list already holds commands
+// nobody took: the service is not running, and queueing more would only
+// have them all run at once when it starts.
+func push(ctx context.Context, client *redis.Client, cmd Command) error {
+	pending, err := client.LLenWithContext(ctx, cmd.List)
+	if err != nil {
+		return err
+	}
+	if pending > 0 {
+		return output.Rejected("%s already holds %d unconsumed command(s), %s is not taking commands (inspect them with 'lsc queue list %s', clear them with 'lsc queue flush %s')",
+			cmd.List, pending, cmd.reader(), cmd.List, cmd.List)
+	}
+	return client.LPushWithContext(ctx, cmd.List, cmd.Payload)
+}
+
+// taken waits up to grace for list to be emptied
+func taken(ctx context.Context, client *redis.Client, list string, grace time.Duration) bool {
+	deadline := time.Now().Add(grace)
+	for {
+		pending, err := client.LLenWithContext(ctx, list)
+		if err != nil || pending == 0 {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// timeoutError describes an unconfirmed command, naming the service if the
+// command was never taken from the list
+func timeoutError(client *redis.Client, cmd Command) error {
+	if cmd.List == "" {
+		return output.Timeout("timeout waiting for %s:%s to become %s", cmd.Hash, cmd.Field, cmd.expected())
+	}
+	message := fmt.Sprintf("%s command sent but %s confirmation timed out after %s (waiting for %s:%s to become %s)",
+		cmd.Payload, cmd.Field, cmd.Timeout, cmd.Hash, cmd.Field, cmd.expected())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if pending, err := client.LLenWithContext(ctx, cmd.List); err == nil && pending > 0 {
+		message += fmt.Sprintf("; %s has not taken it from %s (clear it with 'lsc queue flush %s')", cmd.reader(), cmd.List, cmd.List)
+	}
+	return output.Timeout("%s", message)
+}
@@ -7,29 +7,32 @@ import (
 
 // MillivoltsToVolts converts millivolts string to volts with 1 decimal
 func MillivoltsToVolts(mv string) string {
-	val, err := strconv.Atoi(mv)
-	if err != nil || val == 0 {
-		return "0.0 V"
-	}
-	return fmt.Sprintf("%.1f V", float64(val)/1000.0)
+	return FormatVolts(float64(ParseInt(mv)) / 1000.0)
 }
 
 // MilliampsToAmps converts milliamps string to amps with 1 decimal
 func MilliampsToAmps(ma string) string {
-	val, err := strconv.Atoi(ma)
-	if err != nil || val == 0 {
-		return "0.0 A"
-	}
-	return fmt.Sprintf("%.1f A", float64(val)/1000.0)
+	return FormatAmps(float64(ParseInt(ma)) / 1000.0)
 }
 
 // MetersToKilometers converts meters string to kilometers with 1 decimal
 func MetersToKilometers(m string) string {
-	val, err := strconv.Atoi(m)
-	if err != nil || val == 0 {
-		return "0.0 km"
-	}
-	return fmt.Sprintf("%.1f km", float64(val)/1000.0)
+	return FormatKilometers(float64(ParseInt(m)) / 1000.0)
+}
+
+// FormatVolts formats volts with 1 decimal
+func FormatVolts(v float64) string {
+	return fmt.Sprintf("%.1f V", v)
+}
+
+// FormatAmps formats amps with 1 decimal
+func FormatAmps(a float64) string {
+	return fmt.Sprintf("%.1f A", a)
+}
+
+// FormatKilometers formats kilometers with 1 decimal
+func FormatKilometers(km float64) string {
+	return fmt.Sprintf("%.1f km", km)
 }
 
 // FormatPercentage formats a percentage string with % symbol
@@ -75,15 +78,19 @@ func ParseInt(s string) int {
 
 // FormatVoltageColored formats voltage with appropriate coloring
 func FormatVoltageColored(mv string) string {
-	val := ParseInt(mv)
-	text := MillivoltsToVolts(mv)
+	return FormatVoltsColored(float64(ParseInt(mv)) / 1000.0)
+}
+
+// FormatVoltsColored formats a main battery voltage in volts with coloring
+func FormatVoltsColored(v float64) string {
+	text := FormatVolts(v)
 
 	// Battery voltage ranges (for 14S lithium: 42-58.8V)
-	if val >= 50000 {
+	if v >= 50 {
 		return Success(text) // Good voltage
-	} else if val >= 45000 {
+	} else if v >= 45 {
 		return Warning(text) // Low voltage
-	} else if val > 0 {
+	} else if v > 0 {
 		return Error(text) // Critical voltage
 	}
 	return Dim(text)
@@ -0,0 +1,91 @@
+package model
+
+import "fmt"
+
+// Battery is a main battery slot (battery:0, battery:1)
+type Battery struct {
+	Slot    int
+	Present bool
+	State   string
+	// Charge is in percent, Voltage in V and Current in A
+	Charge  int
+	Voltage float64
+	Current float64
+	// Temperatures are the four sensors in °C
+	Temperatures      [4]int
+	TemperatureState  string
+	CycleCount        int
+	StateOfHealth     int
+	SerialNumber      string
+	ManufacturingDate string
+	FirmwareVersion   string
+
+	Fields
+}
+
+// ParseBattery reads the hash of a main battery slot
+func ParseBattery(slot int, data map[string]string) *Battery {
+	b := &Battery{Slot: slot}
+	p := newParser(BatteryKey(slot), data, &b.Fields)
+	b.Present = p.bool("present")
+	b.State = p.str("state")
+	b.Charge = p.int("charge")
+	b.Voltage = p.milli("voltage")
+	b.Current = p.milli("current")
+	for i := range b.Temperatures {
+		b.Temperatures[i] = p.int(fmt.Sprintf("temperature:%d", i))
+	}
+	b.TemperatureState = p.str("temperature-state")
+	b.CycleCount = p.int("cycle-count")
+	b.StateOfHealth = p.int("state-of-health")
+	b.SerialNumber = p.str("serial-number")
+	b.ManufacturingDate = p.str("manufacturing-date")
+	b.FirmwareVersion = p.str("fw-version")
+	return b
+}
+
+// AuxBattery is the 12 V auxiliary battery
+type AuxBattery struct {
+	// Voltage is in V and Charge in percent
+	Voltage      float64
+	Charge       int
+	ChargeStatus string
+
+	Fields
+}
+
+// ParseAuxBattery reads the aux-battery hash
+func ParseAuxBattery(data map[string]string) *AuxBattery {
+	b := &AuxBattery{}
+	p := newParser(AuxBatteryKey, data, &b.Fields)
+	b.Voltage = p.milli("voltage")
+	b.Charge = p.int("charge")
+	b.ChargeStatus = p.str("charge-status")
+	return b
+}
+
+// CBBattery is the control board battery
+type CBBattery struct {
+	Present bool
+	// Charge and StateOfHealth are in percent, Temperature in °C
+	Charge        int
+	ChargeStatus  string
+	StateOfHealth int
+	CycleCount    int
+	Temperature   int
+
+	Fields
+}
+
+// ParseCBBattery reads the cb-battery hash
+func ParseCBBattery(data map[string]string) *CBBattery {
+	b := &CBBattery{}
+	p := newParser(CBBatteryKey, data, &b.Fields)
+	b.Present = p.bool("present")
+	b.Charge = p.int("charge")
+	b.ChargeStatus = p.str("charge-status")
+	b.StateOfHealth = p.int("state-of-health")
+	b.CycleCount = p.int("cycle-count")
+	b.Temperature = p.int("temperature")
+	return b
+}
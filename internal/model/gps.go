@@ -0,0 +1,99 @@
+package model
+
+// GPS is the gps hash of the modem's GNSS receiver
+type GPS struct {
+	Connected bool
+	Active    bool
+	// State is e.g. off, searching, fix-established or tracking
+	State string
+	Fix   string
+	// Latitude and Longitude are in degrees, Altitude in m, Speed in km/h
+	// and Course in degrees
+	Latitude  float64
+	Longitude float64
+	Altitude  float64
+	Speed     float64
+	Course    float64
+	// EPH is the horizontal error in m
+	EPH     float64
+	Quality float64
+	HDOP    float64
+	PDOP    float64
+	VDOP    float64
+	// Timestamp is the GPS time and Updated the time of the last update, both RFC 3339
+	Timestamp string
+	Updated   string
+
+	Fields
+}
+
+// ParseGPS reads the gps hash
+func ParseGPS(data map[string]string) *GPS {
+	g := &GPS{}
+	p := newParser(GPSKey, data, &g.Fields)
+	g.Connected = p.bool("connected")
+	g.Active = p.bool("active")
+	g.State = p.str("state")
+	g.Fix = p.str("fix")
+	g.Latitude = p.float("latitude")
+	g.Longitude = p.float("longitude")
+	g.Altitude = p.float("altitude")
+	g.Speed = p.float("speed")
+	g.Course = p.float("course")
+	g.EPH = p.float("eph")
+	g.Quality = p.float("quality")
+	g.HDOP = p.float("hdop")
+	g.PDOP = p.float("pdop")
+	g.VDOP = p.float("vdop")
+	g.Timestamp = p.str("timestamp")
+	g.Updated = p.str("updated")
+	return g
+}
+
+// HasFix reports whether the receiver has a position
+func (g *GPS) HasFix() bool {
+	return g.State == "fix-established" || g.State == "tracking"
+}
+
+// Modem is the modem hash
+type Modem struct {
+	State string
+
+	Fields
+}
+
+// ParseModem reads the modem hash
+func ParseModem(data map[string]string) *Modem {
+	m := &Modem{}
+	p := newParser(ModemKey, data, &m.Fields)
+	m.State = p.str("state")
+	return m
+}
+
+// Internet is the internet hash of the modem service
+type Internet struct {
+	// Status is connected or disconnected
+	Status     string
+	ModemState string
+	AccessTech string
+	// SignalQuality is in percent
+	SignalQuality int
+
+	Fields
+}
+
+// ParseInternet reads the internet hash
+func ParseInternet(data map[string]string) *Internet {
+	i := &Internet{}
+	p := newParser(InternetKey, data, &i.Fields)
+	i.Status = p.str("status")
+	i.ModemState = p.str("modem-state")
+	i.AccessTech = p.str("access-tech")
+	i.SignalQuality = p.int("signal-quality")
+	return i
+}
+
+// Connected reports whether the modem is online
+func (i *Internet) Connected() bool {
+	return i.Status == "connected"
+}
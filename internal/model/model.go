@@ -0,0 +1,162 @@
+// Package model describes the Redis hashes the LibreScoot services publish as
+// typed structs. Units are normalized on parsing: volts, amps, kilometers,
+// km/h, °C and percent.
+package model
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// Keys of the hashes described here
+const (
+	VehicleKey      = "vehicle"
+	EngineECUKey    = "engine-ecu"
+	AuxBatteryKey   = "aux-battery"
+	CBBatteryKey    = "cb-battery"
+	PowerManagerKey = "power-manager"
+	PowerMuxKey     = "power-mux"
+	GPSKey          = "gps"
+	ModemKey        = "modem"
+	InternetKey     = "internet"
+	AlarmKey        = "alarm"
+	OTAKey          = "ota"
+	SystemKey       = "system"
+)
+
+// BatterySlots are the main battery slots
+var BatterySlots = []int{0, 1}
+
+// BatteryKey returns the hash key of a main battery slot
+func BatteryKey(slot int) string {
+	return fmt.Sprintf("battery:%d", slot)
+}
+
+// BatteryFaultsKey returns the key of the fault set of a main battery slot
+func BatteryFaultsKey(slot int) string {
+	return fmt.Sprintf("battery:%d:faults", slot)
+}
+
+// Fields reports how a hash was read: the expected fields it lacked or left
+// empty and the values that could not be parsed. Both are read as zero values.
+type Fields struct {
+	Hash    string
+	Missing []string
+	Invalid []*FieldError
+}
+
+// Has reports whether field was present and valid
+func (f *Fields) Has(field string) bool {
+	if slices.Contains(f.Missing, field) {
+		return false
+	}
+	return !slices.ContainsFunc(f.Invalid, func(e *FieldError) bool { return e.Field == field })
+}
+
+// Err returns the parse errors, or nil if every value was valid. Missing
+// fields are not errors: services leave out what they do not know.
+func (f *Fields) Err() error {
+	errs := make([]error, len(f.Invalid))
+	for i, e := range f.Invalid {
+		errs[i] = e
+	}
+	return errors.Join(errs...)
+}
+
+// FieldError is a hash value that could not be parsed
+type FieldError struct {
+	Hash  string
+	Field string
+	Value string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("invalid %s %s '%s': %v", e.Hash, e.Field, e.Value, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// parser reads typed values from a hash, recording what it could not read
+type parser struct {
+	data   map[string]string
+	fields *Fields
+}
+
+func newParser(hash string, data map[string]string, fields *Fields) *parser {
+	*fields = Fields{Hash: hash}
+	return &parser{data: data, fields: fields}
+}
+
+func (p *parser) str(field string) string {
+	value := p.data[field]
+	if value == "" {
+		p.fields.Missing = append(p.fields.Missing, field)
+	}
+	return value
+}
+
+func (p *parser) int(field string) int {
+	value := p.str(field)
+	if value == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		p.invalid(field, value, err)
+		return 0
+	}
+	return n
+}
+
+func (p *parser) int64(field string) int64 {
+	value := p.str(field)
+	if value == "" {
+		return 0
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		p.invalid(field, value, err)
+		return 0
+	}
+	return n
+}
+
+func (p *parser) float(field string) float64 {
+	value := p.str(field)
+	if value == "" {
+		return 0
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		p.invalid(field, value, err)
+		return 0
+	}
+	return f
+}
+
+// milli reads a value given in thousandths (mV, mA, m) in whole units (V, A, km)
+func (p *parser) milli(field string) float64 {
+	return p.float(field) / 1000
+}
+
+// bool reads a flag, which services write as true/false or 1/0
+func (p *parser) bool(field string) bool {
+	switch value := p.str(field); value {
+	case "true", "1":
+		return true
+	case "", "false", "0":
+		return false
+	default:
+		p.invalid(field, value, errors.New("not a boolean"))
+		return false
+	}
+}
+
+func (p *parser) invalid(field, value string, err error) {
+	p.fields.Invalid = append(p.fields.Invalid, &FieldError{Hash: p.fields.Hash, Field: field, Value: value, Err: err})
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestParseBattery(t *testing.T) {
+	b := ParseBattery(1, map[string]string{
+		"present":         "true",
+		"charge":          "87",
+		"voltage":         "53200",
+		"current":         "-1200",
+		"temperature:0":   "21",
+		"temperature:2":   "warm",
+		"state-of-health": "98",
+	})
+
+	if !b.Present || b.Charge != 87 || b.Voltage != 53.2 || b.Current != -1.2 || b.StateOfHealth != 98 {
+		t.Errorf("parsed %+v", b)
+	}
+	if b.Temperatures != [4]int{21, 0, 0, 0} {
+		t.Errorf("temperatures = %v", b.Temperatures)
+	}
+	if b.Hash != "battery:1" {
+		t.Errorf("hash = %q", b.Hash)
+	}
+
+	if !b.Has("voltage") || b.Has("cycle-count") || b.Has("temperature:2") {
+		t.Errorf("Has is wrong for missing %v, invalid %v", b.Missing, b.Invalid)
+	}
+	var fieldErr *FieldError
+	if err := b.Err(); !errors.As(err, &fieldErr) || fieldErr.Field != "temperature:2" || !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("Err() = %v", err)
+	}
+}
+
+func TestParseBool(t *testing.T) {
+	g := ParseGPS(map[string]string{"connected": "1", "active": "false", "state": "tracking"})
+	if !g.Connected || g.Active || !g.HasFix() {
+		t.Errorf("parsed %+v", g)
+	}
+	if g.Err() != nil {
+		t.Errorf("Err() = %v", g.Err())
+	}
+
+	v := ParseEngineECU(map[string]string{"kers": "maybe"})
+	if v.KERS || v.Err() == nil {
+		t.Errorf("kers 'maybe' parsed as %v, error %v", v.KERS, v.Err())
+	}
+}
+
+func TestParseOTA(t *testing.T) {
+	o := ParseOTA(map[string]string{
+		"status":                "downloading",
+		"status:mdb":            "downloading",
+		"download-progress:mdb": "42",
+	})
+	mdb, ok := o.Components["mdb"]
+	if !ok || mdb.Status != "downloading" || mdb.DownloadProgress != 42 {
+		t.Errorf("mdb = %+v", mdb)
+	}
+	if _, ok := o.Components["dbc"]; ok {
+		t.Error("dbc has no update service but was parsed")
+	}
+}
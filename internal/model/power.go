@@ -0,0 +1,35 @@
+package model
+
+// PowerManager is the power-manager hash
+type PowerManager struct {
+	// State is e.g. running, suspending or hibernating
+	State        string
+	WakeupSource string
+
+	Fields
+}
+
+// ParsePowerManager reads the power-manager hash
+func ParsePowerManager(data map[string]string) *PowerManager {
+	pm := &PowerManager{}
+	p := newParser(PowerManagerKey, data, &pm.Fields)
+	pm.State = p.str("state")
+	pm.WakeupSource = p.str("wakeup-source")
+	return pm
+}
+
+// PowerMux is the power-mux hash, which selects the supply of the MDB
+type PowerMux struct {
+	// SelectedInput is aux, main or external
+	SelectedInput string
+
+	Fields
+}
+
+// ParsePowerMux reads the power-mux hash
+func ParsePowerMux(data map[string]string) *PowerMux {
+	pm := &PowerMux{}
+	p := newParser(PowerMuxKey, data, &pm.Fields)
+	pm.SelectedInput = p.str("selected-input")
+	return pm
+}
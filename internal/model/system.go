@@ -0,0 +1,93 @@
+package model
+
+// Alarm is the alarm hash
+type Alarm struct {
+	// Status is disabled, disarmed, delay-armed, armed or triggered
+	Status string
+
+	Fields
+}
+
+// ParseAlarm reads the alarm hash
+func ParseAlarm(data map[string]string) *Alarm {
+	a := &Alarm{}
+	p := newParser(AlarmKey, data, &a.Fields)
+	a.Status = p.str("status")
+	return a
+}
+
+// System is the system hash with the firmware versions
+type System struct {
+	MDBVersion  string
+	DBCVersion  string
+	NRFVersion  string
+	Environment string
+
+	Fields
+}
+
+// ParseSystem reads the system hash
+func ParseSystem(data map[string]string) *System {
+	s := &System{}
+	p := newParser(SystemKey, data, &s.Fields)
+	s.MDBVersion = p.str("mdb-version")
+	s.DBCVersion = p.str("dbc-version")
+	s.NRFVersion = p.str("nrf-fw-version")
+	s.Environment = p.str("environment")
+	return s
+}
+
+// OTAComponents are the systems updated over the air
+var OTAComponents = []string{"mdb", "dbc"}
+
+// OTA is the ota hash of the update service
+type OTA struct {
+	System      string
+	Status      string
+	FreshUpdate bool
+	// Components holds the update state of the components whose update
+	// service is running
+	Components map[string]*OTAComponent
+
+	Fields
+}
+
+// OTAComponent is the update state of one component, from the <field>:<component> fields
+type OTAComponent struct {
+	Status        string
+	UpdateVersion string
+	Error         string
+	ErrorMessage  string
+	// DownloadProgress is in percent
+	DownloadProgress int
+	DownloadBytes    int64
+	DownloadTotal    int64
+	UpdateMethod     string
+}
+
+// ParseOTA reads the ota hash
+func ParseOTA(data map[string]string) *OTA {
+	o := &OTA{Components: make(map[string]*OTAComponent)}
+	p := newParser(OTAKey, data, &o.Fields)
+	o.System = p.str("system")
+	o.Status = p.str("status")
+	o.FreshUpdate = p.bool("fresh-update")
+	for _, name := range OTAComponents {
+		// Only a running update service writes the component's status
+		if _, ok := data["status:"+name]; !ok {
+			continue
+		}
+		field := func(f string) string { return f + ":" + name }
+		o.Components[name] = &OTAComponent{
+			Status:           p.str(field("status")),
+			UpdateVersion:    p.str(field("update-version")),
+			Error:            p.str(field("error")),
+			ErrorMessage:     p.str(field("error-message")),
+			DownloadProgress: p.int(field("download-progress")),
+			DownloadBytes:    p.int64(field("download-bytes")),
+			DownloadTotal:    p.int64(field("download-total")),
+			UpdateMethod:     p.str(field("update-method")),
+		}
+	}
+	return o
+}
@@ -0,0 +1,68 @@
+package model
+
+// Vehicle is the vehicle hash, published by the vehicle service
+type Vehicle struct {
+	// State is the vehicle state, e.g. stand-by, parked or ready-to-drive
+	State         string
+	Kickstand     string
+	BrakeLeft     string
+	BrakeRight    string
+	BlinkerSwitch string
+	BlinkerState  string
+	SeatboxLock   string
+	HandlebarLock string
+
+	Fields
+}
+
+// ParseVehicle reads the vehicle hash
+func ParseVehicle(data map[string]string) *Vehicle {
+	v := &Vehicle{}
+	p := newParser(VehicleKey, data, &v.Fields)
+	v.State = p.str("state")
+	v.Kickstand = p.str("kickstand")
+	v.BrakeLeft = p.str("brake:left")
+	v.BrakeRight = p.str("brake:right")
+	v.BlinkerSwitch = p.str("blinker:switch")
+	v.BlinkerState = p.str("blinker:state")
+	v.SeatboxLock = p.str("seatbox:lock")
+	v.HandlebarLock = p.str("handlebar:lock-sensor")
+	return v
+}
+
+// EngineECU is the engine-ecu hash of the motor controller
+type EngineECU struct {
+	State string
+	// Speed is in km/h
+	Speed int
+	RPM   int
+	// Odometer is in km
+	Odometer float64
+	// Voltage is in V and Current in A
+	Voltage float64
+	Current float64
+	// Temperature is in °C
+	Temperature     int
+	Throttle        bool
+	KERS            bool
+	FirmwareVersion string
+
+	Fields
+}
+
+// ParseEngineECU reads the engine-ecu hash
+func ParseEngineECU(data map[string]string) *EngineECU {
+	e := &EngineECU{}
+	p := newParser(EngineECUKey, data, &e.Fields)
+	e.State = p.str("state")
+	e.Speed = p.int("speed")
+	e.RPM = p.int("rpm")
+	e.Odometer = p.milli("odometer")
+	e.Voltage = p.milli("motor:voltage")
+	e.Current = p.milli("motor:current")
+	e.Temperature = p.int("temperature")
+	e.Throttle = p.bool("throttle")
+	e.KERS = p.bool("kers")
+	e.FirmwareVersion = p.str("fw-version")
+	return e
+}
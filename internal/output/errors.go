@@ -0,0 +1,154 @@
+package output
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// Class is the failure class of an error and determines the exit code
+type Class int
+
+const (
+	ClassGeneral         Class = iota // anything not covered below
+	ClassInvalidArgument              // bad arguments, flags or unknown commands
+	ClassConnection                   // Redis or SSH not reachable
+	ClassTimeout                      // no confirmation within the timeout
+	ClassRejected                     // the scooter service refused the request
+	ClassPrecondition                 // the scooter is in a state the command is unsafe in
+	ClassForbidden                    // read-only mode or the access lists deny the command
+)
+
+// Exit codes returned by lsc, one per failure class
+const (
+	ExitOK              = 0
+	ExitGeneral         = 1
+	ExitInvalidArgument = 2
+	ExitConnection      = 3
+	ExitTimeout         = 4
+	ExitRejected        = 5
+	ExitPrecondition    = 6
+	ExitForbidden       = 7
+)
+
+// Error is an error tagged with a failure class
+type Error struct {
+	Class Class
+	Err   error
+	// Details are included in the JSON error, e.g. why a command was blocked
+	Details interface{}
+
+	// reported marks errors whose details were already rendered as part of the result
+	reported bool
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// InvalidArgument returns an error for bad user input
+func InvalidArgument(format string, args ...interface{}) error {
+	return &Error{Class: ClassInvalidArgument, Err: fmt.Errorf(format, args...)}
+}
+
+// Connection returns an error for an unreachable Redis server or scooter
+func Connection(format string, args ...interface{}) error {
+	return &Error{Class: ClassConnection, Err: fmt.Errorf(format, args...)}
+}
+
+// Timeout returns an error for a state change that was not confirmed in time
+func Timeout(format string, args ...interface{}) error {
+	return &Error{Class: ClassTimeout, Err: fmt.Errorf(format, args...)}
+}
+
+// Rejected returns an error for a request the scooter refused
+func Rejected(format string, args ...interface{}) error {
+	return &Error{Class: ClassRejected, Err: fmt.Errorf(format, args...)}
+}
+
+// Precondition returns an error for a command refused because of the
+// scooter's state; details explain why
+func Precondition(details interface{}, format string, args ...interface{}) error {
+	return &Error{Class: ClassPrecondition, Err: fmt.Errorf(format, args...), Details: details}
+}
+
+// Forbidden returns an error for a command the user may not run
+func Forbidden(format string, args ...interface{}) error {
+	return &Error{Class: ClassForbidden, Err: fmt.Errorf(format, args...)}
+}
+
+// Reported wraps err so it only sets the exit code; PrintError stays silent.
+// Use it when the result was already rendered but the command should still fail.
+func Reported(err error) error {
+	return &Error{Class: ClassOf(err), Err: err, reported: true}
+}
+
+// ClassOf determines the failure class of err. Untagged errors are classified
+// by their cause: deadlines are timeouts, network errors are connection errors.
+func ClassOf(err error) Class {
+	var tagged *Error
+	if errors.As(err, &tagged) {
+		return tagged.Class
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ClassTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ClassTimeout
+		}
+		return ClassConnection
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return ClassConnection
+	}
+	return ClassGeneral
+}
+
+// ExitCode returns the process exit code for err (0 for nil)
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	switch ClassOf(err) {
+	case ClassInvalidArgument:
+		return ExitInvalidArgument
+	case ClassConnection:
+		return ExitConnection
+	case ClassTimeout:
+		return ExitTimeout
+	case ClassRejected:
+		return ExitRejected
+	case ClassPrecondition:
+		return ExitPrecondition
+	case ClassForbidden:
+		return ExitForbidden
+	}
+	return ExitGeneral
+}
+
+// Code returns the machine-readable name of the failure class of err
+func Code(err error) string {
+	switch ClassOf(err) {
+	case ClassInvalidArgument:
+		return "invalid_argument"
+	case ClassConnection:
+		return "connection"
+	case ClassTimeout:
+		return "timeout"
+	case ClassRejected:
+		return "rejected"
+	case ClassPrecondition:
+		return "precondition_failed"
+	case ClassForbidden:
+		return "permission_denied"
+	}
+	return "error"
+}
@@ -0,0 +1,351 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"librescoot/lsc/internal/format"
+)
+
+// Format selects how results are rendered
+type Format int
+
+const (
+	FormatPretty   Format = iota // human-readable output of each command
+	FormatJSON                   // result envelope as JSON
+	FormatYAML                   // result envelope as YAML
+	FormatCSV                    // data as comma-separated rows
+	FormatTable                  // data as an aligned table
+	FormatTemplate               // data executed through a Go template
+)
+
+// templateFuncs are available in template= output
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"join": func(sep string, v interface{}) string {
+		return joinScalars(v, sep)
+	},
+}
+
+// SetFormat selects the output format from an --output value:
+// pretty, json, yaml, csv, table or template=<go-template>. An empty spec
+// selects pretty output.
+func (r *Renderer) SetFormat(spec string) error {
+	name, arg, hasArg := strings.Cut(spec, "=")
+	if hasArg && name != "template" {
+		return InvalidArgument("output format '%s' takes no argument", name)
+	}
+
+	switch name {
+	case "", "pretty":
+		r.format = FormatPretty
+	case "json":
+		r.format = FormatJSON
+	case "yaml", "yml":
+		r.format = FormatYAML
+	case "csv":
+		r.format = FormatCSV
+	case "table":
+		r.format = FormatTable
+	case "template":
+		if arg == "" {
+			return InvalidArgument("template output needs a template, e.g. --output 'template={{.vehicle.state}}'")
+		}
+		tmpl, err := template.New("output").Funcs(templateFuncs).Option("missingkey=zero").Parse(arg)
+		if err != nil {
+			return InvalidArgument("invalid output template: %w", err)
+		}
+		r.format = FormatTemplate
+		r.template = tmpl
+	default:
+		return InvalidArgument("unknown output format '%s' (valid: pretty, json, yaml, csv, table, template=<go-template>)", name)
+	}
+	return nil
+}
+
+// CurrentFormat returns the selected output format
+func (r *Renderer) CurrentFormat() Format {
+	return r.format
+}
+
+// Structured reports whether a machine-readable format is selected. Commands
+// use it to suppress progress messages meant for humans.
+func (r *Renderer) Structured() bool {
+	return r.format != FormatPretty
+}
+
+// SetFields restricts rendered data to the given dot-separated paths
+// (e.g. vehicle.state, battery_0.charge_percent). A leading dot is optional.
+func (r *Renderer) SetFields(paths []string) {
+	r.fields = nil
+	for _, path := range paths {
+		path = strings.TrimPrefix(strings.TrimSpace(path), ".")
+		if path != "" {
+			r.fields = append(r.fields, path)
+		}
+	}
+}
+
+// Lookup resolves a dot-separated path in JSON-like data. Numeric segments
+// index into arrays.
+func Lookup(value interface{}, path string) (interface{}, bool) {
+	path = strings.TrimPrefix(path, ".")
+	if path == "" {
+		return value, true
+	}
+	for _, part := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			next, ok := v[part]
+			if !ok {
+				return nil, false
+			}
+			value = next
+		case []interface{}:
+			idx, err := strconv.Atoi(part)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil, false
+			}
+			value = v[idx]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// normalize converts typed command data (structs, typed maps and slices) to
+// the generic map/slice form, using the same field names as the JSON output
+func normalize(data interface{}) (interface{}, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return nil, err
+	}
+	return generic, nil
+}
+
+// selectFields applies --fields to data. Lists are filtered element by
+// element; paths that do not exist are left out.
+func (r *Renderer) selectFields(data interface{}) interface{} {
+	if len(r.fields) == 0 {
+		return data
+	}
+
+	if list, ok := data.([]interface{}); ok {
+		selected := make([]interface{}, 0, len(list))
+		for _, item := range list {
+			selected = append(selected, r.selectFields(item))
+		}
+		return selected
+	}
+
+	selected := make(map[string]interface{})
+	for _, path := range r.fields {
+		value, ok := Lookup(data, path)
+		if !ok {
+			continue
+		}
+		setPath(selected, strings.Split(path, "."), value)
+	}
+	return selected
+}
+
+func setPath(m map[string]interface{}, parts []string, value interface{}) {
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := m[part].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			m[part] = next
+		}
+		m = next
+	}
+	m[parts[len(parts)-1]] = value
+}
+
+// writeData renders data in one of the data-only formats (csv, table, template)
+func (r *Renderer) writeData(data interface{}) error {
+	if r.format == FormatTemplate {
+		var b strings.Builder
+		if err := r.template.Execute(&b, data); err != nil {
+			return InvalidArgument("failed to execute output template: %w", err)
+		}
+		out := b.String()
+		if !strings.HasSuffix(out, "\n") {
+			out += "\n"
+		}
+		fmt.Print(out)
+		return nil
+	}
+
+	headers, rows := r.tabulate(data)
+	if r.format == FormatTable {
+		upper := make([]string, len(headers))
+		for i, header := range headers {
+			upper[i] = strings.ToUpper(header)
+		}
+		format.PrintTable(upper, rows)
+		return nil
+	}
+
+	w := csv.NewWriter(os.Stdout)
+	w.Write(headers)
+	w.WriteAll(rows)
+	return w.Error()
+}
+
+// tabulate turns data into rows. A list becomes one row per element with
+// flattened fields as columns; a single object becomes field/value rows.
+func (r *Renderer) tabulate(data interface{}) ([]string, [][]string) {
+	list, isList := data.([]interface{})
+	if !isList {
+		flat := flatten(data)
+		rows := make([][]string, 0, len(flat))
+		for _, column := range r.orderColumns(sortedKeys(flat)) {
+			if value, ok := flat[column]; ok {
+				rows = append(rows, []string{column, value})
+			}
+		}
+		return []string{"field", "value"}, rows
+	}
+
+	flats := make([]map[string]string, 0, len(list))
+	var keys []string
+	seen := make(map[string]bool)
+	for _, item := range list {
+		flat := flatten(item)
+		flats = append(flats, flat)
+		for _, key := range sortedKeys(flat) {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	columns := r.orderColumns(keys)
+
+	rows := make([][]string, 0, len(flats))
+	for _, flat := range flats {
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			row[i] = flat[column]
+		}
+		rows = append(rows, row)
+	}
+	return columns, rows
+}
+
+// orderColumns puts flattened keys in --fields order. A field naming an
+// object expands to all of its leaves; a field without values keeps an
+// empty column.
+func (r *Renderer) orderColumns(keys []string) []string {
+	if len(r.fields) == 0 {
+		return keys
+	}
+	var columns []string
+	for _, field := range r.fields {
+		matched := false
+		for _, key := range keys {
+			if key == field || strings.HasPrefix(key, field+".") {
+				columns = append(columns, key)
+				matched = true
+			}
+		}
+		if !matched {
+			columns = append(columns, field)
+		}
+	}
+	return columns
+}
+
+// flatten maps every leaf of value to its dot path. Lists of scalars are
+// joined with ';' so they fit into one cell.
+func flatten(value interface{}) map[string]string {
+	flat := make(map[string]string)
+	var walk func(prefix string, v interface{})
+	walk = func(prefix string, v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for key, child := range v {
+				walk(joinPath(prefix, key), child)
+			}
+		case []interface{}:
+			if isScalarList(v) {
+				flat[prefix] = joinScalars(v, ";")
+				return
+			}
+			for i, child := range v {
+				walk(joinPath(prefix, strconv.Itoa(i)), child)
+			}
+		default:
+			if prefix == "" {
+				prefix = "value"
+			}
+			flat[prefix] = scalarString(v)
+		}
+	}
+	walk("", value)
+	return flat
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func isScalarList(list []interface{}) bool {
+	for _, item := range list {
+		switch item.(type) {
+		case map[string]interface{}, []interface{}:
+			return false
+		}
+	}
+	return true
+}
+
+func joinScalars(v interface{}, sep string) string {
+	list, ok := v.([]interface{})
+	if !ok {
+		return scalarString(v)
+	}
+	parts := make([]string, len(list))
+	for i, item := range list {
+		parts[i] = scalarString(item)
+	}
+	return strings.Join(parts, sep)
+}
+
+func scalarString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	}
+	return fmt.Sprint(v)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
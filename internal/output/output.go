@@ -0,0 +1,193 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"librescoot/lsc/internal/format"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Result is the envelope every command prints in JSON mode
+type Result struct {
+	Command    string      `json:"command" yaml:"command"`
+	Status     string      `json:"status" yaml:"status"`
+	Data       interface{} `json:"data,omitempty" yaml:"data,omitempty"`
+	Error      *ErrorInfo  `json:"error,omitempty" yaml:"error,omitempty"`
+	DurationMs int64       `json:"duration_ms" yaml:"duration_ms"`
+}
+
+// ErrorInfo describes a failed command
+type ErrorInfo struct {
+	Code    string      `json:"code" yaml:"code"`
+	Message string      `json:"message" yaml:"message"`
+	Details interface{} `json:"details,omitempty" yaml:"details,omitempty"`
+}
+
+// Renderer prints the results of the command being executed in the
+// selected format
+type Renderer struct {
+	command  string
+	started  time.Time
+	format   Format
+	template *template.Template
+	fields   []string
+}
+
+// NewRenderer returns a renderer printing in the pretty format
+func NewRenderer() *Renderer {
+	return &Renderer{started: time.Now()}
+}
+
+// Default is a renderer shared by the package-level functions, which are
+// shorthands for its methods
+var Default = NewRenderer()
+
+// Begin records the name and start time of the command being executed
+func (r *Renderer) Begin(name string) {
+	r.command = name
+	r.started = time.Now()
+}
+
+// State is the renderer configuration of one command
+type State struct {
+	renderer Renderer
+}
+
+// Save returns the renderer state, so a command that runs other commands can
+// Restore it before printing its own result
+func (r *Renderer) Save() State {
+	return State{renderer: *r}
+}
+
+// Restore reinstates a state returned by Save
+func (r *Renderer) Restore(s State) {
+	*r = s.renderer
+}
+
+// Render prints a successful result in the selected format: the result
+// envelope around data for json and yaml, data alone for csv, table and
+// template, otherwise whatever pretty prints. --fields is applied to data in
+// every structured format. The returned error is non-nil only if data cannot
+// be rendered, so commands can end with `return output.Render(...)`.
+func (r *Renderer) Render(data interface{}, pretty func()) error {
+	if r.format == FormatPretty {
+		if pretty != nil {
+			pretty()
+		}
+		return nil
+	}
+
+	if len(r.fields) > 0 || (r.format != FormatJSON && r.format != FormatYAML) {
+		generic, err := normalize(data)
+		if err != nil {
+			return err
+		}
+		data = r.selectFields(generic)
+	}
+
+	switch r.format {
+	case FormatJSON, FormatYAML:
+		r.writeResult(Result{
+			Command:    r.command,
+			Status:     "success",
+			Data:       data,
+			DurationMs: time.Since(r.started).Milliseconds(),
+		})
+		return nil
+	}
+	return r.writeData(data)
+}
+
+// PrintError prints a failed result: the envelope on stdout for json and yaml,
+// otherwise a red error message on stderr
+func (r *Renderer) PrintError(err error) {
+	var tagged *Error
+	if errors.As(err, &tagged) && tagged.reported {
+		return
+	}
+
+	if r.format == FormatJSON || r.format == FormatYAML {
+		info := &ErrorInfo{
+			Code:    Code(err),
+			Message: err.Error(),
+		}
+		if tagged != nil {
+			info.Details = tagged.Details
+		}
+		r.writeResult(Result{
+			Command:    r.command,
+			Status:     "error",
+			Error:      info,
+			DurationMs: time.Since(r.started).Milliseconds(),
+		})
+		return
+	}
+	fmt.Fprintln(os.Stderr, format.Error("Error: "+err.Error()))
+}
+
+func (r *Renderer) writeResult(result Result) {
+	var data []byte
+	var err error
+	if r.format == FormatYAML {
+		data, err = marshalYAML(result)
+	} else {
+		data, err = json.MarshalIndent(result, "", "  ")
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error marshaling result: %v\n", err)
+		return
+	}
+	fmt.Println(strings.TrimSuffix(string(data), "\n"))
+}
+
+// marshalYAML encodes result with the same field names as the JSON output
+func marshalYAML(result Result) ([]byte, error) {
+	data, err := normalize(result.Data)
+	if err != nil {
+		return nil, err
+	}
+	result.Data = data
+
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(result); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), enc.Close()
+}
+
+// Begin records the name and start time of the command being executed
+func Begin(name string) { Default.Begin(name) }
+
+// Save returns the state of the Default renderer
+func Save() State { return Default.Save() }
+
+// Restore reinstates a state of the Default renderer returned by Save
+func Restore(s State) { Default.Restore(s) }
+
+// SetFormat selects the output format of the Default renderer
+func SetFormat(spec string) error { return Default.SetFormat(spec) }
+
+// SetFields restricts the data rendered by the Default renderer
+func SetFields(paths []string) { Default.SetFields(paths) }
+
+// CurrentFormat returns the output format of the Default renderer
+func CurrentFormat() Format { return Default.CurrentFormat() }
+
+// Structured reports whether the Default renderer prints a machine-readable format
+func Structured() bool { return Default.Structured() }
+
+// Render prints a successful result through the Default renderer
+func Render(data interface{}, pretty func()) error { return Default.Render(data, pretty) }
+
+// PrintError prints a failed result through the Default renderer
+func PrintError(err error) { Default.PrintError(err) }
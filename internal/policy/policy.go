@@ -0,0 +1,120 @@
+// Package policy checks the scooter's state before commands that are unsafe
+// while it is being ridden or charged, such as locking the handlebar or
+// rebooting.
+package policy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"librescoot/lsc/internal/model"
+	"librescoot/lsc/internal/output"
+	"librescoot/lsc/internal/redis"
+)
+
+// Condition is a scooter state a command can be unsafe in
+type Condition string
+
+const (
+	Moving       Condition = "moving"         // engine-ecu speed above 0
+	ReadyToDrive Condition = "ready_to_drive" // vehicle state ready-to-drive
+	KickstandUp  Condition = "kickstand_up"   // vehicle kickstand up
+	// Charging holds while a main battery is being charged. It is read from
+	// the battery current, which is positive while current flows into the
+	// battery (charging) and negative while the battery discharges.
+	Charging Condition = "charging"
+)
+
+// Riding are the conditions of a scooter that is or may be ridden
+var Riding = []Condition{Moving, ReadyToDrive, KickstandUp}
+
+// All are all conditions
+var All = []Condition{Moving, ReadyToDrive, KickstandUp, Charging}
+
+// Violation is a condition that holds, and the reading that shows it
+type Violation struct {
+	Condition Condition `json:"condition"`
+	Reason    string    `json:"reason"`
+}
+
+// State is what the checks look at
+type State struct {
+	Vehicle   *model.Vehicle
+	ECU       *model.EngineECU
+	Batteries []*model.Battery
+}
+
+// Read reads the vehicle, engine-ecu and main battery hashes
+func Read(ctx context.Context, client *redis.Client) (*State, error) {
+	vehicle, err := client.HGetAllWithContext(ctx, model.VehicleKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", model.VehicleKey, err)
+	}
+	ecu, err := client.HGetAllWithContext(ctx, model.EngineECUKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", model.EngineECUKey, err)
+	}
+	s := &State{
+		Vehicle: model.ParseVehicle(vehicle),
+		ECU:     model.ParseEngineECU(ecu),
+	}
+	for _, slot := range model.BatterySlots {
+		data, err := client.HGetAllWithContext(ctx, model.BatteryKey(slot))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", model.BatteryKey(slot), err)
+		}
+		s.Batteries = append(s.Batteries, model.ParseBattery(slot, data))
+	}
+	return s, nil
+}
+
+// Check returns the conditions that hold. Fields the scooter does not
+// publish do not count as a violation.
+func (s *State) Check(conditions ...Condition) []Violation {
+	var violations []Violation
+	for _, condition := range conditions {
+		if reason := s.reason(condition); reason != "" {
+			violations = append(violations, Violation{Condition: condition, Reason: reason})
+		}
+	}
+	return violations
+}
+
+// reason describes why condition holds, or returns "" if it does not
+func (s *State) reason(condition Condition) string {
+	switch condition {
+	case Moving:
+		if s.ECU.Speed > 0 {
+			return fmt.Sprintf("scooter is moving at %d km/h", s.ECU.Speed)
+		}
+	case ReadyToDrive:
+		if s.Vehicle.State == "ready-to-drive" {
+			return "vehicle is ready to drive"
+		}
+	case KickstandUp:
+		if s.Vehicle.Kickstand == "up" {
+			return "kickstand is up"
+		}
+	case Charging:
+		// The battery current flows into the battery while charging
+		for _, battery := range s.Batteries {
+			if battery.Present && battery.Current > 0 {
+				return fmt.Sprintf("battery %d is charging at %.1f A", battery.Slot, battery.Current)
+			}
+		}
+	}
+	return ""
+}
+
+// Blocked returns the error for a command refused because of violations.
+// The violations are included in the JSON error details.
+func Blocked(command string, violations []Violation) error {
+	reasons := make([]string, len(violations))
+	for i, v := range violations {
+		reasons[i] = v.Reason
+	}
+	return output.Precondition(map[string]interface{}{
+		"violations": violations,
+	}, "refusing to %s: %s (use --force to override)", command, strings.Join(reasons, ", "))
+}
@@ -2,8 +2,13 @@ package redis
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"log"
+	"net"
+	"os"
 	"time"
 
 	rdb "github.com/redis/go-redis/v9"
@@ -11,9 +16,32 @@ import (
 
 // Client is a wrapper around the go-redis client with common functionality
 type Client struct {
-	client *rdb.Client
-	ctx    context.Context
-	logger *log.Logger
+	client         *rdb.Client
+	ctx            context.Context
+	logger         *log.Logger
+	connectTimeout time.Duration
+	hook           *writeHook
+}
+
+// Options configures how a Client connects to Redis
+type Options struct {
+	Addr     string // host:port, ignored when Socket is set
+	Socket   string // unix socket path
+	Username string // ACL user (Redis 6+)
+	Password string
+	DB       int
+
+	TLS         bool
+	TLSCAFile   string // PEM bundle used to verify the server certificate
+	TLSCertFile string // client certificate for mutual TLS
+	TLSKeyFile  string
+
+	ConnectTimeout time.Duration // timeout for the initial ping (default 5s)
+	DialTimeout    time.Duration // timeout for establishing connections (go-redis default when zero)
+	ReadTimeout    time.Duration // socket read timeout (go-redis default when zero)
+
+	// Dialer overrides how connections are established, e.g. through an SSH tunnel
+	Dialer func(ctx context.Context, network, addr string) (net.Conn, error)
 }
 
 // XMessage represents a message from a Redis stream
@@ -28,17 +56,84 @@ type PubSub = rdb.PubSub
 // XReadArgs represents arguments for XREAD command
 type XReadArgs = rdb.XReadArgs
 
+// Pipeliner queues commands of a pipeline or transaction
+type Pipeliner = rdb.Pipeliner
+
+// Nil is returned when a key or hash field does not exist
+const Nil = rdb.Nil
+
 // NewClient creates a new Redis client instance
 func NewClient(addr string) *Client {
+	client, _ := NewClientWithOptions(Options{Addr: addr}) // cannot fail without TLS files
+	return client
+}
+
+// NewClientWithOptions creates a new Redis client instance from connection options
+func NewClientWithOptions(opts Options) (*Client, error) {
+	rdbOpts := &rdb.Options{
+		Addr:             opts.Addr,
+		Username:         opts.Username,
+		Password:         opts.Password,
+		DB:               opts.DB,
+		DialTimeout:      opts.DialTimeout,
+		ReadTimeout:      opts.ReadTimeout,
+		Dialer:           opts.Dialer,
+		DisableIndentity: true, // Disable client identity features for older Redis versions
+	}
+	if opts.Socket != "" {
+		rdbOpts.Network = "unix"
+		rdbOpts.Addr = opts.Socket
+	}
+
+	if opts.TLS || opts.TLSCAFile != "" || opts.TLSCertFile != "" {
+		tlsConfig, err := buildTLSConfig(opts)
+		if err != nil {
+			return nil, err
+		}
+		rdbOpts.TLSConfig = tlsConfig
+	}
+
+	connectTimeout := opts.ConnectTimeout
+	if connectTimeout <= 0 {
+		connectTimeout = 5 * time.Second
+	}
+
 	return &Client{
-		client: rdb.NewClient(&rdb.Options{
-			Addr:            addr,
-			DB:              0, // use default DB
-			DisableIndentity: true, // Disable client identity features for older Redis versions
-		}),
-		ctx:    context.Background(),
-		logger: log.New(log.Writer(), "[Redis] ", log.LstdFlags),
+		client:         rdb.NewClient(rdbOpts),
+		ctx:            context.Background(),
+		logger:         log.New(log.Writer(), "[Redis] ", log.LstdFlags),
+		connectTimeout: connectTimeout,
+	}, nil
+}
+
+// buildTLSConfig loads the CA bundle and client certificate referenced by opts
+func buildTLSConfig(opts Options) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if opts.TLSCAFile != "" {
+		pem, err := os.ReadFile(opts.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read TLS CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in TLS CA file %s", opts.TLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
 	}
+
+	if opts.TLSCertFile != "" || opts.TLSKeyFile != "" {
+		if opts.TLSCertFile == "" || opts.TLSKeyFile == "" {
+			return nil, fmt.Errorf("TLS client certificate and key must be given together")
+		}
+		cert, err := tls.LoadX509KeyPair(opts.TLSCertFile, opts.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
 }
 
 // SetLogger sets a custom logger (use io.Discard to disable logging)
@@ -48,7 +143,7 @@ func (c *Client) SetLogger(l *log.Logger) {
 
 // Connect pings the Redis server to ensure connectivity
 func (c *Client) Connect() error {
-	ctx, cancel := context.WithTimeout(c.ctx, 5*time.Second)
+	ctx, cancel := context.WithTimeout(c.ctx, c.connectTimeout)
 	defer cancel()
 
 	if err := c.client.Ping(ctx).Err(); err != nil {
@@ -118,6 +213,60 @@ func (c *Client) LPushWithContext(ctx context.Context, key, value string) error
 	return c.client.LPush(ctx, key, value).Err()
 }
 
+// LLenWithContext returns the length of a list with context
+func (c *Client) LLenWithContext(ctx context.Context, key string) (int64, error) {
+	return c.client.LLen(ctx, key).Result()
+}
+
+// LRangeWithContext returns the elements of a list between start and stop with context
+func (c *Client) LRangeWithContext(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	return c.client.LRange(ctx, key, start, stop).Result()
+}
+
+// BRPopWithContext pops a value from the tail of the first non-empty list,
+// waiting up to timeout. It returns the list name and the value, or Nil on timeout.
+func (c *Client) BRPopWithContext(ctx context.Context, timeout time.Duration, keys ...string) (string, string, error) {
+	result, err := c.client.BRPop(ctx, timeout, keys...).Result()
+	if err != nil {
+		return "", "", err
+	}
+	return result[0], result[1], nil
+}
+
+// DelWithContext deletes one or more keys with context
+func (c *Client) DelWithContext(ctx context.Context, keys ...string) error {
+	return c.client.Del(ctx, keys...).Err()
+}
+
+// SAddWithContext adds members to a set with context
+func (c *Client) SAddWithContext(ctx context.Context, key string, members ...string) error {
+	args := make([]interface{}, len(members))
+	for i, m := range members {
+		args[i] = m
+	}
+	return c.client.SAdd(ctx, key, args...).Err()
+}
+
+// SRemWithContext removes members from a set with context
+func (c *Client) SRemWithContext(ctx context.Context, key string, members ...string) error {
+	args := make([]interface{}, len(members))
+	for i, m := range members {
+		args[i] = m
+	}
+	return c.client.SRem(ctx, key, args...).Err()
+}
+
+// XAddWithContext appends an entry to a stream, trimming it to about maxLen
+// entries, and returns the entry ID
+func (c *Client) XAddWithContext(ctx context.Context, stream string, maxLen int64, values map[string]interface{}) (string, error) {
+	return c.client.XAdd(ctx, &rdb.XAddArgs{
+		Stream: stream,
+		MaxLen: maxLen,
+		Approx: true,
+		Values: values,
+	}).Result()
+}
+
 // SMembers retrieves all members of a set
 func (c *Client) SMembers(key string) ([]string, error) {
 	return c.client.SMembers(c.ctx, key).Result()
@@ -152,6 +301,51 @@ func (c *Client) XReadStreams(ctx context.Context, streams ...string) ([]XStream
 	}).Result()
 }
 
+// XRevRangeN returns the newest count messages of a stream, newest first
+func (c *Client) XRevRangeN(ctx context.Context, stream string, count int64) ([]XMessage, error) {
+	return c.client.XRevRangeN(ctx, stream, "+", "-", count).Result()
+}
+
+// XRangeWithContext returns the messages of a stream between the IDs start
+// and stop ("-" and "+" for either end), oldest first
+func (c *Client) XRangeWithContext(ctx context.Context, stream, start, stop string) ([]XMessage, error) {
+	return c.client.XRange(ctx, stream, start, stop).Result()
+}
+
+// FollowStream sends the messages added to a stream after lastID ("$" for
+// new ones only) until ctx is cancelled. Read errors are retried.
+func (c *Client) FollowStream(ctx context.Context, stream, lastID string, messages chan<- XMessage) {
+	for ctx.Err() == nil {
+		streams, err := c.XRead(ctx, &XReadArgs{
+			Streams: []string{stream, lastID},
+			Count:   10,
+			Block:   time.Second,
+		})
+		if err != nil && !errors.Is(err, Nil) && ctx.Err() == nil {
+			// Back off while the connection is down
+			time.Sleep(time.Second)
+			continue
+		}
+		if len(streams) == 0 {
+			continue
+		}
+		for _, msg := range streams[0].Messages {
+			lastID = msg.ID
+			select {
+			case messages <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// TxPipelined runs the commands queued by fn in one MULTI/EXEC transaction
+func (c *Client) TxPipelined(ctx context.Context, fn func(Pipeliner) error) error {
+	_, err := c.client.TxPipelined(ctx, fn)
+	return err
+}
+
 // Pipeline creates a new pipeline for batching commands
 func (c *Client) Pipeline() rdb.Pipeliner {
 	return c.client.Pipeline()
@@ -0,0 +1,115 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	rdb "github.com/redis/go-redis/v9"
+)
+
+// writeCommands are the Redis commands that change data or notify services
+var writeCommands = map[string]bool{
+	"set": true, "del": true, "unlink": true, "expire": true, "rename": true,
+	"incr": true, "incrby": true, "decr": true, "decrby": true,
+	"hset": true, "hsetnx": true, "hmset": true, "hdel": true, "hincrby": true,
+	"lpush": true, "rpush": true, "lpop": true, "rpop": true, "blpop": true,
+	"brpop": true, "lrem": true, "lset": true, "ltrim": true,
+	"sadd": true, "srem": true, "zadd": true, "zrem": true,
+	"xadd": true, "xdel": true, "xtrim": true,
+	"publish": true, "flushdb": true, "flushall": true,
+}
+
+// WriteGuard decides whether a write may be sent. It is called with the
+// command line, e.g. "LPUSH scooter:state", and refuses with an error.
+type WriteGuard func(command string) error
+
+// WriteObserver is told about every write that was sent, with its arguments
+// (the command name first, e.g. LPUSH scooter:state lock) and its error
+type WriteObserver func(args []string, err error)
+
+// SetWriteGuard checks every write sent through the client, including
+// pipelines, with guard
+func (c *Client) SetWriteGuard(guard WriteGuard) {
+	c.writeHook().guard = guard
+}
+
+// ObserveWrites calls observer after every write the guard let through
+func (c *Client) ObserveWrites(observer WriteObserver) {
+	c.writeHook().observe = observer
+}
+
+// writeHook returns the hook of the client, adding it on first use
+func (c *Client) writeHook() *writeHook {
+	if c.hook == nil {
+		c.hook = &writeHook{}
+		c.client.AddHook(c.hook)
+	}
+	return c.hook
+}
+
+// writeHook is the go-redis hook applying a WriteGuard and WriteObserver
+type writeHook struct {
+	guard   WriteGuard
+	observe WriteObserver
+}
+
+func isWrite(cmd rdb.Cmder) bool {
+	return writeCommands[strings.ToLower(cmd.Name())]
+}
+
+func (h *writeHook) check(cmd rdb.Cmder) error {
+	if h.guard == nil || !isWrite(cmd) {
+		return nil
+	}
+	line := strings.ToUpper(cmd.Name())
+	if args := cmd.Args(); len(args) > 1 {
+		line += fmt.Sprintf(" %v", args[1])
+	}
+	return h.guard(line)
+}
+
+func (h *writeHook) report(cmd rdb.Cmder) {
+	if h.observe == nil || !isWrite(cmd) {
+		return
+	}
+	args := make([]string, len(cmd.Args()))
+	for i, arg := range cmd.Args() {
+		args[i] = fmt.Sprint(arg)
+	}
+	args[0] = strings.ToUpper(cmd.Name())
+	h.observe(args, cmd.Err())
+}
+
+func (h *writeHook) DialHook(next rdb.DialHook) rdb.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (h *writeHook) ProcessHook(next rdb.ProcessHook) rdb.ProcessHook {
+	return func(ctx context.Context, cmd rdb.Cmder) error {
+		if err := h.check(cmd); err != nil {
+			return err
+		}
+		err := next(ctx, cmd)
+		h.report(cmd)
+		return err
+	}
+}
+
+func (h *writeHook) ProcessPipelineHook(next rdb.ProcessPipelineHook) rdb.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []rdb.Cmder) error {
+		for _, cmd := range cmds {
+			if err := h.check(cmd); err != nil {
+				return err
+			}
+		}
+		err := next(ctx, cmds)
+		for _, cmd := range cmds {
+			h.report(cmd)
+		}
+		return err
+	}
+}
@@ -0,0 +1,123 @@
+package runner
+
+import (
+	"bytes"
+	"io"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Runner executes system commands (systemctl, journalctl, ...) either on the
+// local machine or on the scooter over SSH
+type Runner interface {
+	Run(cmd *Cmd) error
+}
+
+// Cmd describes a command to execute, modelled on exec.Cmd
+type Cmd struct {
+	Name   string
+	Args   []string
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	runner Runner
+}
+
+// Command returns a Cmd that will be executed by r
+func Command(r Runner, name string, args ...string) *Cmd {
+	return &Cmd{Name: name, Args: args, runner: r}
+}
+
+// Run starts the command and waits for it to complete
+func (c *Cmd) Run() error {
+	return c.runner.Run(c)
+}
+
+// Output runs the command and returns its standard output
+func (c *Cmd) Output() ([]byte, error) {
+	var stdout bytes.Buffer
+	c.Stdout = &stdout
+	err := c.Run()
+	return stdout.Bytes(), err
+}
+
+// Local runs commands on this machine
+type Local struct{}
+
+// Run executes the command with os/exec
+func (Local) Run(c *Cmd) error {
+	cmd := exec.Command(c.Name, c.Args...)
+	cmd.Stdin = c.Stdin
+	cmd.Stdout = c.Stdout
+	cmd.Stderr = c.Stderr
+	return cmd.Run()
+}
+
+// Guarded runs commands with Runner once Check allows them, and tells Done
+// how they went
+type Guarded struct {
+	Runner
+	Check func(c *Cmd) error
+	Done  func(c *Cmd, err error)
+}
+
+// Run checks the command and runs it
+func (g Guarded) Run(c *Cmd) error {
+	if err := g.Check(c); err != nil {
+		return err
+	}
+	err := g.Runner.Run(c)
+	if g.Done != nil {
+		g.Done(c, err)
+	}
+	return err
+}
+
+// Remote reports whether r runs commands on another machine
+func Remote(r Runner) bool {
+	switch r := r.(type) {
+	case Guarded:
+		return Remote(r.Runner)
+	case SSH:
+		return true
+	}
+	return false
+}
+
+// SSH runs commands on a remote host, one session per command
+type SSH struct {
+	Client *ssh.Client
+}
+
+// Run executes the command in a new SSH session
+func (r SSH) Run(c *Cmd) error {
+	session, err := r.Client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	session.Stdin = c.Stdin
+	session.Stdout = c.Stdout
+	session.Stderr = c.Stderr
+	return session.Run(shellJoin(c.Name, c.Args))
+}
+
+// shellJoin quotes the command line for the remote shell
+func shellJoin(name string, args []string) string {
+	words := make([]string, 0, len(args)+1)
+	for _, word := range append([]string{name}, args...) {
+		words = append(words, shellQuote(word))
+	}
+	return strings.Join(words, " ")
+}
+
+func shellQuote(word string) string {
+	if word != "" && strings.Trim(word, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:=@,+") == "" {
+		return word
+	}
+	return "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+}
@@ -0,0 +1,335 @@
+package sim
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// seatboxOpenTime is how long the seatbox stays open before the simulated
+// rider closes it
+const seatboxOpenTime = 5 * time.Second
+
+// faultDuration is how long an injected fault stays active
+const faultDuration = 10 * time.Second
+
+// handle runs one command popped from a command list
+func (s *Simulator) handle(list, command string) {
+	s.logf("%s %s", list, command)
+	switch list {
+	case "scooter:state":
+		s.handleState(command)
+	case "scooter:seatbox":
+		s.handleSeatbox(command)
+	case "scooter:blinker":
+		s.handleBlinker(command)
+	case "scooter:horn":
+		// The horn has no state in Redis
+	case "scooter:alarm":
+		s.handleAlarm(command)
+	case "scooter:power":
+		s.handlePower(command)
+	case "scooter:hardware":
+		s.handleHardware(command)
+	case "scooter:update":
+		s.handleUpdate(command)
+	}
+}
+
+// handleState runs the simplified vehicle state machine:
+// stand-by <-> parked <-> ready-to-drive, locking through shutting-down
+func (s *Simulator) handleState(command string) {
+	s.transition++
+	switch command {
+	case "unlock":
+		s.unlock()
+	case "lock":
+		s.lock(false)
+	case "lock-hibernate":
+		s.lock(true)
+	case "force-lock":
+		s.standBy(false)
+	default:
+		s.logf("Ignoring unknown state command '%s'", command)
+	}
+}
+
+func (s *Simulator) unlock() {
+	if state := s.get("vehicle", "state"); state == "parked" || state == "ready-to-drive" {
+		// Announce the state again, so clients waiting for it are not left hanging
+		s.setState(state)
+		return
+	}
+	s.set("power-manager", "state", "running")
+	s.set("alarm", "status", "disarmed")
+	s.afterTransition(s.opts.Delay, func() {
+		s.set("vehicle", "handlebar:lock-sensor", "unlocked")
+		s.set("battery:0", "state", "active")
+		s.set("engine-ecu", "state", "on")
+		s.setState(s.unlockedState())
+	})
+}
+
+func (s *Simulator) lock(hibernate bool) {
+	if s.get("vehicle", "state") == "stand-by" {
+		s.setState("stand-by")
+		if hibernate {
+			s.hibernate()
+		}
+		return
+	}
+	s.target = 0
+	s.setState("shutting-down")
+	s.afterTransition(s.opts.Delay, func() {
+		s.standBy(hibernate)
+	})
+}
+
+// standBy parks and locks the scooter
+func (s *Simulator) standBy(hibernate bool) {
+	s.target = 0
+	s.set("vehicle", "kickstand", "down")
+	s.set("vehicle", "blinker:switch", "off")
+	s.set("vehicle", "blinker:state", "off")
+	s.set("vehicle", "handlebar:lock-sensor", "locked")
+	s.set("engine-ecu", "state", "off")
+	s.set("engine-ecu", "speed", "0")
+	s.set("engine-ecu", "rpm", "0")
+	s.set("battery:0", "state", "asleep")
+	s.set("battery:0", "current", "0")
+	s.setState("stand-by")
+	s.updateAlarm()
+	if hibernate {
+		s.hibernate()
+	}
+}
+
+func (s *Simulator) hibernate() {
+	s.afterTransition(s.opts.Delay, func() {
+		s.set("power-manager", "state", "hibernating")
+	})
+}
+
+// unlockedState is the state an unlocked scooter is in, by its kickstand
+func (s *Simulator) unlockedState() string {
+	if s.get("vehicle", "kickstand") == "up" {
+		return "ready-to-drive"
+	}
+	return "parked"
+}
+
+// setState writes the vehicle state, publishing it even if it did not change
+func (s *Simulator) setState(state string) {
+	if s.get("vehicle", "state") != state {
+		s.logf("Vehicle %s", state)
+	}
+	s.write("vehicle", "state", state)
+}
+
+func (s *Simulator) handleSeatbox(command string) {
+	if command != "open" {
+		s.logf("Ignoring unknown seatbox command '%s'", command)
+		return
+	}
+	s.after(s.opts.Delay, func() {
+		s.set("vehicle", "seatbox:lock", "open")
+		s.after(seatboxOpenTime, func() {
+			s.set("vehicle", "seatbox:lock", "closed")
+		})
+	})
+}
+
+func (s *Simulator) handleBlinker(command string) {
+	switch command {
+	case "off", "left", "right", "both":
+	default:
+		s.logf("Ignoring unknown blinker command '%s'", command)
+		return
+	}
+	s.set("vehicle", "blinker:switch", command)
+	if command == "off" {
+		s.set("vehicle", "blinker:state", "off")
+	} else {
+		s.set("vehicle", "blinker:state", "on")
+	}
+}
+
+// handleAlarm triggers the alarm for the given number of seconds (start:<seconds>)
+func (s *Simulator) handleAlarm(command string) {
+	value, ok := strings.CutPrefix(command, "start:")
+	seconds, err := strconv.Atoi(value)
+	if !ok || err != nil || seconds <= 0 {
+		s.logf("Ignoring unknown alarm command '%s'", command)
+		return
+	}
+	previous := s.get("alarm", "status")
+	if previous == "triggered" {
+		return
+	}
+	s.set("alarm", "status", "triggered")
+	s.after(time.Duration(seconds)*time.Second, func() {
+		if s.get("alarm", "status") == "triggered" {
+			s.set("alarm", "status", previous)
+		}
+	})
+}
+
+// updateAlarm arms the alarm when it is enabled and the scooter is in
+// stand-by, and disarms it otherwise
+func (s *Simulator) updateAlarm() {
+	if !s.alarmEnabled || s.get("vehicle", "state") != "stand-by" {
+		s.set("alarm", "status", "disarmed")
+		return
+	}
+	if s.get("alarm", "status") != "disarmed" {
+		return
+	}
+	s.set("alarm", "status", "delay-armed")
+	s.after(4*s.opts.Delay, func() {
+		if s.get("alarm", "status") == "delay-armed" {
+			s.set("alarm", "status", "armed")
+		}
+	})
+}
+
+func (s *Simulator) handlePower(command string) {
+	states := map[string]string{
+		"run":              "running",
+		"suspend":          "suspending",
+		"hibernate":        "hibernating",
+		"hibernate-manual": "hibernating",
+		"hibernate-timer":  "hibernating",
+		"reboot":           "rebooting",
+	}
+	state, ok := states[command]
+	if !ok {
+		s.logf("Ignoring unknown power command '%s'", command)
+		return
+	}
+	s.set("power-manager", "state", state)
+	if command == "reboot" {
+		s.after(4*s.opts.Delay, func() {
+			s.set("power-manager", "state", "running")
+		})
+	}
+}
+
+// handleHardware switches the dashboard, engine and handlebar lock (<part>:<on|off|lock|unlock>)
+func (s *Simulator) handleHardware(command string) {
+	switch command {
+	case "dashboard:on":
+		s.after(s.opts.Delay, func() {
+			s.set("dashboard", "ready", "true")
+		})
+	case "dashboard:off":
+		s.set("dashboard", "ready", "false")
+	case "engine:on":
+		s.set("engine-ecu", "state", "on")
+	case "engine:off":
+		s.set("engine-ecu", "state", "off")
+	case "handlebar:lock":
+		s.after(s.opts.Delay, func() {
+			s.set("vehicle", "handlebar:lock-sensor", "locked")
+		})
+	case "handlebar:unlock":
+		s.after(s.opts.Delay, func() {
+			s.set("vehicle", "handlebar:lock-sensor", "unlocked")
+		})
+	default:
+		s.logf("Ignoring unknown hardware command '%s'", command)
+	}
+}
+
+func (s *Simulator) handleUpdate(command string) {
+	if command != "check-now" {
+		s.logf("Ignoring unknown update command '%s'", command)
+		return
+	}
+	s.set("ota", "status:mdb", "checking")
+	s.after(4*s.opts.Delay, func() {
+		s.set("ota", "status:mdb", "idle")
+	})
+}
+
+// tick updates the ride telemetry once a second. When Ride is set, an
+// unlocked scooter is ridden around at changing speeds.
+func (s *Simulator) tick() {
+	state := s.get("vehicle", "state")
+	if s.opts.Ride && state == "parked" {
+		s.set("vehicle", "kickstand", "up")
+		s.setState("ready-to-drive")
+		state = "ready-to-drive"
+	}
+
+	speed, _ := strconv.ParseFloat(s.get("engine-ecu", "speed"), 64)
+	if state == "ready-to-drive" && s.opts.Ride {
+		if math.Abs(speed-s.target) < 2 {
+			s.target = float64(s.rand.Intn(26))
+		}
+		speed += math.Max(-3, math.Min(3, s.target-speed))
+	} else {
+		speed = 0
+	}
+
+	current := speed * 400 // mA
+	s.odometer += speed / 3.6
+	s.charge = math.Max(0, s.charge-current/1000/3600*2) // about 2% per ampere-hour
+	voltage := 48000 + int(s.charge*70)
+
+	s.set("engine-ecu", "speed", fmt.Sprint(int(speed)))
+	s.set("engine-ecu", "rpm", fmt.Sprint(int(speed*32)))
+	s.set("engine-ecu", "odometer", fmt.Sprint(int(s.odometer)))
+	s.set("engine-ecu", "motor:current", fmt.Sprint(int(current)))
+	s.set("engine-ecu", "motor:voltage", fmt.Sprint(voltage))
+	s.set("engine-ecu", "throttle", fmt.Sprint(speed > 0))
+	// The battery reports the current flowing into it, negative while discharging
+	s.set("battery:0", "current", fmt.Sprint(-int(current)))
+	s.set("battery:0", "voltage", fmt.Sprint(voltage))
+	s.set("battery:0", "charge", fmt.Sprint(int(math.Ceil(s.charge))))
+	s.set("gps", "speed", fmt.Sprint(int(speed)))
+
+	if speed > 0 {
+		// Head north east; a degree of latitude is about 111 km
+		step := speed / 3.6 / 111000 / math.Sqrt2
+		latitude, _ := strconv.ParseFloat(s.get("gps", "latitude"), 64)
+		longitude, _ := strconv.ParseFloat(s.get("gps", "longitude"), 64)
+		s.set("gps", "latitude", strconv.FormatFloat(latitude+step, 'f', 6, 64))
+		s.set("gps", "longitude", strconv.FormatFloat(longitude+step/math.Cos(latitude*math.Pi/180), 'f', 6, 64))
+		s.set("gps", "course", "45")
+	}
+}
+
+// simulatedFaults are the faults injected with FaultInterval
+var simulatedFaults = []struct {
+	group, code, description, set string
+}{
+	{"battery:0", "32", "Battery temperature high", "battery:0:faults"},
+	{"battery:0", "35", "Battery cell voltage imbalance", "battery:0:faults"},
+	{"ecu", "12", "Motor controller overcurrent", "vehicle:fault"},
+	{"vehicle", "7", "Brake sensor implausible", "vehicle:fault"},
+}
+
+// injectFault raises a random fault: it is added to its fault set, reported
+// on events:faults, and cleared again after faultDuration
+func (s *Simulator) injectFault() {
+	fault := simulatedFaults[s.rand.Intn(len(simulatedFaults))]
+	if err := s.client.SAddWithContext(s.ctx, fault.set, fault.code); err != nil {
+		s.logf("Raising fault %s failed: %v", fault.code, err)
+		return
+	}
+	_, err := s.client.XAddWithContext(s.ctx, "events:faults", 1000, map[string]interface{}{
+		"group":       fault.group,
+		"code":        fault.code,
+		"description": fault.description,
+	})
+	if err != nil {
+		s.logf("Adding fault event failed: %v", err)
+	}
+	s.logf("Fault %s %s: %s", fault.group, fault.code, fault.description)
+
+	s.after(faultDuration, func() {
+		s.client.SRemWithContext(s.ctx, fault.set, fault.code)
+	})
+}
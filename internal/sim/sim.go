@@ -0,0 +1,318 @@
+// Package sim simulates the scooter services behind Redis (vehicle, battery,
+// engine ECU, alarm and power manager), so lsc can be used without a scooter.
+package sim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"time"
+
+	"librescoot/lsc/internal/redis"
+)
+
+// Environment is written to system.environment, so a simulated Redis can be
+// told apart from a real scooter
+const Environment = "simulator"
+
+// commandLists are the lists the services pop their commands from
+var commandLists = []string{
+	"scooter:state", "scooter:seatbox", "scooter:blinker", "scooter:horn",
+	"scooter:alarm", "scooter:power", "scooter:hardware", "scooter:update",
+}
+
+// Options configures a Simulator
+type Options struct {
+	// Delay is how long the simulated hardware takes to react (default 500ms)
+	Delay time.Duration
+	// FaultInterval injects a random fault this often; zero disables faults
+	FaultInterval time.Duration
+	// Ride has a simulated rider take the scooter for a ride whenever it is unlocked
+	Ride bool
+	// Log receives a line per command and state change; nil discards them
+	Log io.Writer
+}
+
+// Simulator owns the simulated state. All state changes happen on the
+// goroutine running Run, so no locking is needed.
+type Simulator struct {
+	client *redis.Client
+	opts   Options
+	rand   *rand.Rand
+
+	ctx       context.Context
+	hashes    map[string]map[string]string
+	scheduled chan func()
+
+	// transition is bumped by every vehicle state command, so delayed steps
+	// of an overtaken command are dropped
+	transition int
+
+	alarmEnabled bool
+	charge       float64 // battery:0 charge in percent, with fractions
+	odometer     float64 // meters
+	target       float64 // speed the simulated rider is heading for
+}
+
+// New creates a simulator writing to client
+func New(client *redis.Client, opts Options) *Simulator {
+	if opts.Delay <= 0 {
+		opts.Delay = 500 * time.Millisecond
+	}
+	if opts.Log == nil {
+		opts.Log = io.Discard
+	}
+	return &Simulator{
+		client:    client,
+		opts:      opts,
+		rand:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		hashes:    make(map[string]map[string]string),
+		scheduled: make(chan func(), 16),
+	}
+}
+
+// Run writes the initial state and serves commands until ctx is cancelled
+func (s *Simulator) Run(ctx context.Context) error {
+	s.ctx = ctx
+	settings := s.client.Subscribe(ctx, "settings")
+	defer settings.Close()
+	if _, err := settings.Receive(ctx); err != nil {
+		return fmt.Errorf("failed to subscribe to settings: %w", err)
+	}
+	if err := s.reset(); err != nil {
+		return err
+	}
+	s.updateAlarm()
+
+	commands := make(chan [2]string)
+	go s.popCommands(commands)
+
+	telemetry := time.NewTicker(time.Second)
+	defer telemetry.Stop()
+	var faults <-chan time.Time
+	if s.opts.FaultInterval > 0 {
+		ticker := time.NewTicker(s.opts.FaultInterval)
+		defer ticker.Stop()
+		faults = ticker.C
+	}
+
+	messages := settings.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case command := <-commands:
+			s.handle(command[0], command[1])
+		case fn := <-s.scheduled:
+			fn()
+		case msg := <-messages:
+			if msg.Payload == "alarm.enabled" {
+				s.loadSettings()
+				s.updateAlarm()
+			}
+		case <-telemetry.C:
+			s.tick()
+		case <-faults:
+			s.injectFault()
+		}
+	}
+}
+
+// popCommands forwards commands from the command lists to the event loop
+func (s *Simulator) popCommands(commands chan<- [2]string) {
+	for s.ctx.Err() == nil {
+		list, value, err := s.client.BRPopWithContext(s.ctx, time.Second, commandLists...)
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			if s.ctx.Err() == nil {
+				s.logf("Reading commands failed: %v", err)
+				s.sleep(time.Second)
+			}
+			continue
+		}
+		select {
+		case commands <- [2]string{list, value}:
+		case <-s.ctx.Done():
+		}
+	}
+}
+
+// reset replaces the service hashes with a locked, parked scooter
+func (s *Simulator) reset() error {
+	initial := map[string]map[string]string{
+		"vehicle": {
+			"state":                 "stand-by",
+			"kickstand":             "down",
+			"brake:left":            "off",
+			"brake:right":           "off",
+			"blinker:switch":        "off",
+			"blinker:state":         "off",
+			"seatbox:lock":          "closed",
+			"seatbox:button":        "off",
+			"handlebar:lock-sensor": "locked",
+			"horn:button":           "off",
+		},
+		"engine-ecu": {
+			"state":         "off",
+			"speed":         "0",
+			"rpm":           "0",
+			"odometer":      "1234500",
+			"motor:voltage": "54000",
+			"motor:current": "0",
+			"temperature":   "22",
+			"throttle":      "false",
+			"kers":          "true",
+			"fw-version":    "0445400C",
+		},
+		"battery:0": battery(true, "asleep", "80"),
+		"battery:1": battery(false, "unknown", "0"),
+		"alarm":     {"status": "disarmed"},
+		"power-manager": {
+			"state":         "running",
+			"wakeup-source": "none",
+		},
+		"gps": {
+			"state":     "fix-established",
+			"latitude":  "52.520008",
+			"longitude": "13.404954",
+			"altitude":  "34.0",
+			"speed":     "0",
+			"course":    "0",
+			"fix":       "3d",
+			"quality":   "1",
+			"hdop":      "0.9",
+			"active":    "1",
+			"connected": "1",
+		},
+		"internet": {
+			"status":         "connected",
+			"modem-state":    "connected",
+			"signal-quality": "70",
+			"access-tech":    "LTE",
+		},
+		"system": {
+			"mdb-version":    "sim",
+			"dbc-version":    "sim",
+			"nrf-fw-version": "sim",
+			"environment":    Environment,
+		},
+		"ota":       {"system": "mdb", "status": "idle", "status:mdb": "idle", "fresh-update": "false"},
+		"dashboard": {"ready": "false"},
+	}
+
+	// Commands queued while no service was listening are dropped, like a
+	// scooter that just booted would never see them
+	keys := append([]string{"vehicle:fault", "battery:0:faults", "battery:1:faults"}, commandLists...)
+	for hash := range initial {
+		keys = append(keys, hash)
+	}
+	if err := s.client.DelWithContext(s.ctx, keys...); err != nil {
+		return fmt.Errorf("failed to reset the simulated state: %w", err)
+	}
+	for hash, fields := range initial {
+		for field, value := range fields {
+			if err := s.client.HSetWithContext(s.ctx, hash, field, value); err != nil {
+				return fmt.Errorf("failed to write %s: %w", hash, err)
+			}
+		}
+		s.hashes[hash] = fields
+	}
+
+	// Settings belong to the user, so only defaults are filled in
+	if _, err := s.client.HGetWithContext(s.ctx, "settings", "alarm.enabled"); errors.Is(err, redis.Nil) {
+		s.client.HSetWithContext(s.ctx, "settings", "alarm.enabled", "false")
+	}
+	s.loadSettings()
+	s.charge = 80
+	s.odometer = 1234500
+	return nil
+}
+
+func battery(present bool, state, charge string) map[string]string {
+	return map[string]string{
+		"present":           fmt.Sprint(present),
+		"state":             state,
+		"charge":            charge,
+		"voltage":           "53200",
+		"current":           "0",
+		"temperature:0":     "21",
+		"temperature:1":     "21",
+		"temperature:2":     "22",
+		"temperature:3":     "22",
+		"temperature-state": "ideal",
+		"cycle-count":       "87",
+		"state-of-health":   "98",
+		"fw-version":        "sim",
+		"serial-number":     "SIM0000000001",
+	}
+}
+
+func (s *Simulator) loadSettings() {
+	value, err := s.client.HGetWithContext(s.ctx, "settings", "alarm.enabled")
+	s.alarmEnabled = err == nil && value == "true"
+}
+
+// get returns a field of the simulated state
+func (s *Simulator) get(hash, field string) string {
+	return s.hashes[hash][field]
+}
+
+// set writes a field and, if it changed, publishes the field name on the
+// hash's channel like the real services do
+func (s *Simulator) set(hash, field, value string) {
+	if s.get(hash, field) == value {
+		return
+	}
+	s.write(hash, field, value)
+}
+
+// write writes and publishes a field even if it did not change
+func (s *Simulator) write(hash, field, value string) {
+	if s.hashes[hash] == nil {
+		s.hashes[hash] = make(map[string]string)
+	}
+	s.hashes[hash][field] = value
+	if err := s.client.HSetWithContext(s.ctx, hash, field, value); err != nil {
+		s.logf("Writing %s %s failed: %v", hash, field, err)
+		return
+	}
+	if err := s.client.Publish(s.ctx, hash, field); err != nil {
+		s.logf("Publishing %s %s failed: %v", hash, field, err)
+	}
+}
+
+// after runs fn on the event loop once d has passed
+func (s *Simulator) after(d time.Duration, fn func()) {
+	time.AfterFunc(d, func() {
+		select {
+		case s.scheduled <- fn:
+		case <-s.ctx.Done():
+		}
+	})
+}
+
+// afterTransition is like after, but drops fn if another vehicle state
+// command arrives in the meantime
+func (s *Simulator) afterTransition(d time.Duration, fn func()) {
+	transition := s.transition
+	s.after(d, func() {
+		if s.transition == transition {
+			fn()
+		}
+	})
+}
+
+func (s *Simulator) sleep(d time.Duration) {
+	select {
+	case <-time.After(d):
+	case <-s.ctx.Done():
+	}
+}
+
+func (s *Simulator) logf(format string, args ...interface{}) {
+	fmt.Fprintf(s.opts.Log, "%s %s\n", time.Now().Format("15:04:05"), fmt.Sprintf(format, args...))
+}
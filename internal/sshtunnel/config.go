@@ -0,0 +1,120 @@
+package sshtunnel
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// HostConfig holds the ssh_config(5) settings lsc understands for a host
+type HostConfig struct {
+	HostName      string
+	User          string
+	Port          string
+	IdentityFiles []string
+}
+
+// LookupHost resolves alias against ~/.ssh/config. Like ssh, the first value
+// obtained for each keyword wins. Missing config files are not an error.
+func LookupHost(alias string) (*HostConfig, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return &HostConfig{}, nil
+	}
+
+	file, err := os.Open(filepath.Join(home, ".ssh", "config"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &HostConfig{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	hc := &HostConfig{}
+	matching := true // keywords before the first Host line apply to all hosts
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		keyword, value := splitConfigLine(scanner.Text())
+		if keyword == "" {
+			continue
+		}
+
+		switch keyword {
+		case "host":
+			matching = matchHost(alias, strings.Fields(value))
+		case "match":
+			// Match blocks are not supported; skip their contents
+			matching = false
+		case "hostname":
+			if matching && hc.HostName == "" {
+				hc.HostName = value
+			}
+		case "user":
+			if matching && hc.User == "" {
+				hc.User = value
+			}
+		case "port":
+			if matching && hc.Port == "" {
+				hc.Port = value
+			}
+		case "identityfile":
+			if matching {
+				hc.IdentityFiles = append(hc.IdentityFiles, expandHome(value, home))
+			}
+		}
+	}
+
+	return hc, scanner.Err()
+}
+
+// splitConfigLine returns the lower-cased keyword and its value, handling
+// both "Keyword value" and "Keyword=value" forms
+func splitConfigLine(line string) (string, string) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", ""
+	}
+
+	idx := strings.IndexAny(line, " \t=")
+	if idx < 0 {
+		return strings.ToLower(line), ""
+	}
+	keyword := strings.ToLower(line[:idx])
+	value := strings.TrimSpace(line[idx:])
+	value = strings.TrimSpace(strings.TrimPrefix(value, "="))
+	value = strings.Trim(value, `"`)
+	return keyword, value
+}
+
+// matchHost reports whether alias matches the patterns of a Host line.
+// A negated pattern (!pattern) that matches rejects the whole line.
+func matchHost(alias string, patterns []string) bool {
+	matched := false
+	for _, pattern := range patterns {
+		negate := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		ok, err := path.Match(pattern, alias)
+		if err != nil || !ok {
+			continue
+		}
+		if negate {
+			return false
+		}
+		matched = true
+	}
+	return matched
+}
+
+func expandHome(p, home string) string {
+	if p == "~" {
+		return home
+	}
+	if strings.HasPrefix(p, "~/") {
+		return filepath.Join(home, p[2:])
+	}
+	return strings.ReplaceAll(p, "%d", home)
+}
@@ -0,0 +1,197 @@
+package sshtunnel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Tunnel is an SSH connection to a scooter used to reach its Redis server
+// and to run commands remotely
+type Tunnel struct {
+	client *ssh.Client
+	target string
+}
+
+// Dial opens an SSH connection to target, which is an ssh config alias or
+// [user@]host[:port]. Keys are taken from the SSH agent and the configured
+// (or default) identity files; the host key must be in ~/.ssh/known_hosts.
+func Dial(target string, timeout time.Duration) (*Tunnel, error) {
+	userName, host, port := splitTarget(target)
+
+	hc, err := LookupHost(host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ssh config: %w", err)
+	}
+	if hc.HostName != "" {
+		host = hc.HostName
+	}
+	if userName == "" {
+		userName = hc.User
+	}
+	if userName == "" {
+		if u, err := user.Current(); err == nil {
+			userName = u.Username
+		}
+	}
+	if port == "" {
+		port = hc.Port
+	}
+	if port == "" {
+		port = "22"
+	}
+
+	home, _ := os.UserHomeDir()
+	hostKeyCallback, err := knownhosts.New(filepath.Join(home, ".ssh", "known_hosts"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load known_hosts: %w", err)
+	}
+
+	auth, err := authMethods(hc.IdentityFiles, home)
+	if err != nil {
+		return nil, err
+	}
+
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	addr := net.JoinHostPort(host, port)
+	client, err := ssh.Dial("tcp", addr, &ssh.ClientConfig{
+		User:            userName,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         timeout,
+	})
+	if err != nil {
+		var keyErr *knownhosts.KeyError
+		if errors.As(err, &keyErr) && len(keyErr.Want) == 0 {
+			return nil, fmt.Errorf("host key for %s is not in known_hosts (connect once with 'ssh %s' to add it)", addr, target)
+		}
+		return nil, fmt.Errorf("ssh connection to %s failed: %w", target, err)
+	}
+
+	return &Tunnel{client: client, target: target}, nil
+}
+
+// Target returns the host the tunnel was opened to, as given to Dial
+func (t *Tunnel) Target() string {
+	return t.target
+}
+
+// Client returns the underlying SSH client
+func (t *Tunnel) Client() *ssh.Client {
+	return t.client
+}
+
+// Close closes the SSH connection and every connection forwarded through it
+func (t *Tunnel) Close() error {
+	return t.client.Close()
+}
+
+// DialContext opens a connection to addr as seen from the remote host. It
+// matches the signature of go-redis' Options.Dialer.
+//
+// SSH channels do not support deadlines, which go-redis relies on for read
+// timeouts, so the channel is bridged through an in-memory pipe that does.
+func (t *Tunnel) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		conn, err := t.client.Dial(network, addr)
+		done <- result{conn, err}
+	}()
+
+	var remote net.Conn
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return nil, r.err
+		}
+		remote = r.conn
+	case <-ctx.Done():
+		go func() {
+			if r := <-done; r.conn != nil {
+				r.conn.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+
+	local, bridge := net.Pipe()
+	go func() {
+		io.Copy(remote, bridge)
+		remote.Close()
+	}()
+	go func() {
+		io.Copy(bridge, remote)
+		bridge.Close()
+	}()
+	return local, nil
+}
+
+// splitTarget splits [user@]host[:port]
+func splitTarget(target string) (userName, host, port string) {
+	host = target
+	if idx := strings.LastIndex(host, "@"); idx >= 0 {
+		userName = host[:idx]
+		host = host[idx+1:]
+	}
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		host, port = h, p
+	}
+	return userName, host, port
+}
+
+// authMethods collects the SSH agent and any unencrypted identity files
+func authMethods(identityFiles []string, home string) ([]ssh.AuthMethod, error) {
+	var methods []ssh.AuthMethod
+
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+
+	if len(identityFiles) == 0 {
+		for _, name := range []string{"id_ed25519", "id_ecdsa", "id_rsa"} {
+			identityFiles = append(identityFiles, filepath.Join(home, ".ssh", name))
+		}
+	}
+
+	var signers []ssh.Signer
+	for _, path := range identityFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		signer, err := ssh.ParsePrivateKey(data)
+		if err != nil {
+			// Passphrase-protected keys are only usable through the agent
+			continue
+		}
+		signers = append(signers, signer)
+	}
+	if len(signers) > 0 {
+		methods = append(methods, ssh.PublicKeys(signers...))
+	}
+
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("no SSH credentials available: start ssh-agent or configure an unencrypted IdentityFile")
+	}
+	return methods, nil
+}
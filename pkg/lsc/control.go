@@ -0,0 +1,36 @@
+package lsc
+
+import (
+	"context"
+
+	"librescoot/lsc/internal/confirm"
+)
+
+// Lock locks the scooter and waits until it is in stand-by
+func (s *Scooter) Lock(ctx context.Context) error {
+	_, err := s.sendState(ctx, "lock", "stand-by")
+	return err
+}
+
+// Unlock unlocks the scooter and waits until it is parked or ready to
+// drive. It returns the state the scooter is in.
+func (s *Scooter) Unlock(ctx context.Context) (string, error) {
+	return s.sendState(ctx, "unlock", "parked", "ready-to-drive")
+}
+
+// sendState sends a vehicle state command and waits for one of the
+// accepted states
+func (s *Scooter) sendState(ctx context.Context, command string, accept ...string) (string, error) {
+	result, err := confirm.Run(ctx, s.client, confirm.Command{
+		List:    "scooter:state",
+		Payload: command,
+		Hash:    "vehicle",
+		Field:   "state",
+		Accept:  accept,
+		Timeout: s.confirmTimeout,
+	})
+	if err != nil {
+		return "", err
+	}
+	return result.Value, nil
+}
@@ -0,0 +1,116 @@
+// Package lsc controls and reads LibreScoot scooters from Go programs, the
+// way the lsc command does, without going through the command line.
+//
+//	scooter, err := lsc.Connect(ctx, lsc.Options{SSH: "deep-blue"})
+//	if err != nil {
+//		return err
+//	}
+//	defer scooter.Close()
+//	err = scooter.Lock(ctx)
+package lsc
+
+import (
+	"context"
+	"time"
+
+	"librescoot/lsc/internal/output"
+	"librescoot/lsc/internal/redis"
+	"librescoot/lsc/internal/sshtunnel"
+)
+
+// scooterRedisAddr is where Redis listens on the scooter's MDB
+const scooterRedisAddr = "192.168.7.1:6379"
+
+// defaultConfirmTimeout bounds how long control methods wait for the
+// scooter to confirm a command
+const defaultConfirmTimeout = 10 * time.Second
+
+// Options configures how Connect reaches a scooter
+type Options struct {
+	// Addr is the Redis address (host:port). It defaults to localhost:6379,
+	// or to the scooter's Redis when SSH is set.
+	Addr     string
+	Username string
+	Password string
+	DB       int
+
+	// SSH tunnels the connection through this host (ssh config alias or
+	// user@host[:port])
+	SSH string
+
+	// ConnectTimeout bounds connecting to SSH and Redis (default 5s)
+	ConnectTimeout time.Duration
+	// ConfirmTimeout bounds how long Lock and Unlock wait for the new state (default 10s)
+	ConfirmTimeout time.Duration
+}
+
+// Scooter is a connection to one scooter's Redis. It is safe for concurrent use.
+type Scooter struct {
+	client         *redis.Client
+	tunnel         *sshtunnel.Tunnel
+	confirmTimeout time.Duration
+}
+
+// Connect opens a connection to a scooter and checks that its Redis responds
+func Connect(ctx context.Context, opts Options) (*Scooter, error) {
+	if opts.ConnectTimeout <= 0 {
+		opts.ConnectTimeout = 5 * time.Second
+	}
+	if opts.ConfirmTimeout <= 0 {
+		opts.ConfirmTimeout = defaultConfirmTimeout
+	}
+
+	redisOpts := redis.Options{
+		Addr:           opts.Addr,
+		Username:       opts.Username,
+		Password:       opts.Password,
+		DB:             opts.DB,
+		ConnectTimeout: opts.ConnectTimeout,
+	}
+	s := &Scooter{confirmTimeout: opts.ConfirmTimeout}
+	if opts.SSH != "" {
+		tunnel, err := sshtunnel.Dial(opts.SSH, opts.ConnectTimeout)
+		if err != nil {
+			return nil, output.Connection("failed to open SSH tunnel: %w", err)
+		}
+		s.tunnel = tunnel
+		redisOpts.Dialer = tunnel.DialContext
+		if redisOpts.Addr == "" {
+			redisOpts.Addr = scooterRedisAddr
+		}
+	}
+	if redisOpts.Addr == "" {
+		redisOpts.Addr = "localhost:6379"
+	}
+
+	client, err := redis.NewClientWithOptions(redisOpts)
+	if err != nil {
+		s.Close()
+		return nil, output.InvalidArgument("%w", err)
+	}
+	s.client = client
+	if err := client.Connect(); err != nil {
+		s.Close()
+		return nil, output.Connection("%w", err)
+	}
+	return s, nil
+}
+
+// Close closes the Redis connection and the SSH tunnel
+func (s *Scooter) Close() error {
+	var err error
+	if s.client != nil {
+		err = s.client.Close()
+	}
+	if s.tunnel != nil {
+		s.tunnel.Close()
+	}
+	return err
+}
+
+// Code returns the failure class of an error returned by this package, the
+// same code lsc reports in its JSON output: invalid_argument, connection,
+// timeout, rejected, precondition_failed, permission_denied or error
+func Code(err error) string {
+	return output.Code(err)
+}
@@ -0,0 +1,82 @@
+package lsc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"librescoot/lsc/internal/redis"
+	"librescoot/lsc/internal/sim"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// TestLockUnlock drives the simulated scooter through the library
+func TestLockUnlock(t *testing.T) {
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(srv.Addr())
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sim.New(client, sim.Options{Delay: 20 * time.Millisecond}).Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		client.Close()
+	})
+	deadline := time.Now().Add(5 * time.Second)
+	for srv.HGet("system", "environment") != sim.Environment {
+		if time.Now().After(deadline) {
+			t.Fatal("simulator did not start")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	scooter, err := Connect(context.Background(), Options{Addr: srv.Addr(), ConfirmTimeout: 2 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer scooter.Close()
+
+	state, err := scooter.Unlock(context.Background())
+	if err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	if state != "parked" {
+		t.Errorf("unlocked state is %q, want parked", state)
+	}
+
+	if err := scooter.Lock(context.Background()); err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	status, err := scooter.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.State != "stand-by" {
+		t.Errorf("state is %q, want stand-by", status.State)
+	}
+	if len(status.Batteries) != 2 || !status.Batteries[0].Present {
+		t.Errorf("batteries = %+v, want battery 0 present", status.Batteries)
+	}
+}
+
+// TestConfirmTimeout checks that an unanswered command fails with a timeout
+func TestConfirmTimeout(t *testing.T) {
+	srv := miniredis.RunT(t)
+	scooter, err := Connect(context.Background(), Options{Addr: srv.Addr(), ConfirmTimeout: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer scooter.Close()
+
+	err = scooter.Lock(context.Background())
+	if code := Code(err); code != "timeout" {
+		t.Fatalf("lock without a scooter: %v (%s), want timeout", err, code)
+	}
+	if got, _ := srv.List("scooter:state"); len(got) != 1 || got[0] != "lock" {
+		t.Errorf("scooter:state = %v, want [lock]", got)
+	}
+}
@@ -0,0 +1,97 @@
+package lsc
+
+import (
+	"context"
+	"fmt"
+
+	"librescoot/lsc/internal/model"
+)
+
+// Status is a snapshot of the scooter's state
+type Status struct {
+	// State is the vehicle state, e.g. stand-by, parked or ready-to-drive
+	State     string
+	Kickstand string
+	Seatbox   string
+	Blinker   string
+	// Speed is in km/h, Odometer in km
+	Speed    int
+	Odometer float64
+	// Alarm is the alarm status, e.g. disarmed or armed
+	Alarm string
+	// Power is the power manager state, e.g. running or suspending
+	Power     string
+	Batteries []Battery
+}
+
+// Battery is the state of one main battery slot
+type Battery struct {
+	Slot    int
+	Present bool
+	State   string
+	// Charge and Health are in percent, Voltage in V, Current in A and
+	// Temperature in °C
+	Charge      int
+	Voltage     float64
+	Current     float64
+	Temperature int
+	Cycles      int
+	Health      int
+}
+
+// Status reads the vehicle, engine, alarm, power manager and battery state
+func (s *Scooter) Status(ctx context.Context) (*Status, error) {
+	vehicleData, err := s.client.HGetAllWithContext(ctx, model.VehicleKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch vehicle data: %w", err)
+	}
+	ecuData, err := s.client.HGetAllWithContext(ctx, model.EngineECUKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch ECU data: %w", err)
+	}
+	// Alarm and power manager are left empty when their services are not running
+	alarmData, _ := s.client.HGetAllWithContext(ctx, model.AlarmKey)
+	powerData, _ := s.client.HGetAllWithContext(ctx, model.PowerManagerKey)
+	batteries, err := s.Batteries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	vehicle := model.ParseVehicle(vehicleData)
+	ecu := model.ParseEngineECU(ecuData)
+	return &Status{
+		State:     vehicle.State,
+		Kickstand: vehicle.Kickstand,
+		Seatbox:   vehicle.SeatboxLock,
+		Blinker:   vehicle.BlinkerSwitch,
+		Speed:     ecu.Speed,
+		Odometer:  ecu.Odometer,
+		Alarm:     model.ParseAlarm(alarmData).Status,
+		Power:     model.ParsePowerManager(powerData).State,
+		Batteries: batteries,
+	}, nil
+}
+
+// Batteries reads both main battery slots
+func (s *Scooter) Batteries(ctx context.Context) ([]Battery, error) {
+	batteries := make([]Battery, 0, len(model.BatterySlots))
+	for _, slot := range model.BatterySlots {
+		data, err := s.client.HGetAllWithContext(ctx, model.BatteryKey(slot))
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch battery:%d data: %w", slot, err)
+		}
+		b := model.ParseBattery(slot, data)
+		batteries = append(batteries, Battery{
+			Slot:        slot,
+			Present:     b.Present,
+			State:       b.State,
+			Charge:      b.Charge,
+			Voltage:     b.Voltage,
+			Current:     b.Current,
+			Temperature: b.Temperatures[0],
+			Cycles:      b.CycleCount,
+			Health:      b.StateOfHealth,
+		})
+	}
+	return batteries, nil
+}